@@ -0,0 +1,80 @@
+package ffmpeg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidOutput is returned, wrapping the error of validate,
+// when AtomicOutput rejected an output.
+var ErrInvalidOutput = errors.New("ffmpeg: invalid output")
+
+var atomicRe = regexp.MustCompile(`^\.ffmpeg-[0-9a-f]{8}-(.+)$`)
+
+// AtomicOutput makes FFmpeg write the output files to temporary
+// names in the same directories, renamed into place once FFmpeg
+// exited successfully and validate, if not nil, accepted them.
+// The temporary files are removed otherwise. Existing outputs
+// are replaced, as with -y.
+//
+// Outputs with a protocol, "-" and patterns such as "%03d.png"
+// are written as is.
+func AtomicOutput(validate func(path string) error) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.atomic = true
+		r.validate = validate
+		r.rewrites = append(r.rewrites, atomicArgs)
+	}
+}
+
+func atomicArgs(args []string) []string {
+	args = append([]string(nil), args...)
+	for _, i := range outputs(args) {
+		o := args[i]
+		if o == "-" || strings.Contains(o, "%") || schemeRe.MatchString(o) {
+			continue
+		}
+		b := make([]byte, 4)
+		rand.Read(b)
+		args[i] = filepath.Join(filepath.Dir(o), ".ffmpeg-"+hex.EncodeToString(b)+"-"+filepath.Base(o))
+	}
+	return args
+}
+
+// finishAtomic renames the temporary outputs of cmd into place
+// if err is nil and they are all valid, or removes them, and
+// returns the error of the run or of the outputs.
+func (r *HookedRunner) finishAtomic(cmd *exec.Cmd, err error) error {
+	var temps, finals []string
+	for _, a := range cmd.Args[1:] {
+		if m := atomicRe.FindStringSubmatch(filepath.Base(a)); m != nil {
+			temps = append(temps, a)
+			finals = append(finals, filepath.Join(filepath.Dir(a), m[1]))
+		}
+	}
+
+	for i, t := range temps {
+		if err != nil || r.validate == nil {
+			break
+		}
+		if verr := r.validate(t); verr != nil {
+			err = fmt.Errorf("%w %s: %w", ErrInvalidOutput, finals[i], verr)
+		}
+	}
+	for i, t := range temps {
+		if err == nil {
+			err = os.Rename(t, finals[i])
+		}
+		if err != nil {
+			os.Remove(t)
+		}
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// fakeWrite writes the last arg, failing if asked to.
+const fakeWrite = `for out; do :; done
+echo media > "$out"
+case "$*" in *-xerror*) exit 1;; esac
+`
+
+func TestAtomicOutput(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.mp4")
+	p := fakeBinary(t, fakeWrite)
+
+	var validated string
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.AtomicOutput(func(path string) error {
+		validated = path
+		if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("output in place before validation: %v", err)
+		}
+		return nil
+	}))
+	if err := r.Run(context.TODO(), "-i in.mp4 "+out); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(validated) != dir || validated == out {
+		t.Errorf("unexpected temporary output %s", validated)
+	}
+	if b, err := os.ReadFile(out); err != nil || string(b) != "media\n" {
+		t.Errorf("unexpected output %q: %v", b, err)
+	}
+	assertOnly(t, dir, "out.mp4")
+}
+
+func TestAtomicOutputFailure(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.mp4")
+	p := fakeBinary(t, fakeWrite)
+
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.AtomicOutput(nil))
+	if err := r.Run(context.TODO(), "-xerror -i in.mp4 "+out); err == nil {
+		t.Error("want error")
+	}
+	assertOnly(t, dir)
+
+	invalid := errors.New("no video stream")
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.AtomicOutput(func(string) error { return invalid }))
+	if err := r.Run(context.TODO(), "-i in.mp4 "+out); !errors.Is(err, invalid) {
+		t.Errorf("want validation error, got %v", err)
+	}
+	assertOnly(t, dir)
+}
+
+// assertOnly checks dir only has the files names.
+func assertOnly(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if len(got) != len(names) {
+		t.Errorf("want %q in %s, got %q", names, dir, got)
+		return
+	}
+	for i := range got {
+		if got[i] != names[i] {
+			t.Errorf("want %q in %s, got %q", names, dir, got)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MixAudio returns the arg mixing the first audio stream of
+// inputs into output, each scaled by its weight (1 if weights
+// is shorter). As amix, the mix is divided by the number of
+// inputs.
+func MixAudio(inputs []string, weights []float64, output string) string {
+	args := []string{"-y"}
+	var graph, pads strings.Builder
+	for i, in := range inputs {
+		args = append(args, "-i", in)
+		w := 1.0
+		if i < len(weights) {
+			w = weights[i]
+		}
+		fmt.Fprintf(&graph, "[%d:a:0]volume=%s[a%d];", i, strconv.FormatFloat(w, 'f', -1, 64), i)
+		fmt.Fprintf(&pads, "[a%d]", i)
+	}
+	fmt.Fprintf(&graph, "%samix=inputs=%d:duration=longest:dropout_transition=0[a]", pads.String(), len(inputs))
+	return strings.Join(append(args, "-filter_complex", graph.String(), "-map [a]", output), " ")
+}
+
+// downmixes are the pan filters downmixing the layouts to stereo
+// with the ITU-R BS.775 coefficients, the LFE being dropped.
+var downmixes = map[string]string{
+	"5.1":       "pan=stereo|FL<FL+0.707*FC+0.707*BL|FR<FR+0.707*FC+0.707*BR",
+	"5.1(side)": "pan=stereo|FL<FL+0.707*FC+0.707*SL|FR<FR+0.707*FC+0.707*SR",
+	"7.1":       "pan=stereo|FL<FL+0.707*FC+0.707*SL+0.707*BL|FR<FR+0.707*FC+0.707*SR+0.707*BR",
+}
+
+// DownmixStereo returns the arg downmixing the first audio
+// stream of input, of the channel layout reported by Probe, e.g.
+// "5.1(side)", to stereo into output. Other streams are copied.
+func DownmixStereo(input, layout, output string) (string, error) {
+	pan, ok := downmixes[layout]
+	if !ok {
+		return "", fmt.Errorf("ffmpeg: no downmix for channel layout %q", layout)
+	}
+	return strings.Join([]string{"-y -i", input, "-map 0:v? -map 0:a:0 -c copy -c:a aac -af", pan, output}, " "), nil
+}
+
+// SplitStereo returns the arg splitting the stereo first audio
+// stream of input into the mono outputs left and right.
+func SplitStereo(input, left, right string) string {
+	return strings.Join([]string{"-y -i", input,
+		"-filter_complex [0:a:0]channelsplit=channel_layout=stereo[l][r]",
+		"-map [l]", left, "-map [r]", right}, " ")
+}
+
+// MergeMono returns the arg merging the first audio stream of
+// mono inputs into the channels of output, in order, e.g. the
+// left and right channels of a stereo output.
+func MergeMono(inputs []string, output string) string {
+	args := []string{"-y"}
+	var graph strings.Builder
+	for i, in := range inputs {
+		args = append(args, "-i", in)
+		fmt.Fprintf(&graph, "[%d:a:0]", i)
+	}
+	fmt.Fprintf(&graph, "amerge=inputs=%d[a]", len(inputs))
+	return strings.Join(append(args, "-filter_complex", graph.String(), "-map [a]", output), " ")
+}
+
+// ExtractAudio returns the arg extracting the audio stream
+// track (from 0) of input into its own output, copied unless
+// args, e.g. "-c:a libmp3lame -q:a 2" when the codec doesn't fit
+// the container of output.
+func ExtractAudio(input string, track int, args, output string) string {
+	if args == "" {
+		args = "-c copy"
+	}
+	return strings.Join([]string{"-y -i", input, "-map", "0:a:" + strconv.Itoa(track), "-vn", args, output}, " ")
+}
+
+// An AudioTrack is an audio file set on a video, see ReplaceAudio.
+type AudioTrack struct {
+	Path     string
+	Offset   time.Duration // delays the audio, or skips its start if negative
+	Language string        // e.g. "eng"
+	Codec    string        // "aac" by default, "copy" to copy it
+
+	// Shortest ends the output with the shorter of the video and
+	// the audio, and Pad pads a shorter audio with silence up to
+	// the end of the video, unless copied.
+	Shortest bool
+	Pad      bool
+}
+
+// ReplaceAudio returns the arg writing video to output with the
+// first audio stream of a instead of its own audio, or as the
+// first and default audio stream before them if keep. No stream
+// of video is re-encoded.
+func ReplaceAudio(video string, a AudioTrack, keep bool, output string) string {
+	codec := a.Codec
+	if codec == "" {
+		codec = "aac"
+	}
+	args := []string{"-y -i", video}
+	var filters []string
+	switch {
+	case a.Offset < 0:
+		args = append(args, "-ss", seconds(-a.Offset))
+	case a.Offset > 0 && codec == "copy":
+		args = append(args, "-itsoffset", seconds(a.Offset))
+	case a.Offset > 0:
+		filters = append(filters, "adelay="+strconv.FormatInt(a.Offset.Milliseconds(), 10)+":all=1")
+	}
+	args = append(args, "-i", a.Path, "-map 0:v -map 1:a:0")
+	if keep {
+		args = append(args, "-map 0:a?")
+	}
+	args = append(args, "-c copy -c:a:0", codec)
+	if a.Pad && codec != "copy" {
+		filters = append(filters, "apad")
+	}
+	if len(filters) > 0 {
+		args = append(args, "-filter:a:0", strings.Join(filters, ","))
+	}
+	if a.Language != "" {
+		args = append(args, "-metadata:s:a:0", "language="+a.Language)
+	}
+	if keep {
+		args = append(args, "-disposition:a:0 default")
+	}
+	if a.Shortest || a.Pad && codec != "copy" {
+		args = append(args, "-shortest")
+	}
+	return strings.Join(append(args, output), " ")
+}
@@ -0,0 +1,67 @@
+package ffmpeg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestMixAudio(t *testing.T) {
+	a := ffmpeg.MixAudio([]string{"voice.wav", "music.mp3"}, []float64{1, 0.25}, "mix.m4a")
+	want := "-y -i voice.wav -i music.mp3 -filter_complex " +
+		"[0:a:0]volume=1[a0];[1:a:0]volume=0.25[a1];[a0][a1]amix=inputs=2:duration=longest:dropout_transition=0[a] " +
+		"-map [a] mix.m4a"
+	if a != want {
+		t.Errorf("want\n%s\ngot\n%s", want, a)
+	}
+}
+
+func TestDownmixStereo(t *testing.T) {
+	a, err := ffmpeg.DownmixStereo("in.mkv", "5.1(side)", "out.mkv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-y -i in.mkv -map 0:v? -map 0:a:0 -c copy -c:a aac -af " +
+		"pan=stereo|FL<FL+0.707*FC+0.707*SL|FR<FR+0.707*FC+0.707*SR out.mkv"
+	if a != want {
+		t.Errorf("want\n%s\ngot\n%s", want, a)
+	}
+	if _, err = ffmpeg.DownmixStereo("in.mkv", "quad", "out.mkv"); err == nil {
+		t.Error("want error for an unknown layout")
+	}
+}
+
+func TestSplitMerge(t *testing.T) {
+	if a := ffmpeg.SplitStereo("in.wav", "l.wav", "r.wav"); a != "-y -i in.wav -filter_complex "+
+		"[0:a:0]channelsplit=channel_layout=stereo[l][r] -map [l] l.wav -map [r] r.wav" {
+		t.Errorf("unexpected split args %q", a)
+	}
+	if a := ffmpeg.MergeMono([]string{"l.wav", "r.wav"}, "out.wav"); a != "-y -i l.wav -i r.wav -filter_complex "+
+		"[0:a:0][1:a:0]amerge=inputs=2[a] -map [a] out.wav" {
+		t.Errorf("unexpected merge args %q", a)
+	}
+}
+
+func TestExtractAudio(t *testing.T) {
+	if a := ffmpeg.ExtractAudio("in.mkv", 1, "", "fr.m4a"); a != "-y -i in.mkv -map 0:a:1 -vn -c copy fr.m4a" {
+		t.Errorf("unexpected arg %q", a)
+	}
+	if a := ffmpeg.ExtractAudio("in.mkv", 0, "-c:a libmp3lame", "en.mp3"); a != "-y -i in.mkv -map 0:a:0 -vn -c:a libmp3lame en.mp3" {
+		t.Errorf("unexpected arg %q", a)
+	}
+}
+
+func TestReplaceAudio(t *testing.T) {
+	a := ffmpeg.ReplaceAudio("in.mp4", ffmpeg.AudioTrack{Path: "dub.wav", Offset: 500 * time.Millisecond, Language: "spa", Pad: true}, true, "out.mp4")
+	want := "-y -i in.mp4 -i dub.wav -map 0:v -map 1:a:0 -map 0:a? -c copy -c:a:0 aac -filter:a:0 adelay=500:all=1,apad " +
+		"-metadata:s:a:0 language=spa -disposition:a:0 default -shortest out.mp4"
+	if a != want {
+		t.Errorf("want\n%s\ngot\n%s", want, a)
+	}
+
+	a = ffmpeg.ReplaceAudio("in.mp4", ffmpeg.AudioTrack{Path: "dub.m4a", Offset: -2 * time.Second, Codec: "copy", Shortest: true}, false, "out.mp4")
+	if want = "-y -i in.mp4 -ss 2 -i dub.m4a -map 0:v -map 1:a:0 -c copy -c:a:0 copy -shortest out.mp4"; a != want {
+		t.Errorf("want\n%s\ngot\n%s", want, a)
+	}
+}
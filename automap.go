@@ -0,0 +1,96 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// A StreamSelector selects streams of an input, see AutoMap.
+type StreamSelector struct {
+	Type string // the codec type, e.g. "audio", any if empty
+
+	// Languages are the "language" tags to select, as reported
+	// by ffprobe, e.g. "eng" or "spa", any if empty.
+	Languages []string
+
+	First bool // only the first stream matching
+
+	// AttachedPic selects the attached pictures, e.g. cover
+	// arts, which the selectors select otherwise not.
+	AttachedPic bool
+}
+
+// A StreamMap is the streams of an input chosen by AutoMap, in
+// output order.
+type StreamMap []ProbeStream
+
+// Args returns the -map args of m, the input being the first.
+func (m StreamMap) Args() string {
+	args := make([]string, len(m))
+	for i, s := range m {
+		args[i] = "-map 0:" + strconv.Itoa(s.Index)
+	}
+	return strings.Join(args, " ")
+}
+
+// String describes m for logs, e.g. "0:0 video h264, 0:1 audio
+// aac eng".
+func (m StreamMap) String() string {
+	ds := make([]string, len(m))
+	for i, s := range m {
+		d := "0:" + strconv.Itoa(s.Index) + " " + s.CodecType + " " + s.CodecName
+		if lang := s.Tags["language"]; lang != "" {
+			d += " " + lang
+		}
+		ds[i] = d
+	}
+	return strings.Join(ds, ", ")
+}
+
+// SelectStreams returns the streams of info chosen by sels, in
+// order, every stream being chosen once. The streams no
+// selector chose are dropped, e.g. the data streams.
+func SelectStreams(info *ProbeInfo, sels ...StreamSelector) StreamMap {
+	var m StreamMap
+	chosen := make(map[int]bool)
+	for _, sel := range sels {
+		for _, s := range info.Streams {
+			if chosen[s.Index] || !sel.selects(s) {
+				continue
+			}
+			chosen[s.Index] = true
+			m = append(m, s)
+			if sel.First {
+				break
+			}
+		}
+	}
+	return m
+}
+
+func (sel StreamSelector) selects(s ProbeStream) bool {
+	if sel.Type != "" && s.CodecType != sel.Type {
+		return false
+	}
+	if sel.AttachedPic != (s.Disposition["attached_pic"] == 1) {
+		return false
+	}
+	return len(sel.Languages) == 0 || contains(sel.Languages, s.Tags["language"])
+}
+
+// AutoMap probes input with the ffprobe binary at probe and
+// returns the streams chosen by sels, see SelectStreams. It
+// fails if no stream is chosen.
+func AutoMap(ctx context.Context, probe, input string, sels ...StreamSelector) (StreamMap, error) {
+	info, err := Probe(ctx, probe, input)
+	if err != nil {
+		return nil, err
+	}
+	m := SelectStreams(info, sels...)
+	if len(m) == 0 {
+		return nil, errors.New("ffmpeg: no stream of " + input + " selected")
+	}
+	return m, nil
+}
@@ -0,0 +1,46 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestSelectStreams(t *testing.T) {
+	info := &ffmpeg.ProbeInfo{Streams: []ffmpeg.ProbeStream{
+		{Index: 0, CodecType: "video", CodecName: "mjpeg", Disposition: map[string]int{"attached_pic": 1}},
+		{Index: 1, CodecType: "video", CodecName: "h264"},
+		{Index: 2, CodecType: "audio", CodecName: "aac", Tags: map[string]string{"language": "fra"}},
+		{Index: 3, CodecType: "audio", CodecName: "aac", Tags: map[string]string{"language": "spa"}},
+		{Index: 4, CodecType: "data", CodecName: "bin_data"},
+		{Index: 5, CodecType: "audio", CodecName: "ac3", Tags: map[string]string{"language": "eng"}},
+		{Index: 6, CodecType: "video", CodecName: "hevc"},
+	}}
+	m := ffmpeg.SelectStreams(info,
+		ffmpeg.StreamSelector{Type: "video", First: true},
+		ffmpeg.StreamSelector{Type: "audio", Languages: []string{"eng", "spa"}},
+		ffmpeg.StreamSelector{AttachedPic: true},
+		ffmpeg.StreamSelector{Type: "audio"})
+	if a := m.Args(); a != "-map 0:1 -map 0:3 -map 0:5 -map 0:0 -map 0:2" {
+		t.Errorf("unexpected args %q", a)
+	}
+	if s := m.String(); s != "0:1 video h264, 0:3 audio aac spa, 0:5 audio ac3 eng, 0:0 video mjpeg, 0:2 audio aac fra" {
+		t.Errorf("unexpected mapping %q", s)
+	}
+}
+
+func TestAutoMap(t *testing.T) {
+	probe := fakeBinary(t, fakeProbe)
+	m, err := ffmpeg.AutoMap(context.TODO(), probe, "in.mp4", ffmpeg.StreamSelector{Type: "audio", Languages: []string{"eng"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Args() != "-map 0:1" {
+		t.Errorf("unexpected args %q", m.Args())
+	}
+
+	if _, err = ffmpeg.AutoMap(context.TODO(), probe, "in.mp4", ffmpeg.StreamSelector{Type: "subtitle"}); err == nil {
+		t.Error("want error for no stream selected")
+	}
+}
@@ -0,0 +1,84 @@
+package ffmpeg
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A BenchCase is an encode measured by a Bench.
+type BenchCase struct {
+	Encoder string // e.g. "libx264"
+	Args    string // the encoder options, e.g. "-preset fast -crf 23"
+	Threads int    // FFmpeg's default if 0
+}
+
+// A BenchResult is the throughput of a BenchCase.
+type BenchResult struct {
+	BenchCase
+	Frames   int64
+	FPS      float64       // as reported at the end by FFmpeg
+	Speed    float64       // relative to real time
+	Duration time.Duration // wall-clock time
+	CPUTime  time.Duration
+	Err      error // of the run, if it failed
+}
+
+// A Bench encodes the first video stream of Input with every
+// case to a null output, discarding the audio, to compare the
+// throughput of encoders and their settings on a machine.
+type Bench struct {
+	Input    string
+	Duration time.Duration // encoded of the Input, all of it if 0
+	Cases    []BenchCase
+}
+
+// Arg returns the arg of the run of c.
+func (b Bench) Arg(c BenchCase) string {
+	args := []string{"-nostdin -stats"}
+	if b.Duration > 0 {
+		args = append(args, "-t", seconds(b.Duration))
+	}
+	args = append(args, "-i", b.Input, "-map 0:v:0 -an -c:v", c.Encoder)
+	if c.Threads > 0 {
+		args = append(args, "-threads", strconv.Itoa(c.Threads))
+	}
+	if c.Args != "" {
+		args = append(args, c.Args)
+	}
+	return strings.Join(append(args, "-f null -"), " ")
+}
+
+// Run runs the cases one after the other, with a HookedRunner
+// built with opts, and returns their results in order. A failed
+// case has its Err set, Run only failing if ctx is done.
+func (b Bench) Run(ctx context.Context, opts ...func(r *HookedRunner)) ([]BenchResult, error) {
+	results := make([]BenchResult, 0, len(b.Cases))
+	for _, c := range b.Cases {
+		var mu sync.Mutex
+		var last Progress
+		ro := append(opts[:len(opts):len(opts)], Subscribe(func(e Event) {
+			if p, ok := e.(Progress); ok {
+				mu.Lock()
+				last = p
+				mu.Unlock()
+			}
+		}))
+		res, err := HookRunner(ro...).RunResult(ctx, b.Arg(c))
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		br := BenchResult{BenchCase: c, Err: err}
+		mu.Lock()
+		br.Frames, br.FPS, br.Speed = last.Frame, last.FPS, last.Speed
+		mu.Unlock()
+		if res != nil {
+			br.Duration, br.CPUTime = res.Duration, res.CPUTime()
+		}
+		results = append(results, br)
+	}
+	return results, nil
+}
@@ -0,0 +1,53 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestBenchArg(t *testing.T) {
+	b := ffmpeg.Bench{Input: "sample.mp4", Duration: 30 * time.Second}
+	if a := b.Arg(ffmpeg.BenchCase{Encoder: "libx264", Args: "-preset fast", Threads: 4}); a !=
+		"-nostdin -stats -t 30 -i sample.mp4 -map 0:v:0 -an -c:v libx264 -threads 4 -preset fast -f null -" {
+		t.Errorf("unexpected arg %q", a)
+	}
+}
+
+func TestBenchRun(t *testing.T) {
+	p := fakeBinary(t, `case "$*" in
+*libx265*) echo "Unknown encoder 'libx265'" >&2; exit 1;;
+*-threads*) echo "frame=  750 fps=250 q=-0.0 Lsize=N/A time=00:00:30.00 bitrate=N/A speed=10.0x" >&2;;
+*) echo "frame=  750 fps=125 q=-0.0 Lsize=N/A time=00:00:30.00 bitrate=N/A speed=5.0x" >&2;;
+esac
+`)
+	b := ffmpeg.Bench{Input: "sample.mp4", Cases: []ffmpeg.BenchCase{
+		{Encoder: "libx264"},
+		{Encoder: "libx264", Threads: 8},
+		{Encoder: "libx265"},
+	}}
+	res, err := b.Run(context.TODO(), ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("unexpected results %+v", res)
+	}
+	if res[0].FPS != 125 || res[0].Speed != 5 || res[0].Frames != 750 || res[0].Err != nil || res[0].Duration == 0 {
+		t.Errorf("unexpected result %+v", res[0])
+	}
+	if res[1].FPS != 250 || res[1].Threads != 8 {
+		t.Errorf("unexpected result %+v", res[1])
+	}
+	if res[2].Err == nil || res[2].Frames != 0 {
+		t.Errorf("want failed case, got %+v", res[2])
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, err = b.Run(ctx, ffmpeg.CustomPath(p)); err != context.Canceled {
+		t.Errorf("want cancelled, got %v", err)
+	}
+}
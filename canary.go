@@ -0,0 +1,278 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Comparer compares the output of a primary run with the
+// output of its canary run. A non-nil error reports a regression.
+type Comparer func(ctx context.Context, primary, canary string) error
+
+// A CanaryReport describes one mirrored run.
+type CanaryReport struct {
+	Arg       string // the primary arg
+	CanaryArg string // the arg given to the alternate Runner
+
+	Err       error // the error returned by the primary Runner
+	CanaryErr error // the error returned by the alternate Runner
+	Diff      error // the error returned by the Comparer, if any
+
+	Duration       time.Duration
+	CanaryDuration time.Duration
+}
+
+// Regressed reports whether the canary did worse than the primary:
+// it failed where the primary succeeded, or its output differs.
+func (r *CanaryReport) Regressed() bool {
+	if r.Err == nil && r.CanaryErr != nil {
+		return true
+	}
+	return r.Diff != nil
+}
+
+// A CanaryRunner mirrors a fraction of its runs through an
+// alternate Runner, typically a newer binary or a different
+// preset, writing the mirrored output to a throwaway location.
+// The result of the primary Runner is always the one returned.
+type CanaryRunner struct {
+	primary  Runner
+	alt      Runner
+	fraction float64
+	rewrite  func(arg string) string
+	compare  Comparer
+	report   func(r *CanaryReport)
+
+	mu      sync.Mutex
+	rnd     *rand.Rand
+	pending sync.WaitGroup
+}
+
+// Run runs the primary Runner and, for the sampled fraction of
+// runs, the alternate Runner in parallel. It returns once the
+// primary exited; the canary goes on in the background, only
+// cancelled with ctx while the primary runs, and its outputs
+// are compared and reported once it exited, see Wait.
+func (c *CanaryRunner) Run(ctx context.Context, arg string) error {
+	if !c.sample() {
+		return c.primary.Run(ctx, arg)
+	}
+
+	rep := &CanaryReport{Arg: arg}
+
+	args := strings.Fields(arg)
+	if len(args) == 0 {
+		return c.primary.Run(ctx, arg)
+	}
+	dir, err := os.MkdirTemp("", "ffmpeg-canary-")
+	if err != nil {
+		// never fail the primary because of the canary
+		return c.primary.Run(ctx, arg)
+	}
+
+	primaryOut := args[len(args)-1]
+	canaryOut := filepath.Join(dir, "out"+filepath.Ext(primaryOut))
+	args[len(args)-1] = canaryOut
+	rep.CanaryArg = strings.Join(args, " ")
+	if c.rewrite != nil {
+		rep.CanaryArg = c.rewrite(rep.CanaryArg)
+	}
+
+	cctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	detach := context.AfterFunc(ctx, cancel)
+	done := make(chan struct{})
+	c.pending.Add(1)
+	go func() {
+		defer close(done)
+		start := time.Now()
+		rep.CanaryErr = c.alt.Run(cctx, rep.CanaryArg)
+		rep.CanaryDuration = time.Since(start)
+	}()
+
+	start := time.Now()
+	rep.Err = c.primary.Run(ctx, arg)
+	rep.Duration = time.Since(start)
+	detach()
+
+	// linked for the comparison, in case the caller moves or
+	// removes it meanwhile
+	if rep.Err == nil {
+		if l := filepath.Join(dir, "primary"+filepath.Ext(primaryOut)); os.Link(primaryOut, l) == nil {
+			primaryOut = l
+		}
+	}
+	go func() {
+		defer c.pending.Done()
+		defer os.RemoveAll(dir)
+		defer cancel()
+		<-done
+		if rep.Err == nil && rep.CanaryErr == nil && c.compare != nil {
+			rep.Diff = c.compare(cctx, primaryOut, canaryOut)
+		}
+		if c.report != nil {
+			c.report(rep)
+		}
+	}()
+	return rep.Err
+}
+
+// Wait waits for the canaries in progress to be compared and
+// reported, e.g. before the program exits.
+func (c *CanaryRunner) Wait() {
+	c.pending.Wait()
+}
+
+func (c *CanaryRunner) sample() bool {
+	if c.fraction <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rnd.Float64() < c.fraction
+}
+
+// Canary returns a CanaryRunner that mirrors the given fraction
+// (0 to 1) of runs from primary through alt. The output of a
+// mirrored run is assumed to be the last argument and is replaced
+// by a temporary file which is removed after the comparison.
+func Canary(primary, alt Runner, fraction float64, opts ...func(c *CanaryRunner)) *CanaryRunner {
+	c := &CanaryRunner{
+		primary:  primary,
+		alt:      alt,
+		fraction: fraction,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+// CanaryArgs rewrites the arg of the mirrored run, e.g. to try
+// a different preset. It receives the arg with the output already
+// replaced by the throwaway location.
+func CanaryArgs(f func(arg string) string) func(c *CanaryRunner) {
+	return func(c *CanaryRunner) {
+		c.rewrite = f
+	}
+}
+
+// CanaryCompare sets the Comparer used on the outputs when both
+// runs succeeded.
+func CanaryCompare(cmp Comparer) func(c *CanaryRunner) {
+	return func(c *CanaryRunner) {
+		c.compare = cmp
+	}
+}
+
+// CanaryReporter sets the function receiving a report of every
+// mirrored run.
+func CanaryReporter(f func(r *CanaryReport)) func(c *CanaryRunner) {
+	return func(c *CanaryRunner) {
+		c.report = f
+	}
+}
+
+// SizeComparer returns a Comparer that reports a regression when
+// the size of the canary output differs from the primary output
+// by more than the given ratio (e.g. 0.1 for 10%).
+func SizeComparer(ratio float64) Comparer {
+	return func(ctx context.Context, primary, canary string) error {
+		p, err := os.Stat(primary)
+		if err != nil {
+			return err
+		}
+		c, err := os.Stat(canary)
+		if err != nil {
+			return err
+		}
+		if p.Size() == 0 {
+			if c.Size() == 0 {
+				return nil
+			}
+			return &SizeDiffError{Primary: p.Size(), Canary: c.Size()}
+		}
+		d := float64(c.Size()-p.Size()) / float64(p.Size())
+		if d < 0 {
+			d = -d
+		}
+		if d > ratio {
+			return &SizeDiffError{Primary: p.Size(), Canary: c.Size()}
+		}
+		return nil
+	}
+}
+
+// A SizeDiffError is returned by a SizeComparer.
+type SizeDiffError struct {
+	Primary, Canary int64
+}
+
+func (e *SizeDiffError) Error() string {
+	return fmt.Sprintf("ffmpeg: canary output size %d differs from primary %d",
+		e.Canary, e.Primary)
+}
+
+// A Metric is a quality metric of FFmpeg, for QualityComparer.
+type Metric string
+
+// The Metrics.
+const (
+	SSIM Metric = "ssim" // from 0 to 1, identical
+	PSNR Metric = "psnr" // in dB, inf if identical
+)
+
+var metricRes = map[Metric]*regexp.Regexp{
+	SSIM: regexp.MustCompile(`SSIM .*All:(\S+)`),
+	PSNR: regexp.MustCompile(`PSNR .*average:(\S+)`),
+}
+
+// QualityComparer returns a Comparer that reports a regression
+// when the metric m of the canary output, the primary output
+// being the reference, is below min, e.g. 0.98 for SSIM or 40
+// for PSNR. The first videos, which must have the same size,
+// are compared by FFmpeg run with a HookedRunner built with
+// opts.
+func QualityComparer(m Metric, min float64, opts ...func(r *HookedRunner)) Comparer {
+	return func(ctx context.Context, primary, canary string) error {
+		score := math.NaN()
+		opts := append(opts[:len(opts):len(opts)], LineHook(func(line string) {
+			if sm := metricRes[m].FindStringSubmatch(line); sm != nil {
+				score, _ = strconv.ParseFloat(sm[1], 64)
+			}
+		}))
+		arg := "-nostdin -i " + canary + " -i " + primary + " -lavfi [0:v:0][1:v:0]" + string(m) + " -f null -"
+		if err := HookRunner(opts...).Run(ctx, arg); err != nil {
+			return err
+		}
+		if math.IsNaN(score) {
+			return errors.New("ffmpeg: no " + string(m) + " score")
+		}
+		if score < min {
+			return &QualityDiffError{Metric: m, Score: score, Min: min}
+		}
+		return nil
+	}
+}
+
+// A QualityDiffError is returned by a QualityComparer.
+type QualityDiffError struct {
+	Metric     Metric
+	Score, Min float64
+}
+
+func (e *QualityDiffError) Error() string {
+	return fmt.Sprintf("ffmpeg: canary output %s %g is below %g", e.Metric, e.Score, e.Min)
+}
@@ -0,0 +1,126 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// fakeRunner records the args it is given and writes the
+// size-s output (the last arg) when it is set, once block is
+// closed if set.
+type fakeRunner struct {
+	mu    sync.Mutex
+	args  []string
+	size  int
+	err   error
+	block chan struct{}
+}
+
+func (f *fakeRunner) Run(ctx context.Context, arg string) error {
+	f.mu.Lock()
+	f.args = append(f.args, arg)
+	f.mu.Unlock()
+	if f.block != nil {
+		<-f.block
+	}
+	if f.size > 0 {
+		fs := strings.Fields(arg)
+		os.WriteFile(fs[len(fs)-1], make([]byte, f.size), 0644)
+	}
+	return f.err
+}
+
+func TestCanary(t *testing.T) {
+	dir := t.TempDir()
+	out := dir + "/out.mp4"
+
+	primary := &fakeRunner{size: 100}
+	alt := &fakeRunner{size: 150}
+
+	var rep *ffmpeg.CanaryReport
+	c := ffmpeg.Canary(primary, alt, 1,
+		ffmpeg.CanaryArgs(func(arg string) string {
+			return "-preset slow " + arg
+		}),
+		ffmpeg.CanaryCompare(ffmpeg.SizeComparer(0.1)),
+		ffmpeg.CanaryReporter(func(r *ffmpeg.CanaryReport) {
+			rep = r
+		}))
+
+	if err := c.Run(context.TODO(), "-i in.mp4 "+out); err != nil {
+		t.Fatal(err)
+	}
+	c.Wait()
+	if rep == nil {
+		t.Fatal("no report")
+	}
+	if !strings.HasPrefix(rep.CanaryArg, "-preset slow -i in.mp4 ") ||
+		strings.HasSuffix(rep.CanaryArg, out) {
+		t.Errorf("unexpected canary arg: %s", rep.CanaryArg)
+	}
+	var sde *ffmpeg.SizeDiffError
+	if !errors.As(rep.Diff, &sde) || !rep.Regressed() {
+		t.Errorf("want size regression, got %v", rep.Diff)
+	}
+}
+
+func TestCanaryNotSampled(t *testing.T) {
+	primary := &fakeRunner{}
+	alt := &fakeRunner{}
+	c := ffmpeg.Canary(primary, alt, 0)
+	c.Run(context.TODO(), "-i in.mp4 out.mp4")
+	if len(primary.args) != 1 || len(alt.args) != 0 {
+		t.Errorf("unexpected runs: %v %v", primary.args, alt.args)
+	}
+}
+
+func TestCanaryAsync(t *testing.T) {
+	out := t.TempDir() + "/out.mp4"
+	primary := &fakeRunner{size: 100}
+	alt := &fakeRunner{size: 100, block: make(chan struct{})}
+
+	reported := make(chan *ffmpeg.CanaryReport, 1)
+	c := ffmpeg.Canary(primary, alt, 1,
+		ffmpeg.CanaryCompare(ffmpeg.SizeComparer(0.1)),
+		ffmpeg.CanaryReporter(func(r *ffmpeg.CanaryReport) { reported <- r }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.Run(ctx, "-i in.mp4 "+out); err != nil {
+		t.Fatal(err)
+	}
+	cancel() // the canary is not cancelled once the primary exited
+	os.Remove(out)
+	select {
+	case <-reported:
+		t.Fatal("reported before the canary exited")
+	default:
+	}
+
+	close(alt.block)
+	c.Wait()
+	rep := <-reported
+	if rep.CanaryErr != nil || rep.Diff != nil || rep.Regressed() {
+		t.Errorf("unexpected report %+v", rep)
+	}
+}
+
+func TestQualityComparer(t *testing.T) {
+	p := fakeBinary(t, `echo "[Parsed_ssim_0 @ 0x1] SSIM Y:0.97 (15.2) U:0.98 (17.0) V:0.98 (17.0) All:0.975 (16.0)" >&2`+"\n")
+	cmp := ffmpeg.QualityComparer(ffmpeg.SSIM, 0.98, ffmpeg.CustomPath(p))
+	var qe *ffmpeg.QualityDiffError
+	if err := cmp(context.TODO(), "primary.mp4", "canary.mp4"); !errors.As(err, &qe) || qe.Score != 0.975 {
+		t.Errorf("want quality regression, got %v", err)
+	}
+	if err := ffmpeg.QualityComparer(ffmpeg.SSIM, 0.95, ffmpeg.CustomPath(p))(context.TODO(), "primary.mp4", "canary.mp4"); err != nil {
+		t.Error(err)
+	}
+	if err := ffmpeg.QualityComparer(ffmpeg.PSNR, 40, ffmpeg.CustomPath(p))(context.TODO(), "primary.mp4", "canary.mp4"); err == nil {
+		t.Error("want an error without a score")
+	}
+}
@@ -0,0 +1,159 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+)
+
+// Capabilities lists what a FFmpeg binary is built with.
+type Capabilities struct {
+	Encoders        map[string]bool
+	Decoders        map[string]bool
+	Muxers          map[string]bool
+	Demuxers        map[string]bool
+	Filters         map[string]bool
+	InputProtocols  map[string]bool
+	OutputProtocols map[string]bool
+}
+
+// LoadCapabilities runs the FFmpeg binary at path (as found by
+// exec.LookPath) with -encoders, -decoders, -formats, -filters
+// and -protocols and parses the listings.
+func LoadCapabilities(ctx context.Context, path string) (*Capabilities, error) {
+	c := &Capabilities{}
+
+	lists := []struct {
+		flag string
+		set  func(b []byte)
+	}{
+		{"-encoders", func(b []byte) { c.Encoders = parseListing(b, "") }},
+		{"-decoders", func(b []byte) { c.Decoders = parseListing(b, "") }},
+		{"-formats", func(b []byte) {
+			c.Demuxers = parseListing(b, "D")
+			c.Muxers = parseListing(b, "E")
+		}},
+		{"-filters", func(b []byte) { c.Filters = parseListing(b, "") }},
+		{"-protocols", func(b []byte) {
+			c.InputProtocols, c.OutputProtocols = parseProtocols(b)
+		}},
+	}
+
+	for _, l := range lists {
+		b, err := output(ctx, path, "-hide_banner "+l.flag)
+		if err != nil {
+			return nil, err
+		}
+		l.set(b)
+	}
+
+	return c, nil
+}
+
+// HasEncoder reports whether the named encoder is available.
+func (c *Capabilities) HasEncoder(name string) bool { return c.Encoders[name] }
+
+// HasDecoder reports whether the named decoder is available.
+func (c *Capabilities) HasDecoder(name string) bool { return c.Decoders[name] }
+
+// HasMuxer reports whether the named muxer is available.
+func (c *Capabilities) HasMuxer(name string) bool { return c.Muxers[name] }
+
+// HasDemuxer reports whether the named demuxer is available.
+func (c *Capabilities) HasDemuxer(name string) bool { return c.Demuxers[name] }
+
+// HasFilter reports whether the named filter is available.
+func (c *Capabilities) HasFilter(name string) bool { return c.Filters[name] }
+
+// HasProtocol reports whether the named protocol is available
+// for input or output.
+func (c *Capabilities) HasProtocol(name string) bool {
+	return c.InputProtocols[name] || c.OutputProtocols[name]
+}
+
+// Require returns a *MissingError listing the encoders and
+// filters not available, or nil if all of them are.
+func (c *Capabilities) Require(encoders []string, filters []string) error {
+	e := &MissingError{}
+	for _, n := range encoders {
+		if !c.HasEncoder(n) {
+			e.Encoders = append(e.Encoders, n)
+		}
+	}
+	for _, n := range filters {
+		if !c.HasFilter(n) {
+			e.Filters = append(e.Filters, n)
+		}
+	}
+	if len(e.Encoders) == 0 && len(e.Filters) == 0 {
+		return nil
+	}
+	return e
+}
+
+// A MissingError reports the capabilities a job needs but
+// the FFmpeg binary lacks.
+type MissingError struct {
+	Encoders []string
+	Filters  []string
+}
+
+func (e *MissingError) Error() string {
+	var parts []string
+	if len(e.Encoders) > 0 {
+		parts = append(parts, "encoders "+strings.Join(e.Encoders, ", "))
+	}
+	if len(e.Filters) > 0 {
+		parts = append(parts, "filters "+strings.Join(e.Filters, ", "))
+	}
+	return "ffmpeg: missing " + strings.Join(parts, "; ")
+}
+
+// parseListing parses the output of -encoders, -decoders,
+// -formats or -filters: a header and a legend of "FLAGS = meaning"
+// lines, then one "FLAGS name description" entry per line. If flag
+// is not empty, only entries whose flags contain it are kept.
+// Comma-separated names (e.g. "mov,mp4,m4a") are split.
+func parseListing(b []byte, flag string) map[string]bool {
+	m := make(map[string]bool)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		fs := strings.Fields(line)
+		if len(fs) < 2 || fs[1] == "=" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		if flag != "" {
+			// flags of -formats are in the first columns and
+			// may start with a space
+			if len(line) < 3 || !strings.Contains(line[:3], flag) {
+				continue
+			}
+		}
+		for _, n := range strings.Split(fs[1], ",") {
+			m[n] = true
+		}
+	}
+	return m
+}
+
+// parseProtocols parses the output of -protocols.
+func parseProtocols(b []byte) (in, out map[string]bool) {
+	in, out = make(map[string]bool), make(map[string]bool)
+	var cur map[string]bool
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "Input:":
+			cur = in
+		case line == "Output:":
+			cur = out
+		case line == "" || cur == nil:
+		default:
+			cur[line] = true
+		}
+	}
+	return in, out
+}
@@ -0,0 +1,85 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+const fakeListings = `case "$2" in
+-encoders) cat <<EOF
+Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC (codec h264)
+ A....D aac                  AAC (Advanced Audio Coding)
+EOF
+;;
+-decoders) cat <<EOF
+Decoders:
+ V..... = Video
+ ------
+ VFS..D h264                 H.264 / AVC / MPEG-4 part 10
+EOF
+;;
+-formats) cat <<EOF
+File formats:
+ D. = Demuxing supported
+ .E = Muxing supported
+ --
+ D  aac             raw ADTS AAC (Advanced Audio Coding)
+  E mp4             MP4 (MPEG-4 Part 14)
+ D  mov,mp4,m4a,3gp,3g2,mj2 QuickTime / MOV
+EOF
+;;
+-filters) cat <<EOF
+Filters:
+  T.. = Timeline support
+  | = Source or sink filter
+ ... scale             V->V       Scale the input video size.
+ TSC overlay           VV->V      Overlay a video source on top of the input.
+EOF
+;;
+-protocols) cat <<EOF
+Supported file protocols:
+Input:
+  file
+  rtsp
+Output:
+  file
+  rtmp
+EOF
+;;
+esac
+`
+
+func TestLoadCapabilities(t *testing.T) {
+	c, err := ffmpeg.LoadCapabilities(context.TODO(), fakeBinary(t, fakeListings))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ok := range []bool{
+		c.HasEncoder("libx264"), c.HasEncoder("aac"), c.HasDecoder("h264"),
+		c.HasMuxer("mp4"), c.HasDemuxer("aac"), c.HasDemuxer("m4a"),
+		c.HasFilter("scale"), c.HasFilter("overlay"),
+		c.HasProtocol("rtsp"), c.OutputProtocols["rtmp"],
+	} {
+		if !ok {
+			t.Errorf("missing capability: %+v", c)
+		}
+	}
+	if c.HasEncoder("libx265") || c.HasMuxer("aac") || c.InputProtocols["rtmp"] {
+		t.Errorf("unexpected capability: %+v", c)
+	}
+
+	err = c.Require([]string{"libx264", "libx265"}, []string{"libvmaf"})
+	if err == nil || err.Error() != "ffmpeg: missing encoders libx265; filters libvmaf" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err = c.Require([]string{"aac"}, nil); err != nil {
+		t.Error(err)
+	}
+}
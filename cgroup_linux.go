@@ -0,0 +1,48 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// cgroupSetup creates the cgroup and makes cmd start in it.
+func cgroupSetup(cmd *exec.Cmd, parent string, memoryMax int64, cpus float64) (func(), error) {
+	dir, err := os.MkdirTemp(parent, "ffmpeg-")
+	if err != nil {
+		return nil, err
+	}
+	undo := func() { os.Remove(dir) }
+
+	if memoryMax > 0 {
+		err = os.WriteFile(filepath.Join(dir, "memory.max"), []byte(fmt.Sprint(memoryMax)), 0)
+	}
+	if err == nil && cpus > 0 {
+		const period = 100000
+		max := fmt.Sprintf("%d %d", int(cpus*period), period)
+		err = os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(max), 0)
+	}
+	if err != nil {
+		undo()
+		return nil, err
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		undo()
+		return nil, err
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(f.Fd())
+
+	return func() {
+		f.Close()
+		undo()
+	}, nil
+}
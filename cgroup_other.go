@@ -0,0 +1,12 @@
+//go:build !linux
+
+package ffmpeg
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func cgroupSetup(cmd *exec.Cmd, parent string, memoryMax int64, cpus float64) (func(), error) {
+	return nil, errors.New("ffmpeg: cgroups are only supported on Linux")
+}
@@ -0,0 +1,64 @@
+package ffmpeg
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A CheckReport is the outcome of decoding a whole input with
+// Check.
+type CheckReport struct {
+	Errors  []DecodeError
+	Corrupt int           // the frames decoded corrupt or concealed
+	Decoded time.Duration // how far the input was decoded
+}
+
+// A DecodeError is an error FFmpeg reported decoding an input.
+type DecodeError struct {
+	At        time.Duration // the decoding position, as of the last stats
+	Component string        // e.g. "h264"
+	Message   string
+}
+
+// OK reports whether the input decoded without error.
+func (r *CheckReport) OK() bool {
+	return len(r.Errors) == 0 && r.Corrupt == 0
+}
+
+// Check decodes the video and audio streams of input to a null
+// output, which takes no subtitles, running FFmpeg with a
+// HookedRunner built with opts, and reports the decode errors,
+// e.g. to validate uploads. All the errors are collected unless
+// opts has GlobalArgs("-xerror") to stop at the first one, and
+// an input failing to open is a DecodeError too. The error returned is that of a run failing
+// without any DecodeError, e.g. for a missing FFmpeg.
+func Check(ctx context.Context, input string, opts ...func(r *HookedRunner)) (*CheckReport, error) {
+	var mu sync.Mutex
+	rep := &CheckReport{}
+	opts = append(opts[:len(opts):len(opts)],
+		Subscribe(func(e Event) {
+			if p, ok := e.(Progress); ok {
+				mu.Lock()
+				rep.Decoded = p.Time
+				mu.Unlock()
+			}
+		}),
+		LogHook(LogError, func(rec LogRecord) {
+			mu.Lock()
+			defer mu.Unlock()
+			if strings.Contains(rec.Message, "corrupt decoded frame") || strings.HasPrefix(rec.Message, "concealing ") {
+				rep.Corrupt++
+			}
+			rep.Errors = append(rep.Errors, DecodeError{At: rep.Decoded, Component: rec.Component, Message: rec.Message})
+		}))
+	err := HookRunner(opts...).Run(ctx, "-nostdin -v error -stats -i "+input+" -map 0:v? -map 0:a? -f null -")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err != nil && (ctx.Err() != nil || len(rep.Errors) == 0) {
+		return nil, err
+	}
+	return rep, nil
+}
@@ -0,0 +1,44 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestCheck(t *testing.T) {
+	// the null muxer has no subtitle encoder
+	p := fakeBinary(t, `case "$*" in
+*"good.mp4 -map 0:v? -map 0:a? -f null"*) echo "frame=  250 fps=0.0 q=-0.0 Lsize=N/A time=00:00:10.00 bitrate=N/A speed= 50x" >&2;;
+*bad.mp4*)
+	echo "frame=  100 fps=0.0 q=-0.0 size=N/A time=00:00:04.00 bitrate=N/A speed= 50x" >&2
+	echo "[h264 @ 0x55d0c8] [error] error while decoding MB 12 30, bytestream -7" >&2
+	echo "[h264 @ 0x55d0c8] [error] concealing 1200 DC, 1200 AC, 1200 MV errors in P frame" >&2
+	echo "frame=  250 fps=0.0 q=-0.0 Lsize=N/A time=00:00:10.00 bitrate=N/A speed= 50x" >&2;;
+*) exit 1;;
+esac
+`)
+	rep, err := ffmpeg.Check(context.TODO(), "good.mp4", ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() || rep.Decoded != 10*time.Second {
+		t.Errorf("unexpected report %+v", rep)
+	}
+
+	if rep, err = ffmpeg.Check(context.TODO(), "bad.mp4", ffmpeg.CustomPath(p)); err != nil {
+		t.Fatal(err)
+	}
+	if rep.OK() || rep.Corrupt != 1 || len(rep.Errors) != 2 {
+		t.Fatalf("unexpected report %+v", rep)
+	}
+	if e := rep.Errors[0]; e.At != 4*time.Second || e.Component != "h264" || e.Message != "error while decoding MB 12 30, bytestream -7" {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	if _, err = ffmpeg.Check(context.TODO(), "other.mp4", ffmpeg.CustomPath(p)); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,122 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A ClipMode is how a Clip is cut.
+type ClipMode int
+
+// The ClipModes.
+const (
+	// KeyframeCopy copies the streams from the keyframe at or
+	// before Start: fast and lossless, but the clip may start
+	// earlier than asked.
+	KeyframeCopy ClipMode = iota
+	// FrameAccurate re-encodes the clip to start at Start.
+	FrameAccurate
+)
+
+// A Clip extracts the part of Input from Start to End into
+// Output.
+type Clip struct {
+	Input  string
+	Output string
+	Start  time.Duration
+	End    time.Duration // 0 for the end of Input
+	Mode   ClipMode
+
+	// VideoCodec and AudioCodec are the encoders of the
+	// FrameAccurate mode, libx264 and aac by default.
+	VideoCodec string
+	AudioCodec string
+}
+
+// A ClipResult reports the clip extracted, as probed.
+type ClipResult struct {
+	Start    time.Duration // in Input
+	End      time.Duration // in Input, 0 if the Clip has none
+	Duration time.Duration
+}
+
+// Args returns the arg to run FFmpeg with to extract c. The
+// seek is before the input in both modes, fast, and frame
+// accurate when re-encoding. Copied clips have their timestamps
+// shifted to start at zero, as players mishandle negative ones.
+func (c Clip) Args() string {
+	args := []string{"-y"}
+	if c.Start > 0 {
+		args = append(args, "-ss", seconds(c.Start))
+	}
+	args = append(args, "-i", c.Input)
+	if c.End > 0 {
+		args = append(args, "-t", seconds(c.End-c.Start))
+	}
+
+	switch c.Mode {
+	case KeyframeCopy:
+		args = append(args, "-map", "0", "-c", "copy", "-avoid_negative_ts", "make_zero")
+	case FrameAccurate:
+		vc, ac := c.VideoCodec, c.AudioCodec
+		if vc == "" {
+			vc = "libx264"
+		}
+		if ac == "" {
+			ac = "aac"
+		}
+		args = append(args, "-c:v", vc, "-c:a", ac)
+	}
+	return strings.Join(append(args, c.Output), " ")
+}
+
+// Run extracts c with r and, if probe is not empty, probes the
+// Output with the ffprobe binary at probe to report the clip.
+// For KeyframeCopy, the actual Start is estimated from End, or
+// the probed duration of Input, and the probed duration.
+func (c Clip) Run(ctx context.Context, r Runner, probe string) (*ClipResult, error) {
+	if c.End > 0 && c.End <= c.Start {
+		return nil, errors.New("ffmpeg: clip ends before it starts")
+	}
+	if err := r.Run(ctx, c.Args()); err != nil {
+		return nil, err
+	}
+	if probe == "" {
+		return nil, nil
+	}
+
+	info, err := Probe(ctx, probe, c.Output)
+	if err != nil {
+		return nil, err
+	}
+	res := &ClipResult{
+		Start:    c.Start,
+		Duration: info.Format.Duration.Duration(),
+	}
+	end := c.End
+	if end == 0 && c.Mode == KeyframeCopy {
+		in, err := Probe(ctx, probe, c.Input)
+		if err != nil {
+			return nil, err
+		}
+		end = in.Format.Duration.Duration()
+	}
+	switch {
+	case c.Mode == KeyframeCopy:
+		res.End = c.End
+		if res.Start = end - res.Duration; res.Start < 0 {
+			res.Start = 0
+		}
+	case c.End > 0:
+		res.End = c.Start + res.Duration
+	}
+	return res, nil
+}
+
+// seconds formats d for FFmpeg, e.g. "12.5".
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
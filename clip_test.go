@@ -0,0 +1,52 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestClipArgs(t *testing.T) {
+	c := ffmpeg.Clip{Input: "in.mp4", Output: "out.mp4", Start: 1500 * time.Millisecond, End: 10 * time.Second}
+	if a := c.Args(); a != "-y -ss 1.5 -i in.mp4 -t 8.5 -map 0 -c copy -avoid_negative_ts make_zero out.mp4" {
+		t.Errorf("unexpected copy args %q", a)
+	}
+	c.Mode, c.End = ffmpeg.FrameAccurate, 0
+	if a := c.Args(); a != "-y -ss 1.5 -i in.mp4 -c:v libx264 -c:a aac out.mp4" {
+		t.Errorf("unexpected accurate args %q", a)
+	}
+}
+
+func TestClipRun(t *testing.T) {
+	p, last := argsBinary(t)
+	probe := fakeBinary(t, fakeProbe)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p))
+
+	// the probed output lasts 9s
+	c := ffmpeg.Clip{Input: "in.mp4", Output: "short.mp4", Start: 2 * time.Second, End: 10 * time.Second}
+	res, err := c.Run(context.TODO(), r, probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last() != c.Args() {
+		t.Errorf("unexpected args %q", last())
+	}
+	if res.Start != time.Second || res.End != 10*time.Second || res.Duration != 9*time.Second {
+		t.Errorf("unexpected copy result %+v", res)
+	}
+
+	c.Mode = ffmpeg.FrameAccurate
+	if res, err = c.Run(context.TODO(), r, probe); err != nil {
+		t.Fatal(err)
+	}
+	if res.Start != 2*time.Second || res.End != 11*time.Second {
+		t.Errorf("unexpected accurate result %+v", res)
+	}
+
+	c.End = time.Second
+	if _, err = c.Run(context.TODO(), r, probe); err == nil {
+		t.Error("want error for a clip ending before its start")
+	}
+}
@@ -0,0 +1,202 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A ConcatMethod is how Concat joins its inputs.
+type ConcatMethod int
+
+// The ConcatMethods.
+const (
+	// ConcatDemuxer copies inputs with the same streams, read
+	// from a list file.
+	ConcatDemuxer ConcatMethod = iota
+	// ConcatProtocol copies MPEG-TS inputs joined as bytes.
+	ConcatProtocol
+	// ConcatFilter re-encodes inputs with different codecs.
+	ConcatFilter
+)
+
+func (m ConcatMethod) String() string {
+	switch m {
+	case ConcatDemuxer:
+		return "demuxer"
+	case ConcatProtocol:
+		return "protocol"
+	case ConcatFilter:
+		return "filter"
+	}
+	return "ConcatMethod(" + fmt.Sprint(int(m)) + ")"
+}
+
+// A Concat joins Inputs into Output.
+type Concat struct {
+	Inputs []string
+	Output string
+
+	// Args are output options for the ConcatFilter method,
+	// e.g. "-c:v libx264 -c:a aac".
+	Args string
+}
+
+// ConcatList returns the list file of the concat demuxer for
+// paths, quoted so that any path is read as is.
+func ConcatList(paths []string) string {
+	var b strings.Builder
+	b.WriteString("ffconcat version 1.0\n")
+	for _, p := range paths {
+		b.WriteString("file '" + strings.ReplaceAll(p, "'", `'\''`) + "'\n")
+	}
+	return b.String()
+}
+
+// ChooseConcat returns the method to join inputs described by
+// infos: the protocol for MPEG-TS inputs, the demuxer for
+// inputs whose streams match, and the filter otherwise.
+func ChooseConcat(infos []*ProbeInfo) (ConcatMethod, error) {
+	if len(infos) == 0 {
+		return 0, errors.New("ffmpeg: nothing to concat")
+	}
+	ts := true
+	for _, info := range infos {
+		ts = ts && info.Format.FormatName == "mpegts"
+	}
+	for _, info := range infos[1:] {
+		if !sameStreams(infos[0], info) {
+			return ConcatFilter, nil
+		}
+	}
+	if ts {
+		return ConcatProtocol, nil
+	}
+	return ConcatDemuxer, nil
+}
+
+// sameStreams reports whether a and b can be concatenated
+// without re-encoding.
+func sameStreams(a, b *ProbeInfo) bool {
+	if len(a.Streams) != len(b.Streams) {
+		return false
+	}
+	for i, s := range a.Streams {
+		t := b.Streams[i]
+		if s.CodecType != t.CodecType || s.CodecName != t.CodecName ||
+			s.Width != t.Width || s.Height != t.Height || s.PixFmt != t.PixFmt ||
+			s.SampleRate != t.SampleRate || s.Channels != t.Channels {
+			return false
+		}
+	}
+	return true
+}
+
+// Run probes the inputs with the ffprobe binary at probe, joins
+// them with r using the method chosen by ChooseConcat and
+// returns the method.
+func (c Concat) Run(ctx context.Context, r Runner, probe string) (ConcatMethod, error) {
+	infos := make([]*ProbeInfo, len(c.Inputs))
+	for i, in := range c.Inputs {
+		info, err := Probe(ctx, probe, in)
+		if err != nil {
+			return 0, err
+		}
+		infos[i] = info
+	}
+
+	m, err := ChooseConcat(infos)
+	if err != nil {
+		return m, err
+	}
+	if m == ConcatProtocol && strings.ContainsAny(strings.Join(c.Inputs, ""), "| ") {
+		m = ConcatDemuxer
+	}
+
+	switch m {
+	case ConcatProtocol:
+		return m, r.Run(ctx, "-y -i concat:"+strings.Join(c.Inputs, "|")+" -c copy "+c.Output)
+	case ConcatFilter:
+		return m, r.Run(ctx, c.filterArgs(infos))
+	}
+
+	// the demuxer opens the relative paths from the list file
+	paths := make([]string, len(c.Inputs))
+	for i, in := range c.Inputs {
+		if paths[i] = in; !strings.Contains(in, "://") {
+			if paths[i], err = filepath.Abs(in); err != nil {
+				return m, err
+			}
+		}
+	}
+	ws, err := NewWorkspace("")
+	if err != nil {
+		return m, err
+	}
+	defer ws.Close()
+	list := ws.Path("list.ffconcat")
+	if err = os.WriteFile(list, []byte(ConcatList(paths)), 0644); err != nil {
+		return m, err
+	}
+	return m, r.Run(ctx, "-y -f concat -safe 0 -i "+list+" -map 0 -c copy "+c.Output)
+}
+
+// filterArgs joins the first video and audio streams of the
+// inputs, if they all have one, with the concat filter. The
+// videos are scaled and padded to the size of the first one,
+// which the filter needs them all to have.
+func (c Concat) filterArgs(infos []*ProbeInfo) string {
+	v, a := 1, 1
+	for _, info := range infos {
+		if len(info.StreamsOf("video")) == 0 {
+			v = 0
+		}
+		if len(info.StreamsOf("audio")) == 0 {
+			a = 0
+		}
+	}
+
+	args := []string{"-y"}
+	var graph strings.Builder
+	if v == 1 {
+		first := infos[0].StreamsOf("video")[0]
+		for i := range c.Inputs {
+			fmt.Fprintf(&graph, "[%d:v:0]", i)
+			if w, h := first.Width, first.Height; w > 0 && h > 0 {
+				fmt.Fprintf(&graph, "scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:-1:-1,", w, h, w, h)
+			}
+			fmt.Fprintf(&graph, "setsar=1[v%d];", i)
+		}
+	}
+	for i, in := range c.Inputs {
+		args = append(args, "-i", in)
+		if v == 1 {
+			fmt.Fprintf(&graph, "[v%d]", i)
+		}
+		if a == 1 {
+			fmt.Fprintf(&graph, "[%d:a:0]", i)
+		}
+	}
+	fmt.Fprintf(&graph, "concat=n=%d:v=%d:a=%d", len(c.Inputs), v, a)
+	if v == 1 {
+		graph.WriteString("[v]")
+	}
+	if a == 1 {
+		graph.WriteString("[a]")
+	}
+
+	args = append(args, "-filter_complex", graph.String())
+	if v == 1 {
+		args = append(args, "-map", "[v]")
+	}
+	if a == 1 {
+		args = append(args, "-map", "[a]")
+	}
+	if c.Args != "" {
+		args = append(args, c.Args)
+	}
+	return strings.Join(append(args, c.Output), " ")
+}
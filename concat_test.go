@@ -0,0 +1,89 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestConcatList(t *testing.T) {
+	l := ffmpeg.ConcatList([]string{"/in/a.mp4", "/in/it's b.mp4"})
+	want := "ffconcat version 1.0\nfile '/in/a.mp4'\nfile '/in/it'\\''s b.mp4'\n"
+	if l != want {
+		t.Errorf("want %q, got %q", want, l)
+	}
+}
+
+func TestChooseConcat(t *testing.T) {
+	stream := func(codec string) ffmpeg.ProbeStream {
+		return ffmpeg.ProbeStream{CodecType: "video", CodecName: codec, Width: 1280, Height: 720}
+	}
+	info := func(format, codec string) *ffmpeg.ProbeInfo {
+		return &ffmpeg.ProbeInfo{
+			Format:  ffmpeg.ProbeFormat{FormatName: format},
+			Streams: []ffmpeg.ProbeStream{stream(codec)},
+		}
+	}
+
+	for _, c := range []struct {
+		infos []*ffmpeg.ProbeInfo
+		want  ffmpeg.ConcatMethod
+	}{
+		{[]*ffmpeg.ProbeInfo{info("mpegts", "h264"), info("mpegts", "h264")}, ffmpeg.ConcatProtocol},
+		{[]*ffmpeg.ProbeInfo{info("mov,mp4", "h264"), info("mpegts", "h264")}, ffmpeg.ConcatDemuxer},
+		{[]*ffmpeg.ProbeInfo{info("mov,mp4", "h264"), info("mov,mp4", "hevc")}, ffmpeg.ConcatFilter},
+	} {
+		if m, err := ffmpeg.ChooseConcat(c.infos); err != nil || m != c.want {
+			t.Errorf("want %s, got %s: %v", c.want, m, err)
+		}
+	}
+	if _, err := ffmpeg.ChooseConcat(nil); err == nil {
+		t.Error("want an error without inputs")
+	}
+}
+
+func TestConcatRun(t *testing.T) {
+	dir := t.TempDir()
+	record := filepath.Join(dir, "list")
+	p := fakeBinary(t, `cp "$7" `+record+"\n")
+	c := ffmpeg.Concat{Inputs: []string{"a.mp4", "b.mp4"}, Output: "out.mp4"}
+
+	m, err := c.Run(context.TODO(), ffmpeg.HookRunner(ffmpeg.CustomPath(p)), fakeBinary(t, fakeProbe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != ffmpeg.ConcatDemuxer {
+		t.Errorf("want demuxer, got %s", m)
+	}
+	wd, _ := os.Getwd()
+	abs := []string{filepath.Join(wd, "a.mp4"), filepath.Join(wd, "b.mp4")}
+	if b, _ := os.ReadFile(record); string(b) != ffmpeg.ConcatList(abs) {
+		t.Errorf("unexpected list %q", b)
+	}
+}
+
+func TestConcatFilter(t *testing.T) {
+	probe := `w=1280 h=720
+case "$*" in *small*) w=640 h=360;; esac
+` + strings.Replace(fakeProbe, `"width": 1280, "height": 720`, `"width": $w, "height": $h`, 1)
+	p, last := argsBinary(t)
+	c := ffmpeg.Concat{Inputs: []string{"a.mp4", "small.mp4"}, Output: "out.mp4"}
+
+	m, err := c.Run(context.TODO(), ffmpeg.HookRunner(ffmpeg.CustomPath(p)), fakeBinary(t, probe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != ffmpeg.ConcatFilter {
+		t.Errorf("want filter, got %s", m)
+	}
+	fit := "scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:-1:-1,setsar=1"
+	want := "-y -i a.mp4 -i small.mp4 -filter_complex [0:v:0]" + fit + "[v0];[1:v:0]" + fit + "[v1];" +
+		"[v0][0:a:0][v1][1:a:0]concat=n=2:v=1:a=1[v][a] -map [v] -map [a] out.mp4"
+	if got := last(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// A CmdSnapshot is the Cmd of a run as started, after the hooks
+// and options applied, see Debug. The secrets are redacted by
+// the Redactor of the runner.
+type CmdSnapshot struct {
+	Path string
+	Args []string // without the path
+
+	// Env are the variables set for FFmpeg, nil if it inherits
+	// the environment as is. The values of the variables whose
+	// name has a Params of the Redactor, e.g. "AWS_SECRET", are
+	// redacted.
+	Env []string
+	Dir string
+
+	// Stdin, Stdout and Stderr are the file names of the
+	// standard files, "/dev/null" for none, or the Go type
+	// of their reader or writer, e.g. "*io.PipeWriter".
+	Stdin  string
+	Stdout string
+	Stderr string
+
+	ExtraFiles []string // the file names, from fd 3 on
+}
+
+// String returns the redacted command line of s, run from its
+// Dir if set, without the Env.
+func (s *CmdSnapshot) String() string {
+	line := CommandLine(exec.Command(s.Path, s.Args...))
+	if s.Dir != "" {
+		return "cd " + shellQuote(s.Dir) + " && " + line
+	}
+	return line
+}
+
+// MarshalJSON implements json.Marshaler, with the command line
+// of s, see String.
+func (s *CmdSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Command    string   `json:"command"`
+		Path       string   `json:"path"`
+		Args       []string `json:"args"`
+		Env        []string `json:"env,omitempty"`
+		Dir        string   `json:"dir,omitempty"`
+		Stdin      string   `json:"stdin"`
+		Stdout     string   `json:"stdout"`
+		Stderr     string   `json:"stderr"`
+		ExtraFiles []string `json:"extra_files,omitempty"`
+	}{s.String(), s.Path, s.Args, s.Env, s.Dir, s.Stdin, s.Stdout, s.Stderr, s.ExtraFiles})
+}
+
+// Debug snapshots the Cmd right before it is started, given
+// as the Cmd of the Started event and of the Result, for
+// support to reproduce exactly what ran.
+func Debug() func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.debug = true
+	}
+}
+
+// snapshot returns the CmdSnapshot of cmd, redacted by rd.
+func snapshot(cmd *exec.Cmd, rd *Redactor) *CmdSnapshot {
+	s := &CmdSnapshot{
+		Path:   cmd.Path,
+		Args:   rd.Args(cmd.Args[1:]),
+		Dir:    cmd.Dir,
+		Stdin:  describeFile(cmd.Stdin),
+		Stdout: describeFile(cmd.Stdout),
+		Stderr: describeFile(cmd.Stderr),
+	}
+	if cmd.Env != nil {
+		s.Env = make([]string, len(cmd.Env))
+		for i, kv := range cmd.Env {
+			s.Env[i] = rd.env(kv)
+		}
+	}
+	for _, f := range cmd.ExtraFiles {
+		s.ExtraFiles = append(s.ExtraFiles, describeFile(f))
+	}
+	return s
+}
+
+// describeFile describes a standard file of a Cmd.
+func describeFile(v interface{}) string {
+	switch f := v.(type) {
+	case nil:
+		return os.DevNull
+	case *os.File:
+		if f == nil {
+			return os.DevNull
+		}
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// env redacts an environment variable "KEY=value".
+func (rd *Redactor) env(kv string) string {
+	if rd == nil {
+		return kv
+	}
+	k, v, _ := strings.Cut(kv, "=")
+	lk := strings.ToLower(k)
+	for _, p := range rd.Params {
+		if strings.Contains(lk, strings.ToLower(p)) {
+			return k + "=" + Redacted
+		}
+	}
+	return k + "=" + rd.String(v)
+}
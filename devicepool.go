@@ -0,0 +1,120 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNoDevice is returned by the Run of a DevicePool without
+// devices or sessions, which would wait forever.
+var ErrNoDevice = errors.New("ffmpeg: no device session in the pool")
+
+// A DevicePool runs FFmpeg with HWAccel on the least loaded of
+// several devices, e.g. the GPUs of a multi-GPU encode host,
+// within a number of sessions per device. The runs wait while
+// all the devices are saturated. A DevicePool is a Runner.
+type DevicePool struct {
+	accel    Accel
+	devices  []int
+	sessions int
+	opts     []func(r *HookedRunner)
+
+	mu      sync.Mutex
+	load    []int         // the sessions running, by device
+	changed chan struct{} // closed when a session ends
+}
+
+// NewDevicePool returns a DevicePool of the devices of a, by
+// index, e.g. 0 and 1 for /dev/nvidia0 and /dev/nvidia1.
+func NewDevicePool(a Accel, devices []int, opts ...func(p *DevicePool)) *DevicePool {
+	p := &DevicePool{
+		accel:    a,
+		devices:  devices,
+		sessions: 3,
+		load:     make([]int, len(devices)),
+		changed:  make(chan struct{}),
+	}
+
+	for _, o := range opts {
+		o(p)
+	}
+
+	return p
+}
+
+// SessionsPerDevice sets how many runs a device runs at the same
+// time, 3 by default: the NVENC session limit of the consumer
+// NVIDIA cards of older drivers.
+func SessionsPerDevice(n int) func(p *DevicePool) {
+	return func(p *DevicePool) {
+		p.sessions = n
+	}
+}
+
+// DeviceRunner sets the options of the HookedRunner of every
+// run, to which HWAccel is added.
+func DeviceRunner(opts ...func(r *HookedRunner)) func(p *DevicePool) {
+	return func(p *DevicePool) {
+		p.opts = opts
+	}
+}
+
+// Run runs arg on the least loaded device with a free session,
+// waiting for one until ctx is done. It returns ErrNoDevice if
+// p has no device, or SessionsPerDevice is not positive.
+func (p *DevicePool) Run(ctx context.Context, arg string) error {
+	i, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.release(i)
+
+	opts := append(p.opts[:len(p.opts):len(p.opts)], HWAccel(p.accel, p.devices[i]))
+	return HookRunner(opts...).Run(ctx, arg)
+}
+
+// Load returns the number of runs in progress, by device in
+// the order given to NewDevicePool.
+func (p *DevicePool) Load() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]int(nil), p.load...)
+}
+
+// acquire returns the index of the device to run on.
+func (p *DevicePool) acquire(ctx context.Context) (int, error) {
+	if len(p.devices) == 0 || p.sessions < 1 {
+		return 0, ErrNoDevice
+	}
+	for {
+		p.mu.Lock()
+		best := -1
+		for i, n := range p.load {
+			if n < p.sessions && (best < 0 || n < p.load[best]) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			p.load[best]++
+			p.mu.Unlock()
+			return best, nil
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func (p *DevicePool) release(i int) {
+	p.mu.Lock()
+	p.load[i]--
+	close(p.changed)
+	p.changed = make(chan struct{})
+	p.mu.Unlock()
+}
@@ -0,0 +1,82 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestDevicePool(t *testing.T) {
+	dir := t.TempDir()
+	// records the device of the run in a file named after the output
+	p := fakeBinary(t, `for a; do :; done
+echo "$@" > `+dir+`/"$a"
+sleep 0.2
+`)
+	pool := ffmpeg.NewDevicePool(ffmpeg.NVENC, []int{0, 1},
+		ffmpeg.SessionsPerDevice(1), ffmpeg.DeviceRunner(ffmpeg.CustomPath(p)))
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, out := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		wg.Add(1)
+		go func(out string) {
+			defer wg.Done()
+			if err := pool.Run(context.TODO(), "-i in.mp4 -c:v libx264 "+out); err != nil {
+				t.Error(err)
+			}
+		}(out)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if load := pool.Load(); load[0] != 1 || load[1] != 1 {
+		t.Errorf("unexpected load %v", load)
+	}
+	wg.Wait()
+	if d := time.Since(start); d < 400*time.Millisecond {
+		t.Errorf("the third run did not wait: %s", d)
+	}
+
+	devices := map[string]int{}
+	for _, out := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		b, _ := os.ReadFile(filepath.Join(dir, out))
+		for _, d := range []string{"0", "1"} {
+			if strings.Contains(string(b), "-hwaccel_device "+d+" ") && strings.Contains(string(b), "h264_nvenc -gpu "+d) {
+				devices[d]++
+			}
+		}
+	}
+	if devices["0"]+devices["1"] != 3 || devices["0"] == 0 || devices["1"] == 0 {
+		t.Errorf("unexpected devices %v", devices)
+	}
+}
+
+func TestDevicePoolCancel(t *testing.T) {
+	p := fakeBinary(t, "sleep 1\n")
+	pool := ffmpeg.NewDevicePool(ffmpeg.VAAPI, []int{0}, ffmpeg.SessionsPerDevice(1), ffmpeg.DeviceRunner(ffmpeg.CustomPath(p)))
+	go pool.Run(context.TODO(), "-i in.mp4 out.mp4")
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	if err := pool.Run(ctx, "-i in.mp4 out.mp4"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded, got %v", err)
+	}
+}
+
+func TestDevicePoolEmpty(t *testing.T) {
+	for _, pool := range []*ffmpeg.DevicePool{
+		ffmpeg.NewDevicePool(ffmpeg.NVENC, nil),
+		ffmpeg.NewDevicePool(ffmpeg.NVENC, []int{0}, ffmpeg.SessionsPerDevice(0)),
+	} {
+		if err := pool.Run(context.TODO(), "-i in.mp4 out.mp4"); !errors.Is(err, ffmpeg.ErrNoDevice) {
+			t.Errorf("want no device, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrDiskFull is returned, wrapping the exit error if any, when
+// an output filesystem of a DiskGuard run was, or got, below its
+// MinFree.
+var ErrDiskFull = errors.New("ffmpeg: not enough disk space")
+
+// ErrOutputTooLarge is returned, wrapping the exit error if any,
+// when FFmpeg was stopped by a DiskGuard for its outputs growing
+// beyond MaxOutput.
+var ErrOutputTooLarge = errors.New("ffmpeg: output too large")
+
+// A DiskQuota is the disk space a run may use, see DiskGuard.
+type DiskQuota struct {
+	// MinFree is the space, in bytes, to keep free on the
+	// filesystems of the outputs.
+	MinFree int64
+
+	// Estimate is the expected size of the outputs, in bytes, see
+	// EstimateSize, required free on top of MinFree to start.
+	Estimate int64
+
+	// MaxOutput caps the size of the outputs, in bytes, none if 0.
+	MaxOutput int64
+
+	// Interval is the period of the checks while FFmpeg runs,
+	// 1s by default.
+	Interval time.Duration
+}
+
+// DiskGuard makes the runner refuse to start FFmpeg, returning
+// an error wrapping ErrDiskFull, when a filesystem of the outputs
+// has less than the MinFree and Estimate of q free. While FFmpeg
+// runs, it is stopped with the exit hook (see DoneHook) when a
+// filesystem gets below MinFree, or when the outputs grow beyond
+// MaxOutput: the files of the patterns such as "%03d.ts" or
+// "%Y%m%d-%H%M%S.mp4" (-strftime 1) and the segments of
+// -hls_segment_filename count.
+//
+// Outputs with a protocol and "-" are not guarded. The free
+// space is only checked on Linux, macOS, FreeBSD and DragonFly.
+func DiskGuard(q DiskQuota) func(r *HookedRunner) {
+	if q.Interval <= 0 {
+		q.Interval = time.Second
+	}
+	return func(r *HookedRunner) {
+		r.quota = &q
+	}
+}
+
+// EstimateSize returns the size, in bytes, of d of media at the
+// bitrate, in bits/s, e.g. the target bitrates of the video and
+// audio summed, or the BitRate of a similar output probed.
+func EstimateSize(d time.Duration, bitrate int64) int64 {
+	return int64(d.Seconds() * float64(bitrate) / 8)
+}
+
+// outputFiles returns the outputs of cmd DiskGuard guards, the
+// HLS segments included, relative ones joined with cmd.Dir.
+func (r *HookedRunner) outputFiles(cmd *exec.Cmd) []string {
+	args := cmd.Args[len(r.wrap)+1:]
+	var files []string
+	walkArgs(args, func(flag string, i int) bool {
+		o := args[i]
+		if flag == "-hls_segment_filename" && i+1 < len(args) {
+			o = args[i+1]
+		} else if flag != "" {
+			return true
+		}
+		if o == "-" || o == os.DevNull || schemeRe.MatchString(o) {
+			return true
+		}
+		if !filepath.IsAbs(o) && cmd.Dir != "" {
+			o = filepath.Join(cmd.Dir, o)
+		}
+		files = append(files, o)
+		return true
+	})
+	return files
+}
+
+// checkFree returns an error wrapping ErrDiskFull if a filesystem
+// of files has less than need bytes free.
+func checkFree(files []string, need int64) error {
+	checked := make(map[string]bool)
+	for _, f := range files {
+		dir := filepath.Dir(f)
+		if checked[dir] {
+			continue
+		}
+		checked[dir] = true
+		free, err := DiskFree(dir)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if free < need {
+			return fmt.Errorf("%w: %d bytes free in %s, %d needed", ErrDiskFull, free, dir, need)
+		}
+	}
+	return nil
+}
+
+// patternRe matches the conversions of the image2, segment and
+// HLS patterns, and of strftime.
+var patternRe = regexp.MustCompile(`%0?\d*[a-zA-Z]`)
+
+// outputSize returns the size of files, the files of their
+// patterns summed.
+func outputSize(files []string) int64 {
+	var size int64
+	for _, f := range files {
+		names := []string{f}
+		if strings.Contains(f, "%") {
+			names, _ = filepath.Glob(patternRe.ReplaceAllString(f, "*"))
+		}
+		for _, n := range names {
+			if fi, err := os.Stat(n); err == nil {
+				size += fi.Size()
+			}
+		}
+	}
+	return size
+}
+
+// watch checks files every Interval until stop is closed,
+// sending ErrDiskFull or ErrOutputTooLarge on exceeded once q
+// is exceeded.
+func (q *DiskQuota) watch(files []string, stop <-chan struct{}, exceeded chan<- error) {
+	t := time.NewTicker(q.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		var err error
+		if q.MaxOutput > 0 && outputSize(files) > q.MaxOutput {
+			err = ErrOutputTooLarge
+		} else if q.MinFree > 0 && errors.Is(checkFree(files, q.MinFree), ErrDiskFull) {
+			err = ErrDiskFull
+		}
+		if err != nil {
+			exceeded <- err
+			return
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || dragonfly)
+
+package ffmpeg
+
+import "errors"
+
+// DiskFree returns the space, in bytes, available to the program
+// on the filesystem of path.
+func DiskFree(path string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package ffmpeg
+
+import "syscall"
+
+// DiskFree returns the space, in bytes, available to the program
+// on the filesystem of path.
+func DiskFree(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -0,0 +1,65 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestDiskGuard(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ffmpeg.DiskFree(dir); errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.mp4")
+
+	// a fake writing its output and not exiting
+	p := fakeBinary(t, `for a; do o=$a; done
+echo data > $o
+exec sleep 10
+`)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.DiskGuard(ffmpeg.DiskQuota{MinFree: 1 << 62}))
+	if res, err := r.RunResult(context.TODO(), "-i in.mp4 "+out); !errors.Is(err, ffmpeg.ErrDiskFull) || res != nil {
+		t.Errorf("want disk full, got %v", err)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("ffmpeg started")
+	}
+
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.DiskGuard(ffmpeg.DiskQuota{
+		MaxOutput: 4,
+		Interval:  10 * time.Millisecond,
+	}))
+	start := time.Now()
+	res, err := r.RunResult(context.TODO(), "-i in.mp4 "+out)
+	if !errors.Is(err, ffmpeg.ErrOutputTooLarge) || res.Reason != ffmpeg.StopQuota || time.Since(start) > 5*time.Second {
+		t.Errorf("want output too large, got %v", err)
+	}
+
+	// relative strftime segments, the playlist staying empty
+	p = fakeBinary(t, `for a; do o=$a; done
+touch $o; echo data > seg-20261014.ts
+exec sleep 10
+`)
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.WorkingDir(dir), ffmpeg.DiskGuard(ffmpeg.DiskQuota{
+		MaxOutput: 4,
+		Interval:  10 * time.Millisecond,
+	}))
+	arg := "-i in.mp4 -f hls -strftime 1 -hls_segment_filename seg-%Y%m%d.ts index.m3u8"
+	if _, err := r.RunResult(context.TODO(), arg); !errors.Is(err, ffmpeg.ErrOutputTooLarge) {
+		t.Errorf("want segments too large, got %v", err)
+	}
+}
+
+func TestEstimateSize(t *testing.T) {
+	if s := ffmpeg.EstimateSize(time.Minute, 2_000_000); s != 15_000_000 {
+		t.Errorf("unexpected size %d", s)
+	}
+}
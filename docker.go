@@ -0,0 +1,99 @@
+package ffmpeg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os/exec"
+	"strconv"
+)
+
+// A Container describes how DockerRunner runs FFmpeg.
+type Container struct {
+	Image string // e.g. "jrottenberg/ffmpeg:6.1-ubuntu"
+
+	// Command is the FFmpeg path in the image, if the
+	// entrypoint of the image is not FFmpeg already.
+	Command string
+
+	// Volumes are mounted with -v, e.g. "/data/in:/in:ro".
+	// The paths in the args are the ones in the container.
+	Volumes []string
+
+	GPUs    string  // given to --gpus, e.g. "all" or "device=0"
+	Memory  string  // given to --memory, e.g. "2g"
+	CPUs    float64 // given to --cpus, 0 for no limit
+	Network string  // given to --network, e.g. "none"
+	User    string  // given to --user, e.g. "1000:1000"
+
+	// Flags are other docker run flags, e.g. "--read-only".
+	Flags []string
+}
+
+// args returns the docker run args of a container named name.
+func (c *Container) args(name string) []string {
+	args := []string{"run", "--rm", "-i", "--name", name}
+	for _, v := range c.Volumes {
+		args = append(args, "-v", v)
+	}
+	opt := func(flag, v string) {
+		if v != "" {
+			args = append(args, flag, v)
+		}
+	}
+	opt("--gpus", c.GPUs)
+	opt("--memory", c.Memory)
+	if c.CPUs > 0 {
+		opt("--cpus", strconv.FormatFloat(c.CPUs, 'f', -1, 64))
+	}
+	opt("--network", c.Network)
+	opt("--user", c.User)
+	args = append(args, c.Flags...)
+	args = append(args, c.Image)
+	if c.Command != "" {
+		args = append(args, c.Command)
+	}
+	return args
+}
+
+// DockerRunner returns a HookedRunner running FFmpeg in a new
+// container of c for every run, through the docker CLI (use
+// CustomPath to run e.g. podman instead). The hooks apply to the
+// CLI process, which relays stdin, stdout, stderr and signals
+// such as SIGTERM to FFmpeg. The default exit hook removes the
+// container with "docker kill" then kills the CLI.
+//
+// Options acting on the local binary, such as MinVersion, Nice
+// or Cgroup, are not meaningful for a DockerRunner.
+func DockerRunner(c Container, opts ...func(r *HookedRunner)) *HookedRunner {
+	r := HookRunner(CustomPath("docker"), DoneHook(func(cmd *exec.Cmd) {
+		if name := flagValue(cmd.Args, "--name"); name != "" {
+			exec.Command(cmd.Path, "kill", name).Run()
+		}
+		cmd.Process.Kill()
+	}))
+	r.lead = func() []string {
+		return c.args(containerName())
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+func containerName() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "ffmpeg-" + hex.EncodeToString(b)
+}
+
+// flagValue returns the value following flag in args.
+func flagValue(args []string, flag string) string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestDockerRunner(t *testing.T) {
+	docker, last := argsBinary(t)
+
+	r := ffmpeg.DockerRunner(ffmpeg.Container{
+		Image:   "jrottenberg/ffmpeg:6.1-ubuntu",
+		Volumes: []string{"/data/in:/in:ro", "/data/out:/out"},
+		GPUs:    "all",
+		Memory:  "2g",
+		CPUs:    1.5,
+	}, ffmpeg.CustomPath(docker), ffmpeg.Threads(2))
+
+	if err := r.Run(context.TODO(), "-i /in/a.mp4 /out/a.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`^run --rm -i --name ffmpeg-[0-9a-f]{16} ` +
+		`-v /data/in:/in:ro -v /data/out:/out --gpus all --memory 2g --cpus 1.5 ` +
+		`jrottenberg/ffmpeg:6.1-ubuntu -i /in/a.mp4 -threads 2 /out/a.mp4$`)
+	if got := last(); !re.MatchString(got) {
+		t.Errorf("unexpected args %q", got)
+	}
+}
+
+func TestDockerRunnerCancel(t *testing.T) {
+	// a docker CLI recording its args and running for a while
+	docker, last := argsBinary(t)
+	slow := fakeBinary(t, docker+` "$@"; [ "$1" = run ] && sleep 10`+"\n")
+
+	var name string
+	r := ffmpeg.DockerRunner(ffmpeg.Container{Image: "ffmpeg"}, ffmpeg.CustomPath(slow),
+		ffmpeg.PostHook(func(cmd *exec.Cmd) {
+			name = cmd.Args[5]
+		}))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	r.Run(ctx, "-i a.mp4 b.mp4")
+
+	if got := last(); got != "kill "+name || !strings.HasPrefix(name, "ffmpeg-") {
+		t.Errorf("container not killed: %q", got)
+	}
+}
@@ -0,0 +1,42 @@
+package ffmpeg
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Env adds variables, as "KEY=value", to the environment FFmpeg
+// inherits, e.g. "FFREPORT=file=report.log", "FONTCONFIG_PATH"
+// or "CUDA_VISIBLE_DEVICES". Env can be given several times,
+// the last value of a key wins.
+func Env(kv ...string) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.env = append(r.env, kv...)
+	}
+}
+
+// WorkingDir runs FFmpeg in dir, which the relative paths of
+// the args are then relative to.
+func WorkingDir(dir string) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.dir = dir
+	}
+}
+
+// ExtraFiles passes files to FFmpeg as the file descriptors
+// from 3 on, e.g. for "pipe:3" inputs or outputs.
+func ExtraFiles(files ...*os.File) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.extraFiles = append(r.extraFiles, files...)
+	}
+}
+
+// setEnv applies the environment options to cmd, before the
+// pre hook.
+func (r *HookedRunner) setEnv(cmd *exec.Cmd) {
+	if len(r.env) > 0 {
+		cmd.Env = append(os.Environ(), r.env...)
+	}
+	cmd.Dir = r.dir
+	cmd.ExtraFiles = r.extraFiles
+}
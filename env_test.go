@@ -0,0 +1,37 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestEnv(t *testing.T) {
+	dir, out := t.TempDir(), filepath.Join(t.TempDir(), "out")
+	p := fakeBinary(t, `echo "$FFREPORT $CUDA_VISIBLE_DEVICES $(pwd)" > `+out+"\necho extra >&3\n")
+	f, err := os.Create(filepath.Join(t.TempDir(), "extra"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p),
+		ffmpeg.Env("FFREPORT=file=report.log", "CUDA_VISIBLE_DEVICES=0"),
+		ffmpeg.Env("CUDA_VISIBLE_DEVICES=1"),
+		ffmpeg.WorkingDir(dir),
+		ffmpeg.ExtraFiles(f))
+	if err = r.Run(context.TODO(), "-i in.mp4 pipe:3"); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.EvalSymlinks(dir)
+	if b, _ := os.ReadFile(out); strings.TrimSpace(string(b)) != "file=report.log 1 "+want {
+		t.Errorf("unexpected env %q", b)
+	}
+	if b, _ := os.ReadFile(f.Name()); string(b) != "extra\n" {
+		t.Errorf("unexpected extra file %q", b)
+	}
+}
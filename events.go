@@ -0,0 +1,278 @@
+package ffmpeg
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// An Event is something that happened during a run. It is
+// one of Resolved, Started, Progress, Stalled, Signalled and
+// Exited.
+type Event interface {
+	isEvent()
+}
+
+// Resolved is emitted once the command to run is built.
+// The Args are redacted, see WithRedactor.
+type Resolved struct {
+	Path string
+	Args []string
+}
+
+// Started is emitted once the process started.
+type Started struct {
+	Pid int
+	Cmd *CmdSnapshot // with Debug, nil otherwise
+}
+
+// Progress is emitted for every stats line FFmpeg writes
+// to stderr, e.g. "frame=  100 fps= 25 ... speed=1.0x".
+// Fields FFmpeg reports as N/A are zero.
+type Progress struct {
+	Frame   int64
+	FPS     float64
+	Size    int64         // output size in bytes
+	Time    time.Duration // output timestamp
+	Bitrate float64       // kbits/s
+	Speed   float64       // relative to real time
+}
+
+// Stalled is emitted when no Progress was seen for the
+// duration set by StallTimeout. It is emitted again after
+// another such duration without progress.
+type Stalled struct {
+	Since time.Duration
+}
+
+// Signalled is emitted when the context is done or the
+// Timeout expired and the exit hook is about to be called.
+type Signalled struct {
+	// Cause is the ctx.Err(), ErrTimeout, ErrStalled,
+	// ErrInterrupted, ErrDiskFull or ErrOutputTooLarge.
+	Cause error
+}
+
+// Exited is emitted once the process exited, or failed
+// to start, in which case Code is -1.
+type Exited struct {
+	Code     int
+	Err      error
+	Duration time.Duration
+	Reason   StopReason
+}
+
+func (Resolved) isEvent()  {}
+func (Started) isEvent()   {}
+func (Progress) isEvent()  {}
+func (Stalled) isEvent()   {}
+func (Signalled) isEvent() {}
+func (Exited) isEvent()    {}
+
+// Subscribe calls f with every Event of every run. Subscribe
+// can be given several times. The calls for a run never
+// overlap, but they come from different goroutines and
+// should not block.
+func Subscribe(f func(e Event)) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.subs = append(r.subs, f)
+	}
+}
+
+// Events sends every Event of every run to ch. The runner
+// blocks on the send, so ch should be buffered and drained.
+func Events(ch chan<- Event) func(r *HookedRunner) {
+	return Subscribe(func(e Event) {
+		ch <- e
+	})
+}
+
+// StallTimeout emits Stalled when a running FFmpeg reported
+// no progress for d.
+func StallTimeout(d time.Duration) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.stall = d
+	}
+}
+
+// An emitter serializes the events of a run.
+type emitter struct {
+	mu         sync.Mutex
+	subs       []func(e Event)
+	progressed chan struct{}
+	paused     atomic.Bool
+	toggled    chan struct{}
+}
+
+// newEmitter returns nil if r has no subscribers and does not
+// StopOnStall. The subscribers of SubscribeLabels get l.
+func (r *HookedRunner) newEmitter(l Labels) *emitter {
+	if len(r.subs) == 0 && len(r.labelSubs) == 0 && !r.stopStall {
+		return nil
+	}
+	subs := r.subs
+	for _, f := range r.labelSubs {
+		f := f
+		subs = append(subs[:len(subs):len(subs)], func(e Event) { f(e, l) })
+	}
+	return &emitter{
+		subs:       subs,
+		progressed: make(chan struct{}, 1),
+		toggled:    make(chan struct{}, 1),
+	}
+}
+
+func (em *emitter) emit(e Event) {
+	if em == nil {
+		return
+	}
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	for _, f := range em.subs {
+		f(e)
+	}
+}
+
+// line emits a Progress if line is a stats line.
+func (em *emitter) line(line string) {
+	if em == nil {
+		return
+	}
+	// the stats are logged at info with -loglevel +level
+	if p, ok := parseStats(strings.TrimPrefix(line, "[info] ")); ok {
+		em.progress(p)
+	}
+}
+
+// progress emits p and resets the stall watch.
+func (em *emitter) progress(p Progress) {
+	if em == nil {
+		return
+	}
+	select {
+	case em.progressed <- struct{}{}:
+	default:
+	}
+	em.emit(p)
+}
+
+// pause stops the stall watch while FFmpeg is paused.
+func (em *emitter) pause(paused bool) {
+	if em == nil {
+		return
+	}
+	em.paused.Store(paused)
+	select {
+	case em.toggled <- struct{}{}:
+	default:
+	}
+}
+
+// watch emits Stalled every d without progress until stop
+// is closed, also signalling stalled if not nil. The clock is
+// stopped while paused, and restarted on resume.
+func (em *emitter) watch(d time.Duration, stop <-chan struct{}, stalled chan<- struct{}) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-em.progressed:
+			last = time.Now()
+		case <-em.toggled:
+			last = time.Now()
+		case <-t.C:
+			em.emit(Stalled{Since: time.Since(last)})
+			select {
+			case stalled <- struct{}{}:
+			default:
+			}
+		case <-stop:
+			return
+		}
+		if !t.Stop() {
+			select {
+			case <-t.C:
+			default:
+			}
+		}
+		if !em.paused.Load() {
+			t.Reset(d)
+		}
+	}
+}
+
+var statsRe = regexp.MustCompile(`(\w+)=\s*(\S+)`)
+
+// parseStats parses a FFmpeg stats line.
+func parseStats(line string) (Progress, bool) {
+	var p Progress
+	if !strings.HasPrefix(line, "frame=") && !strings.HasPrefix(line, "size=") {
+		return p, false
+	}
+	for _, m := range statsRe.FindAllStringSubmatch(line, -1) {
+		v := m[2]
+		switch m[1] {
+		case "frame":
+			p.Frame, _ = strconv.ParseInt(v, 10, 64)
+		case "fps":
+			p.FPS, _ = strconv.ParseFloat(v, 64)
+		case "size", "Lsize":
+			p.Size = parseSize(v)
+		case "time":
+			p.Time = parseClock(v)
+		case "bitrate":
+			p.Bitrate, _ = strconv.ParseFloat(strings.TrimSuffix(v, "kbits/s"), 64)
+		case "speed":
+			p.Speed, _ = strconv.ParseFloat(strings.TrimSuffix(v, "x"), 64)
+		}
+	}
+	return p, true
+}
+
+// parseSize parses sizes like "512kB", "512KiB" or "3MiB".
+func parseSize(v string) int64 {
+	i := strings.IndexFunc(v, func(c rune) bool {
+		return (c < '0' || c > '9') && c != '.'
+	})
+	if i < 0 {
+		i = len(v)
+	}
+	n, err := strconv.ParseFloat(v[:i], 64)
+	if err != nil {
+		return 0
+	}
+	switch v[i:] {
+	case "kB", "KiB":
+		n *= 1 << 10
+	case "MiB":
+		n *= 1 << 20
+	case "GiB":
+		n *= 1 << 30
+	}
+	return int64(n)
+}
+
+// parseClock parses timestamps like "00:01:02.50".
+func parseClock(v string) time.Duration {
+	neg := strings.HasPrefix(v, "-")
+	fs := strings.Split(strings.TrimPrefix(v, "-"), ":")
+	if len(fs) != 3 {
+		return 0
+	}
+	h, err1 := strconv.Atoi(fs[0])
+	m, err2 := strconv.Atoi(fs[1])
+	s, err3 := strconv.ParseFloat(fs[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(s*float64(time.Second))
+	if neg {
+		d = -d
+	}
+	return d
+}
@@ -0,0 +1,66 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestEvents(t *testing.T) {
+	p := fakeBinary(t, `printf 'frame=   50 fps= 25 q=28.0 size=     512kB time=00:00:02.00 bitrate=2097.2kbits/s speed=1.01x\r' >&2
+sleep 0.3
+exit 1
+`)
+
+	ch := make(chan ffmpeg.Event, 16)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p),
+		ffmpeg.Events(ch), ffmpeg.StallTimeout(100*time.Millisecond))
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err == nil {
+		t.Fatal("want exit error")
+	}
+	close(ch)
+
+	var events []ffmpeg.Event
+	for e := range ch {
+		events = append(events, e)
+	}
+	if len(events) < 5 {
+		t.Fatalf("too few events: %#v", events)
+	}
+
+	if e, ok := events[0].(ffmpeg.Resolved); !ok || e.Path != p {
+		t.Errorf("want Resolved, got %#v", events[0])
+	}
+	if e, ok := events[1].(ffmpeg.Started); !ok || e.Pid == 0 {
+		t.Errorf("want Started, got %#v", events[1])
+	}
+	want := ffmpeg.Progress{Frame: 50, FPS: 25, Size: 512 << 10,
+		Time: 2 * time.Second, Bitrate: 2097.2, Speed: 1.01}
+	if events[2] != want {
+		t.Errorf("got %#v, want %#v", events[2], want)
+	}
+	if _, ok := events[3].(ffmpeg.Stalled); !ok {
+		t.Errorf("want Stalled, got %#v", events[3])
+	}
+	if e, ok := events[len(events)-1].(ffmpeg.Exited); !ok || e.Code != 1 || e.Err == nil {
+		t.Errorf("want Exited, got %#v", events[len(events)-1])
+	}
+}
+
+func TestEventsSignalled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	var signalled bool
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep"), ffmpeg.Subscribe(func(e ffmpeg.Event) {
+		if s, ok := e.(ffmpeg.Signalled); ok {
+			signalled = s.Cause == context.DeadlineExceeded
+		}
+	}))
+	r.Run(ctx, "10")
+	if !signalled {
+		t.Error("want Signalled")
+	}
+}
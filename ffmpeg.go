@@ -5,9 +5,16 @@ from Go code.
 package ffmpeg
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"log/slog"
+	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
+	"sync"
+	"time"
 )
 
 // A Runner runs FFmpeg.
@@ -30,34 +37,122 @@ type ErrHook func(cmd *exec.Cmd) error
 // FFmpeg Command before/after FFmpeg starts and when
 // the exit signal received.
 type HookedRunner struct {
-	path string // the path of FFmpeg binary
-	pre  ErrHook
-	post Hook
-	exit Hook
+	tool  Tool
+	paths []string // the candidate paths of FFmpeg binary
+	pre   ErrHook
+	post  Hook
+	exit  Hook
+	dry   Hook
+
+	lines  []func(line string)
+	levels bool // -loglevel +level
+	subs   []func(e Event)
+	stall  time.Duration
+
+	timeout   time.Duration
+	stopStall bool
+	signals   []os.Signal // forwarded
+	quota     *DiskQuota
+	history   History
+	labels    Labels
+	labelSubs []func(e Event, l Labels)
+
+	global     []string
+	env        []string
+	dir        string
+	extraFiles []*os.File
+
+	statsPeriod time.Duration
+	progressNet string // of the -progress listener
+
+	logger   *slog.Logger
+	level    slog.Level
+	redactor *Redactor
+	policy   *Policy
+
+	rewrites []func(args []string) []string // applied in order
+	lead     func() []string                // args before the FFmpeg ones
+	wrap     []string                       // command prefix, e.g. nice -n 10
+	setups   []func(cmd *exec.Cmd) (undo func(), err error)
+	atomic   bool
+	validate func(path string) error
+
+	onProcess []func(p *Process)
+	debug     bool // snapshot the Cmd
+
+	minVer   []int
+	mu       sync.Mutex
+	versions map[string]*VersionInfo // detected, by path
 }
 
 // Run runs the command (path + arg) and waits for its exit
 // or the context timeout.
 func (r *HookedRunner) Run(ctx context.Context, arg string) error {
-	// look for binary path
-	path, err := exec.LookPath(r.path)
+	_, err := r.RunResult(ctx, arg)
+	return err
+}
+
+// RunResult is like Run but also returns a Result describing
+// the process once it exited. The Result is nil if the process
+// was not started.
+func (r *HookedRunner) RunResult(ctx context.Context, arg string) (*Result, error) {
+	labels := r.runLabels(ctx)
+	em := r.newEmitter(labels)
+	var command string
+	var args []string // redacted
+	fail := func(err error) (*Result, error) {
+		r.logErr(ctx, "ffmpeg not started", err)
+		em.emit(Exited{Code: -1, Err: err, Reason: StopFailed})
+		return nil, runError(err, command, args, labels)
+	}
+
+	cmd, err := r.Plan(ctx, arg)
 	if err != nil {
-		return err
+		return fail(err)
 	}
+	command = r.redactor.CommandLine(cmd)
+	args = r.redactor.Args(cmd.Args[1:])
+	r.log(ctx, "ffmpeg resolved", "path", cmd.Path, "args", args)
+	em.emit(Resolved{Path: cmd.Path, Args: args})
 
-	// convert arg string to args slices
-	args := strings.Fields(arg)
-	cmd := exec.Command(path, args...)
+	if r.dry != nil {
+		r.dry(cmd)
+		return nil, nil
+	}
 
-	if r.pre != nil {
-		if err = r.pre(cmd); err != nil {
-			return err
+	var files []string // guarded
+	if r.quota != nil {
+		files = r.outputFiles(cmd)
+		if err = checkFree(files, r.quota.MinFree+r.quota.Estimate); err != nil {
+			return fail(err)
+		}
+	}
+
+	for _, setup := range r.setups {
+		undo, err := setup(cmd)
+		if err != nil {
+			return fail(err)
 		}
+		defer undo()
 	}
 
+	stopProgress, err := r.listenProgress(cmd, em)
+	if err != nil {
+		return fail(err)
+	}
+	stopLines := r.attachLines(cmd, em)
+	var snap *CmdSnapshot
+	if r.debug {
+		snap = snapshot(cmd, r.redactor)
+	}
 	if err = cmd.Start(); err != nil {
-		return err
+		stopLines()
+		stopProgress()
+		return fail(err)
 	}
+	start := time.Now()
+	r.log(ctx, "ffmpeg started", "pid", cmd.Process.Pid)
+	em.emit(Started{Pid: cmd.Process.Pid, Cmd: snap})
 
 	if r.post != nil {
 		r.post(cmd)
@@ -66,25 +161,184 @@ func (r *HookedRunner) Run(ctx context.Context, arg string) error {
 	// controls
 	done := ctx.Done()
 	cleanup := make(chan struct{})
+	var timeout <-chan time.Time
+	onPause := []func(paused bool){em.pause}
+	if r.timeout > 0 {
+		t := time.NewTimer(r.timeout)
+		defer t.Stop()
+		timeout = t.C
+		left, since := r.timeout, start
+		onPause = append(onPause, func(paused bool) {
+			if paused {
+				t.Stop()
+				left -= time.Since(since)
+			} else {
+				since = time.Now()
+				t.Reset(left)
+			}
+		})
+	}
+
+	var stalled chan struct{}
+	if r.stopStall {
+		stalled = make(chan struct{}, 1)
+	}
+	var signals chan os.Signal
+	if len(r.signals) > 0 {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, r.signals...)
+		defer signal.Stop(signals)
+	}
+	var exceeded chan error
+	if r.quota != nil {
+		exceeded = make(chan error, 1)
+	}
+
+	var proc *Process
+	if len(r.onProcess) > 0 {
+		proc = &Process{proc: cmd.Process, onPause: onPause}
+		for _, f := range r.onProcess {
+			f(proc)
+		}
+	}
 
 	// exit handling
+	var cause error // why it was stopped, only read after bg.Wait
+	var bg sync.WaitGroup
+	bg.Add(1)
 	go func() {
+		defer bg.Done()
 		select {
 		case <-done:
-			if r.exit != nil {
-				r.exit(cmd)
-			}
+			cause = ctx.Err()
+		case <-timeout:
+			cause = ErrTimeout
+		case <-stalled:
+			cause = ErrStalled
+		case <-signals:
+			cause = ErrInterrupted
+		case cause = <-exceeded:
 		case <-cleanup:
 			return
 		}
+		em.emit(Signalled{Cause: cause})
+		if proc != nil {
+			proc.cont()
+		}
+		if r.exit != nil {
+			r.exit(cmd)
+		}
 	}()
 
+	if em != nil && r.stall > 0 {
+		bg.Add(1)
+		go func() {
+			defer bg.Done()
+			em.watch(r.stall, cleanup, stalled)
+		}()
+	}
+	if r.quota != nil && (r.quota.MaxOutput > 0 || r.quota.MinFree > 0) {
+		bg.Add(1)
+		go func() {
+			defer bg.Done()
+			r.quota.watch(files, cleanup, exceeded)
+		}()
+	}
+
 	err = cmd.Wait()
+	stopLines()
+	stopProgress()
 
 	// cleanup the exit handling goroutine
 	close(cleanup)
+	bg.Wait()
 
-	return err
+	res := newResult(cmd.ProcessState, time.Since(start), cause)
+	res.Cmd = snap
+	err = r.stopError(cause, err)
+	if r.atomic {
+		err = r.finishAtomic(cmd, err)
+	}
+	r.logExit(ctx, cmd, res.Duration, err)
+	if r.history != nil {
+		rec := RunRecord{Start: start, Path: cmd.Path, Args: args, Labels: labels}
+		if herr := r.record(rec, res, err); herr != nil {
+			r.logErr(ctx, "ffmpeg run not recorded", herr)
+		}
+	}
+	em.emit(Exited{Code: res.Code, Err: err, Duration: res.Duration, Reason: res.Reason})
+
+	return res, runError(err, command, args, labels)
+}
+
+// Plan resolves the binary path and builds the Cmd that Run
+// would start for arg, including the effect of the pre hook,
+// without starting it.
+func (r *HookedRunner) Plan(ctx context.Context, arg string) (*exec.Cmd, error) {
+	// look for binary path
+	path, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// convert arg string to args slices
+	args := strings.Fields(arg)
+	if r.policy != nil {
+		if err = r.policy.Validate(args); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range r.rewrites {
+		args = f(args)
+	}
+	args = append(append(r.globalArgs(args), r.progressArgs(ctx, path)...), args...)
+	if r.levels {
+		args = append([]string{"-loglevel", "+level"}, args...)
+	}
+	if r.lead != nil {
+		args = append(r.lead(), args...)
+	}
+	cmd := exec.Command(path, args...)
+	if len(r.wrap) > 0 {
+		wp, err := exec.LookPath(r.wrap[0])
+		if err != nil {
+			return nil, err
+		}
+		wargs := append(r.wrap[1:len(r.wrap):len(r.wrap)], path)
+		cmd = exec.Command(wp, append(wargs, args...)...)
+	}
+
+	r.setEnv(cmd)
+
+	if r.pre != nil {
+		if err = r.pre(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmd, nil
+}
+
+// resolve returns the first candidate path found by
+// exec.LookPath and meeting the MinVersion, if any.
+func (r *HookedRunner) resolve(ctx context.Context) (string, error) {
+	var err error
+	for _, p := range r.paths {
+		var path string
+		if path, err = exec.LookPath(p); err != nil {
+			continue
+		}
+		if r.minVer != nil {
+			if err = r.checkVersion(ctx, path); err != nil {
+				continue
+			}
+		}
+		return path, nil
+	}
+	if len(r.paths) > 1 {
+		err = fmt.Errorf("ffmpeg: no usable binary in %v: %w", r.paths, err)
+	}
+	return "", err
 }
 
 // HookRunner returns a HookedRunner.
@@ -92,7 +346,9 @@ func (r *HookedRunner) Run(ctx context.Context, arg string) error {
 // and kill (-9) the process when receiving a exit signal.
 func HookRunner(opts ...func(r *HookedRunner)) *HookedRunner {
 	r := &HookedRunner{
-		path: "ffmpeg",
+		tool:     FFmpeg,
+		paths:    []string{"ffmpeg"},
+		redactor: DefaultRedactor,
 		exit: func(cmd *exec.Cmd) {
 			cmd.Process.Kill()
 		},
@@ -107,9 +363,14 @@ func HookRunner(opts ...func(r *HookedRunner)) *HookedRunner {
 
 // CustomPath sets the ffmpeg binary path.
 // It should be able to found by exec.LookPath.
-func CustomPath(p string) func(r *HookedRunner) {
+// Given several candidate paths, the runner uses the
+// first one found and meeting the MinVersion, if any.
+// Without paths, the path is left as is.
+func CustomPath(paths ...string) func(r *HookedRunner) {
 	return func(r *HookedRunner) {
-		r.path = p
+		if len(paths) > 0 {
+			r.paths = paths
+		}
 	}
 }
 
@@ -139,3 +400,49 @@ func DoneHook(h Hook) func(r *HookedRunner) {
 		r.exit = h
 	}
 }
+
+// output runs the binary at path with arg and returns its stdout.
+func output(ctx context.Context, path, arg string) ([]byte, error) {
+	var buf bytes.Buffer
+	r := HookRunner(CustomPath(path), PreHook(func(cmd *exec.Cmd) error {
+		cmd.Stdout = &buf
+		return nil
+	}))
+	err := r.Run(ctx, arg)
+	return buf.Bytes(), err
+}
+
+// DryRun makes Run build the Cmd as usual and hand it to h
+// instead of starting it, e.g. to log or review the jobs.
+// Run then returns nil.
+func DryRun(h Hook) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.dry = h
+	}
+}
+
+// CommandLine returns the command line of cmd, quoted so
+// that it can be pasted into a POSIX shell.
+func CommandLine(cmd *exec.Cmd) string {
+	qs := make([]string, len(cmd.Args))
+	for i, a := range cmd.Args {
+		if i == 0 {
+			a = cmd.Path
+		}
+		qs[i] = shellQuote(a)
+	}
+	return strings.Join(qs, " ")
+}
+
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.ContainsRune("-_./:=,+@%", c)) {
+			return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+		}
+	}
+	return s
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
 
@@ -37,3 +39,50 @@ func ExampleHookRunner() {
 	err := r.Run(context.TODO(), "-loglevel warning -y -re -i test.mp4 out.mp4")
 	log.Println(err)
 }
+
+// fakeBinary writes an executable shell script with the given
+// body and returns its path, standing in for a real binary.
+func fakeBinary(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(p, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// argsBinary returns a fake binary recording its args, and a
+// function returning the args of its last run.
+func argsBinary(t *testing.T) (string, func() string) {
+	t.Helper()
+	f := filepath.Join(t.TempDir(), "args")
+	p := fakeBinary(t, `echo "$@" > `+f+"\n")
+	return p, func() string {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(b))
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	p, _ := argsBinary(t)
+
+	var line string
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.DryRun(func(cmd *exec.Cmd) {
+		line = ffmpeg.CommandLine(cmd)
+	}))
+	if err := r.Run(context.TODO(), "-i in.mp4 -vf [0:v]scale=640:-2 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if want := p + " -i in.mp4 -vf '[0:v]scale=640:-2' out.mp4"; line != want {
+		t.Errorf("got %q, want %q", line, want)
+	}
+
+	// no path keeps the one set
+	cmd, err := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.CustomPath()).Plan(context.TODO(), "-version")
+	if err != nil || cmd.Path != p {
+		t.Errorf("unexpected path %v: %v", cmd, err)
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Package ffmpegtest provides Runners for testing code
+depending on ffmpeg.Runner, and generated test media.
+*/
+package ffmpegtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// A Call is a recorded Run call.
+type Call struct {
+	Ctx context.Context
+	Arg string
+}
+
+// A Response is what a MockRunner does for a Run call.
+type Response struct {
+	Err error
+
+	// Output, if not nil, is written to the output, i.e. the
+	// last argument.
+	Output []byte
+
+	// Delay makes Run wait before responding, or return the
+	// context error if it is done first.
+	Delay time.Duration
+}
+
+type rule struct {
+	match func(arg string) bool
+	res   Response
+	once  bool
+	used  bool
+}
+
+// A MockRunner records its Run calls and responds with the
+// Response of the first matching rule, or the default one.
+// It is safe for concurrent use.
+type MockRunner struct {
+	mu    sync.Mutex
+	calls []Call
+	rules []*rule
+	def   Response
+}
+
+// NewMock returns a MockRunner whose runs succeed unless
+// configured otherwise.
+func NewMock() *MockRunner {
+	return &MockRunner{}
+}
+
+// Default sets the Response to runs matching no rule.
+func (m *MockRunner) Default(res Response) *MockRunner {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.def = res
+	return m
+}
+
+// Respond responds res to the runs whose arg contains substr.
+func (m *MockRunner) Respond(substr string, res Response) *MockRunner {
+	return m.RespondFunc(func(arg string) bool {
+		return strings.Contains(arg, substr)
+	}, res)
+}
+
+// RespondFunc responds res to the runs whose arg match.
+func (m *MockRunner) RespondFunc(match func(arg string) bool, res Response) *MockRunner {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, &rule{match: match, res: res})
+	return m
+}
+
+// RespondOnce responds res to the next run with exactly arg.
+func (m *MockRunner) RespondOnce(arg string, res Response) *MockRunner {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, &rule{
+		match: func(a string) bool { return a == arg },
+		res:   res,
+		once:  true,
+	})
+	return m
+}
+
+// Run records the call and responds.
+func (m *MockRunner) Run(ctx context.Context, arg string) error {
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Ctx: ctx, Arg: arg})
+	res := m.def
+	for _, r := range m.rules {
+		if r.used || !r.match(arg) {
+			continue
+		}
+		r.used = r.once
+		res = r.res
+		break
+	}
+	m.mu.Unlock()
+
+	if res.Delay > 0 {
+		t := time.NewTimer(res.Delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+
+	if res.Output != nil {
+		if fs := strings.Fields(arg); len(fs) > 0 {
+			if err := os.WriteFile(fs[len(fs)-1], res.Output, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return res.Err
+}
+
+// Calls returns the calls recorded so far.
+func (m *MockRunner) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// Args returns the args of the calls recorded so far.
+func (m *MockRunner) Args() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	args := make([]string, len(m.calls))
+	for i, c := range m.calls {
+		args[i] = c.Arg
+	}
+	return args
+}
+
+// Reset forgets the recorded calls.
+func (m *MockRunner) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// An Invocation is a run captured by a RecordingRunner.
+type Invocation struct {
+	Arg      string        `json:"arg"`
+	Err      string        `json:"err,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// A RecordingRunner captures the runs of a Runner to replay
+// them later with Replay.
+type RecordingRunner struct {
+	r ffmpeg.Runner
+
+	mu   sync.Mutex
+	invs []Invocation
+}
+
+// Record returns a RecordingRunner capturing the runs of r.
+func Record(r ffmpeg.Runner) *RecordingRunner {
+	return &RecordingRunner{r: r}
+}
+
+// Run runs r and captures the run.
+func (rr *RecordingRunner) Run(ctx context.Context, arg string) error {
+	start := time.Now()
+	err := rr.r.Run(ctx, arg)
+
+	inv := Invocation{Arg: arg, Duration: time.Since(start)}
+	if err != nil {
+		inv.Err = err.Error()
+	}
+	rr.mu.Lock()
+	rr.invs = append(rr.invs, inv)
+	rr.mu.Unlock()
+
+	return err
+}
+
+// Invocations returns the runs captured so far.
+func (rr *RecordingRunner) Invocations() []Invocation {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return append([]Invocation(nil), rr.invs...)
+}
+
+// Save writes the runs captured so far to the golden file
+// at path, as indented JSON.
+func (rr *RecordingRunner) Save(path string) error {
+	b, err := json.MarshalIndent(rr.Invocations(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Replay returns a MockRunner responding to each run of the
+// golden file at path, in order, with the error recorded.
+// Runs not in the file fail with ErrNotRecorded.
+func Replay(path string) (*MockRunner, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var invs []Invocation
+	if err = json.Unmarshal(b, &invs); err != nil {
+		return nil, err
+	}
+
+	m := NewMock().Default(Response{Err: ErrNotRecorded})
+	for _, inv := range invs {
+		var res Response
+		if inv.Err != "" {
+			res.Err = errors.New(inv.Err)
+		}
+		m.RespondOnce(inv.Arg, res)
+	}
+	return m, nil
+}
+
+// ErrNotRecorded is returned by a replaying MockRunner for
+// the runs missing from its golden file.
+var ErrNotRecorded = errors.New("ffmpegtest: run not recorded")
@@ -0,0 +1,65 @@
+package ffmpegtest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/practigo/ffmpeg/ffmpegtest"
+)
+
+func TestMockRunner(t *testing.T) {
+	boom := errors.New("exit status 1")
+	out := filepath.Join(t.TempDir(), "out.mp4")
+
+	m := ffmpegtest.NewMock().
+		Respond("bad.mp4", ffmpegtest.Response{Err: boom}).
+		Respond("good.mp4", ffmpegtest.Response{Output: []byte("data")})
+
+	if err := m.Run(context.TODO(), "-i bad.mp4 x.mp4"); err != boom {
+		t.Errorf("want canned error, got %v", err)
+	}
+	if err := m.Run(context.TODO(), "-i good.mp4 "+out); err != nil {
+		t.Error(err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "data" {
+		t.Errorf("unexpected output %q", b)
+	}
+	if err := m.Run(context.TODO(), "-i other.mp4 x.mp4"); err != nil {
+		t.Error(err)
+	}
+
+	want := []string{"-i bad.mp4 x.mp4", "-i good.mp4 " + out, "-i other.mp4 x.mp4"}
+	if got := m.Args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "runs.json")
+
+	real := ffmpegtest.NewMock().Respond("b.mp4", ffmpegtest.Response{Err: errors.New("exit status 1")})
+	rec := ffmpegtest.Record(real)
+	rec.Run(context.TODO(), "-i a.mp4 out.mp4")
+	rec.Run(context.TODO(), "-i b.mp4 out.mp4")
+	if err := rec.Save(golden); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ffmpegtest.Replay(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Run(context.TODO(), "-i a.mp4 out.mp4"); err != nil {
+		t.Error(err)
+	}
+	if err = m.Run(context.TODO(), "-i b.mp4 out.mp4"); err == nil || err.Error() != "exit status 1" {
+		t.Errorf("want recorded error, got %v", err)
+	}
+	if err = m.Run(context.TODO(), "-i a.mp4 out.mp4"); err != ffmpegtest.ErrNotRecorded {
+		t.Errorf("want ErrNotRecorded, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package ffmpegtest
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// A Source is test media generated by FFmpeg: the testsrc2
+// pattern and a sine tone, instead of a checked-in file.
+type Source struct {
+	Duration  time.Duration // 5s by default
+	Width     int           // 640 by default
+	Height    int           // 360 by default
+	FPS       int           // 25 by default
+	Frequency int           // of the tone, 1kHz by default
+	NoAudio   bool
+
+	// Timecode burns in the timecode of the frames with drawtext,
+	// which needs a FFmpeg with libfreetype and a default font.
+	Timecode bool
+}
+
+func (s Source) defaults() Source {
+	if s.Duration == 0 {
+		s.Duration = 5 * time.Second
+	}
+	if s.Width == 0 {
+		s.Width = 640
+	}
+	if s.Height == 0 {
+		s.Height = 360
+	}
+	if s.FPS == 0 {
+		s.FPS = 25
+	}
+	if s.Frequency == 0 {
+		s.Frequency = 1000
+	}
+	return s
+}
+
+// Arg returns the arg generating s to output, its container
+// and codecs given by the extension, e.g. ".mp4".
+func (s Source) Arg(output string) string {
+	s = s.defaults()
+	d := strconv.FormatFloat(s.Duration.Seconds(), 'f', -1, 64)
+	fps := strconv.Itoa(s.FPS)
+	args := []string{"-y -f lavfi -i", "testsrc2=size=" + strconv.Itoa(s.Width) + "x" + strconv.Itoa(s.Height) +
+		":rate=" + fps + ":duration=" + d}
+	if !s.NoAudio {
+		args = append(args, "-f lavfi -i", "sine=frequency="+strconv.Itoa(s.Frequency)+":sample_rate=48000:duration="+d)
+	}
+	if s.Timecode {
+		args = append(args, "-vf", "drawtext=timecode="+ffmpeg.EscapeFilterValue("00:00:00:00")+":rate="+fps+
+			":fontsize="+strconv.Itoa(s.Height/10)+":fontcolor=white:box=1:boxcolor=black:x=(w-tw)/2:y=h-2*lh")
+	}
+	return strings.Join(append(args, "-pix_fmt yuv420p", output), " ")
+}
+
+// Generate writes s to output with r.
+func (s Source) Generate(ctx context.Context, r ffmpeg.Runner, output string) error {
+	return r.Run(ctx, s.Arg(output))
+}
+
+// TestSource generates s as name, e.g. "in.mp4", in a temporary
+// directory of t with FFmpeg from the PATH and returns its path.
+// The test is skipped if FFmpeg is not installed.
+func TestSource(t testing.TB, s Source, name string) string {
+	t.Helper()
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	p := filepath.Join(t.TempDir(), name)
+	if err := s.Generate(context.Background(), ffmpeg.HookRunner(), p); err != nil {
+		t.Fatalf("generating %s: %v", name, err)
+	}
+	return p
+}
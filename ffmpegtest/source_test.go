@@ -0,0 +1,40 @@
+package ffmpegtest_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg/ffmpegtest"
+)
+
+func TestSourceArg(t *testing.T) {
+	if a := (ffmpegtest.Source{}).Arg("in.mp4"); a != "-y -f lavfi -i testsrc2=size=640x360:rate=25:duration=5 "+
+		"-f lavfi -i sine=frequency=1000:sample_rate=48000:duration=5 -pix_fmt yuv420p in.mp4" {
+		t.Errorf("unexpected arg %q", a)
+	}
+	s := ffmpegtest.Source{Duration: 1500 * time.Millisecond, Width: 1280, Height: 720, FPS: 30, NoAudio: true, Timecode: true}
+	if a := s.Arg("in.mkv"); a != `-y -f lavfi -i testsrc2=size=1280x720:rate=30:duration=1.5 `+
+		`-vf drawtext=timecode=00\\:00\\:00\\:00:rate=30:fontsize=72:fontcolor=white:box=1:boxcolor=black:x=(w-tw)/2:y=h-2*lh `+
+		`-pix_fmt yuv420p in.mkv` {
+		t.Errorf("unexpected arg %q", a)
+	}
+
+	m := ffmpegtest.NewMock()
+	if err := s.Generate(context.TODO(), m, "in.mkv"); err != nil || m.Args()[0] != s.Arg("in.mkv") {
+		t.Errorf("unexpected run %q: %v", m.Args(), err)
+	}
+}
+
+func TestSourceGenerate(t *testing.T) {
+	for _, s := range []ffmpegtest.Source{
+		{Duration: time.Second, Width: 160, Height: 90},
+		{Duration: time.Second, Width: 160, Height: 90, Timecode: true},
+	} {
+		p := ffmpegtest.TestSource(t, s, "in.mp4")
+		if fi, err := os.Stat(p); err != nil || fi.Size() == 0 {
+			t.Errorf("no test source %+v: %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package filtergraph
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// A Layout is how a Composition places its videos.
+type Layout int
+
+// The Layouts.
+const (
+	// SideBySide places the videos in a row.
+	SideBySide Layout = iota
+	// Grid places the videos in the smallest square grid, row
+	// by row.
+	Grid
+	// PictureInPicture fills the canvas with the first video
+	// and stacks the others, smaller, in a corner.
+	PictureInPicture
+)
+
+// A Corner is where a PictureInPicture places its small videos.
+type Corner int
+
+// The Corners.
+const (
+	BottomRight Corner = iota
+	BottomLeft
+	TopRight
+	TopLeft
+)
+
+// A ComposeInput is an input of a Composition.
+type ComposeInput struct {
+	Path    string
+	Offset  time.Duration // when it starts in the composition
+	NoAudio bool          // for inputs without audio, or to mute
+}
+
+// A Composition renders its inputs, e.g. the recordings of the
+// participants of a meeting, into a single video, their first
+// audio streams mixed.
+type Composition struct {
+	Inputs []ComposeInput
+	Layout Layout
+	Width  int // of the canvas, 1280 by default
+	Height int // 720 by default
+
+	// Scale is the size of the small videos of PictureInPicture
+	// relative to the canvas, 0.25 by default, placed in the
+	// Corner, Margin pixels away from the edges.
+	Scale  float64
+	Corner Corner
+	Margin int
+}
+
+func (c Composition) defaults() Composition {
+	if c.Width == 0 {
+		c.Width = 1280
+	}
+	if c.Height == 0 {
+		c.Height = 720
+	}
+	if c.Scale == 0 {
+		c.Scale = 0.25
+	}
+	return c
+}
+
+// Graph returns the graph of c, with the input i read as the
+// input i, and its video and audio outputs, the audio being
+// empty if no input has audio. The videos are fit in their
+// cells, letterboxed, and delayed by their Offset as the audio.
+func (c Composition) Graph() (g *Graph, video, audio Pad, err error) {
+	c = c.defaults()
+	if len(c.Inputs) == 0 {
+		return nil, "", "", errors.New("filtergraph: nothing to compose")
+	}
+	g = New()
+	if c.Layout == PictureInPicture {
+		video = c.pip(g)
+	} else {
+		video = c.grid(g)
+	}
+
+	var audios []Pad
+	for i, in := range c.Inputs {
+		if in.NoAudio {
+			continue
+		}
+		ch := g.Chain(Pad(strconv.Itoa(i)+":a:0")).Filter("asetpts", "PTS-STARTPTS")
+		if in.Offset > 0 {
+			ch.Filter("adelay", strconv.FormatInt(in.Offset.Milliseconds(), 10), "all=1")
+		}
+		audios = append(audios, ch.Output())
+	}
+	if len(audios) > 1 {
+		audio = g.Chain(audios...).Filter("amix", "inputs="+strconv.Itoa(len(audios)), "duration=longest").Output()
+	} else if len(audios) == 1 {
+		audio = audios[0]
+	}
+	return g, video, audio, nil
+}
+
+// fit returns the video of the input i fit in w x h and delayed
+// by its Offset with black frames if pad, or else timestamps.
+func (c Composition) fit(g *Graph, i, w, h int, pad bool) Pad {
+	sw, sh := strconv.Itoa(w), strconv.Itoa(h)
+	ch := g.Chain(Pad(strconv.Itoa(i)+":v:0")).
+		Filter("scale", sw, sh, "force_original_aspect_ratio=decrease").
+		Filter("pad", sw, sh, "(ow-iw)/2", "(oh-ih)/2").
+		Filter("setsar", "1")
+	off := c.Inputs[i].Offset
+	switch {
+	case off <= 0:
+		ch.Filter("setpts", "PTS-STARTPTS")
+	case pad:
+		ch.Filter("setpts", "PTS-STARTPTS").
+			Filter("tpad", "start_duration="+seconds(off), "color=black")
+	default:
+		ch.Filter("setpts", "PTS-STARTPTS+"+seconds(off)+"/TB")
+	}
+	return ch.Output()
+}
+
+// grid lays out the videos in a grid, a row for SideBySide.
+func (c Composition) grid(g *Graph) Pad {
+	n := len(c.Inputs)
+	cols, rows := n, 1
+	if c.Layout == Grid {
+		cols = int(math.Ceil(math.Sqrt(float64(n))))
+		rows = (n + cols - 1) / cols
+	}
+	w, h := even(c.Width/cols), even(c.Height/rows)
+	if n == 1 {
+		return c.fit(g, 0, w, h, true)
+	}
+
+	pads := make([]Pad, n)
+	cells := make([]string, n)
+	for i := range c.Inputs {
+		pads[i] = c.fit(g, i, w, h, true)
+		cells[i] = strconv.Itoa(i%cols*w) + "_" + strconv.Itoa(i/cols*h)
+	}
+	return g.Chain(pads...).Filter("xstack", "inputs="+strconv.Itoa(n), "layout="+strings.Join(cells, "|"), "fill=black").Output()
+}
+
+// pip overlays the small videos on the first one. A small video
+// is only overlaid from its Offset on.
+func (c Composition) pip(g *Graph) Pad {
+	v := c.fit(g, 0, c.Width, c.Height, true)
+	w, h := even(int(float64(c.Width)*c.Scale)), even(int(float64(c.Height)*c.Scale))
+	m := c.Margin
+	for i := 1; i < len(c.Inputs); i++ {
+		small := c.fit(g, i, w, h, false)
+		x, y := m, m+(i-1)*(h+m)
+		if c.Corner == BottomRight || c.Corner == TopRight {
+			x = c.Width - w - m
+		}
+		if c.Corner == BottomRight || c.Corner == BottomLeft {
+			y = c.Height - h - m - (i-1)*(h+m)
+		}
+		v = g.Chain(v, small).Filter("overlay", "x="+strconv.Itoa(x), "y="+strconv.Itoa(y), "eof_action=pass").Output()
+	}
+	return v
+}
+
+// even rounds n down to an even number, as yuv420p requires.
+func even(n int) int {
+	return n &^ 1
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// Args returns the args rendering c to output with the output
+// args, e.g. "-c:v libx264 -c:a aac", lasting as the longest
+// input.
+func (c Composition) Args(args, output string) (string, error) {
+	g, video, audio, err := c.Graph()
+	if err != nil {
+		return "", err
+	}
+	var as []string
+	for _, in := range c.Inputs {
+		as = append(as, "-i", in.Path)
+	}
+	as = append(as, g.Args(), "-map", video.Map())
+	if audio != "" {
+		as = append(as, "-map", audio.Map())
+	}
+	if args != "" {
+		as = append(as, args)
+	}
+	return strings.Join(append(as, "-y", output), " "), nil
+}
+
+// Compose renders c to output with r, see Args.
+func Compose(ctx context.Context, r ffmpeg.Runner, c Composition, args, output string) error {
+	arg, err := c.Args(args, output)
+	if err != nil {
+		return err
+	}
+	return r.Run(ctx, arg)
+}
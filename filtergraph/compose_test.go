@@ -0,0 +1,68 @@
+package filtergraph_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg/ffmpegtest"
+	"github.com/practigo/ffmpeg/filtergraph"
+)
+
+func TestCompositionSideBySide(t *testing.T) {
+	c := filtergraph.Composition{Inputs: []filtergraph.ComposeInput{
+		{Path: "a.mp4"},
+		{Path: "b.mp4", Offset: 1500 * time.Millisecond},
+	}}
+	arg, err := c.Args("-c:v libx264", "out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-i a.mp4 -i b.mp4 -filter_complex " +
+		"[0:v:0]scale=640:720:force_original_aspect_ratio=decrease,pad=640:720:(ow-iw)/2:(oh-ih)/2,setsar=1,setpts=PTS-STARTPTS[f0];" +
+		"[1:v:0]scale=640:720:force_original_aspect_ratio=decrease,pad=640:720:(ow-iw)/2:(oh-ih)/2,setsar=1," +
+		"setpts=PTS-STARTPTS,tpad=start_duration=1.5:color=black[f1];" +
+		"[f0][f1]xstack=inputs=2:layout=0_0|640_0:fill=black[f2];" +
+		"[0:a:0]asetpts=PTS-STARTPTS[f3];[1:a:0]asetpts=PTS-STARTPTS,adelay=1500:all=1[f4];" +
+		"[f3][f4]amix=inputs=2:duration=longest[f5] -map [f2] -map [f5] -c:v libx264 -y out.mp4"
+	if arg != want {
+		t.Errorf("got\n%s\nwant\n%s", arg, want)
+	}
+}
+
+func TestCompositionGrid(t *testing.T) {
+	c := filtergraph.Composition{Layout: filtergraph.Grid, Inputs: []filtergraph.ComposeInput{
+		{Path: "a.mp4", NoAudio: true}, {Path: "b.mp4", NoAudio: true}, {Path: "c.mp4", NoAudio: true},
+	}}
+	g, video, audio, err := c.Graph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audio != "" || video != "f3" {
+		t.Errorf("unexpected outputs %q %q", video, audio)
+	}
+	want := "[f0][f1][f2]xstack=inputs=3:layout=0_0|640_0|0_360:fill=black[f3]"
+	if s := g.String(); len(s) < len(want) || s[len(s)-len(want):] != want {
+		t.Errorf("unexpected graph %s", s)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	c := filtergraph.Composition{Layout: filtergraph.PictureInPicture, Corner: filtergraph.TopRight, Margin: 10,
+		Inputs: []filtergraph.ComposeInput{{Path: "talk.mp4"}, {Path: "cam.mp4", Offset: time.Second, NoAudio: true}}}
+	m := ffmpegtest.NewMock()
+	if err := filtergraph.Compose(context.TODO(), m, c, "", "out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	want := "-i talk.mp4 -i cam.mp4 -filter_complex " +
+		"[0:v:0]scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2,setsar=1,setpts=PTS-STARTPTS[f0];" +
+		"[1:v:0]scale=320:180:force_original_aspect_ratio=decrease,pad=320:180:(ow-iw)/2:(oh-ih)/2,setsar=1,setpts=PTS-STARTPTS+1/TB[f1];" +
+		"[f0][f1]overlay=x=950:y=10:eof_action=pass[f2];[0:a:0]asetpts=PTS-STARTPTS[f3] -map [f2] -map [f3] -y out.mp4"
+	if args := m.Args(); len(args) != 1 || args[0] != want {
+		t.Errorf("got\n%q\nwant\n%q", args, want)
+	}
+
+	if err := filtergraph.Compose(context.TODO(), m, filtergraph.Composition{}, "", "out.mp4"); err == nil {
+		t.Error("want error for no input")
+	}
+}
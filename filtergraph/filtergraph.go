@@ -0,0 +1,208 @@
+/*
+Package filtergraph composes FFmpeg filtergraphs, for
+-filter_complex, rendering them correctly escaped.
+
+A Graph is made of chains of filters reading labelled pads,
+either the streams of the inputs such as "0:v" or the outputs of
+other chains:
+
+	g := filtergraph.New()
+	bg := g.Chain("0:v").Filter("scale", "1280", "-2").Output()
+	out := g.Chain(bg, "1:v").Filter("overlay", "x=W-w-10", "y=10").Output()
+	arg := g.Args() + " -map " + out.Map()
+
+A Composition builds the graph rendering several inputs side by
+side, in a grid or picture-in-picture.
+*/
+package filtergraph
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// A Pad is a label of a stream of the graph, e.g. "0:v" for the
+// video of the first input.
+type Pad string
+
+// Map returns the -map value of p, e.g. "[f0]".
+func (p Pad) Map() string {
+	return "[" + string(p) + "]"
+}
+
+// A Graph is a filtergraph.
+type Graph struct {
+	chains []*Chain
+	labels int
+}
+
+// New returns an empty Graph.
+func New() *Graph {
+	return &Graph{}
+}
+
+// A Chain is a sequence of filters, from its input pads to its
+// output pads.
+type Chain struct {
+	g       *Graph
+	inputs  []Pad
+	filters []string
+	outputs []Pad
+}
+
+// Chain adds a chain reading inputs to g.
+func (g *Graph) Chain(inputs ...Pad) *Chain {
+	c := &Chain{g: g, inputs: inputs}
+	g.chains = append(g.chains, c)
+	return c
+}
+
+// Filter appends the filter name to c with args, positional
+// ("1280") or named ("x=10") options whose values are escaped.
+func (c *Chain) Filter(name string, args ...string) *Chain {
+	f := name
+	for i, a := range args {
+		if i == 0 {
+			f += "="
+		} else {
+			f += ":"
+		}
+		if k, v, ok := strings.Cut(a, "="); ok && isKey(k) {
+			f += k + "=" + Escape(v)
+		} else {
+			f += Escape(a)
+		}
+	}
+	c.filters = append(c.filters, f)
+	return c
+}
+
+func isKey(k string) bool {
+	if k == "" {
+		return false
+	}
+	for _, r := range k {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Output labels the single output of c and returns it.
+func (c *Chain) Output() Pad {
+	return c.Outputs(1)[0]
+}
+
+// Outputs labels n outputs of c, e.g. of a split, and returns
+// them.
+func (c *Chain) Outputs(n int) []Pad {
+	pads := make([]Pad, n)
+	for i := range pads {
+		pads[i] = Pad("f" + strconv.Itoa(c.g.labels))
+		c.g.labels++
+	}
+	c.outputs = append(c.outputs, pads...)
+	return pads
+}
+
+// To labels the outputs of c with labels and returns c.
+func (c *Chain) To(labels ...Pad) *Chain {
+	c.outputs = append(c.outputs, labels...)
+	return c
+}
+
+// Escape escapes v for a filter option value in a filtergraph,
+// see ffmpeg.EscapeFilterValue.
+func Escape(v string) string {
+	return ffmpeg.EscapeFilterValue(v)
+}
+
+// String renders g, e.g. "[0:v]scale=1280:-2[f0];[f0][1:v]overlay[f1]".
+func (g *Graph) String() string {
+	cs := make([]string, len(g.chains))
+	for i, c := range g.chains {
+		var b strings.Builder
+		for _, p := range c.inputs {
+			b.WriteString(p.Map())
+		}
+		b.WriteString(strings.Join(c.filters, ","))
+		for _, p := range c.outputs {
+			b.WriteString(p.Map())
+		}
+		cs[i] = b.String()
+	}
+	return strings.Join(cs, ";")
+}
+
+// Args returns "-filter_complex" and g. As ffmpeg args are split
+// on spaces, graphs with spaces, e.g. in a drawtext text, should
+// be given with Script instead.
+func (g *Graph) Args() string {
+	return "-filter_complex " + g.String()
+}
+
+// Script writes g to the file at path and returns the args
+// reading it.
+func (g *Graph) Script(path string) (string, error) {
+	if err := os.WriteFile(path, []byte(g.String()), 0644); err != nil {
+		return "", err
+	}
+	return "-filter_complex_script " + path, nil
+}
+
+// Outputs returns the output pads of g not read by another chain,
+// which should be mapped.
+func (g *Graph) Outputs() []Pad {
+	read := make(map[Pad]bool)
+	for _, c := range g.chains {
+		for _, p := range c.inputs {
+			read[p] = true
+		}
+	}
+	var pads []Pad
+	for _, c := range g.chains {
+		for _, p := range c.outputs {
+			if !read[p] {
+				pads = append(pads, p)
+			}
+		}
+	}
+	return pads
+}
+
+// Validate runs FFmpeg with r on 0.1s of inputs through g, to a
+// null output, and returns the error of the run, reporting any
+// mistake in the graph.
+func (g *Graph) Validate(ctx context.Context, r ffmpeg.Runner, inputs ...string) error {
+	if len(g.chains) == 0 {
+		return errors.New("filtergraph: empty graph")
+	}
+	args := []string{"-v error -nostdin"}
+	for _, in := range inputs {
+		args = append(args, "-i", in)
+	}
+
+	fc := g.Args()
+	if strings.ContainsAny(g.String(), " \t\n") {
+		ws, err := ffmpeg.NewWorkspace("")
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+		if fc, err = g.Script(ws.Path("graph.txt")); err != nil {
+			return err
+		}
+	}
+	args = append(args, fc)
+	for _, p := range g.Outputs() {
+		args = append(args, "-map", p.Map())
+	}
+	args = append(args, "-t 0.1 -f null -")
+	return r.Run(ctx, strings.Join(args, " "))
+}
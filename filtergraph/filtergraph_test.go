@@ -0,0 +1,42 @@
+package filtergraph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/practigo/ffmpeg/ffmpegtest"
+	"github.com/practigo/ffmpeg/filtergraph"
+)
+
+func TestGraph(t *testing.T) {
+	g := filtergraph.New()
+	bg := g.Chain("0:v").Filter("scale", "1280", "-2").Output()
+	logo := g.Chain("1:v").Filter("format", "rgba").Filter("colorchannelmixer", "aa=0.5").Output()
+	g.Chain(bg, logo).Filter("overlay", "x=W-w-10", "y=10").
+		Filter("drawtext", "text=Time: 10:00, it's [live]", "fontsize=24").To("out")
+
+	want := `[0:v]scale=1280:-2[f0];[1:v]format=rgba,colorchannelmixer=aa=0.5[f1];` +
+		`[f0][f1]overlay=x=W-w-10:y=10,drawtext=text=Time\\: 10\\:00\, it\\\'s \[live\]:fontsize=24[out]`
+	if s := g.String(); s != want {
+		t.Errorf("want\n%s\ngot\n%s", want, s)
+	}
+	if outs := g.Outputs(); len(outs) != 1 || outs[0] != "out" {
+		t.Errorf("unexpected outputs %q", outs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	g := filtergraph.New()
+	vs := g.Chain("0:v").Filter("split").Outputs(2)
+	g.Chain(vs[0]).Filter("scale", "640", "360")
+	g.Chain(vs[1]).Filter("hflip").Output()
+
+	m := ffmpegtest.NewMock()
+	if err := g.Validate(context.TODO(), m, "in.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	want := "-v error -nostdin -i in.mp4 -filter_complex [0:v]split[f0][f1];[f0]scale=640:360;[f1]hflip[f2] -map [f2] -t 0.1 -f null -"
+	if a := m.Args(); len(a) != 1 || a[0] != want {
+		t.Errorf("want %q, got %q", want, a)
+	}
+}
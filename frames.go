@@ -0,0 +1,119 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// FrameOptions are the options of ReadFrames.
+type FrameOptions struct {
+	Width  int // the frames are scaled to Width x Height
+	Height int
+	FPS    float64 // resample the frame rate, if not 0
+	Gray   bool    // *image.Gray frames instead of *image.RGBA
+}
+
+// A FrameReader reads the decoded video frames of an input,
+// FFmpeg being blocked while they are not read.
+type FrameReader struct {
+	opts   FrameOptions
+	pipe   *io.PipeReader
+	cancel context.CancelFunc
+	done   chan struct{}
+	frame  image.Image
+	buf    []byte
+	err    error
+}
+
+// ReadFrames starts FFmpeg decoding input into a FrameReader,
+// with a HookedRunner built with opts. The FrameReader must be
+// closed.
+func ReadFrames(ctx context.Context, input string, fo FrameOptions, opts ...func(r *HookedRunner)) (*FrameReader, error) {
+	if fo.Width <= 0 || fo.Height <= 0 {
+		return nil, errors.New("ffmpeg: frame size required")
+	}
+	vf := fmt.Sprintf("scale=%d:%d", fo.Width, fo.Height)
+	if fo.FPS > 0 {
+		vf = fmt.Sprintf("fps=%g,%s", fo.FPS, vf)
+	}
+	pix := "rgba"
+	if fo.Gray {
+		pix = "gray"
+	}
+	arg := strings.Join([]string{"-nostdin -v error -i", input, "-map 0:v:0 -vf", vf,
+		"-pix_fmt", pix, "-f rawvideo -"}, " ")
+
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(ctx)
+	fr := &FrameReader{opts: fo, pipe: pr, cancel: cancel, done: make(chan struct{})}
+	if fo.Gray {
+		g := image.NewGray(image.Rect(0, 0, fo.Width, fo.Height))
+		fr.frame, fr.buf = g, g.Pix
+	} else {
+		rgba := image.NewRGBA(image.Rect(0, 0, fo.Width, fo.Height))
+		fr.frame, fr.buf = rgba, rgba.Pix
+	}
+
+	opts = append(opts[:len(opts):len(opts)], withSetup(func(cmd *exec.Cmd) (func(), error) {
+		cmd.Stdout = pw
+		return func() {}, nil
+	}))
+	go func() {
+		defer close(fr.done)
+		pw.CloseWithError(HookRunner(opts...).Run(ctx, arg))
+	}()
+	return fr, nil
+}
+
+// withSetup adds a setup to the runner, run before FFmpeg starts
+// without replacing the PreHook.
+func withSetup(setup func(cmd *exec.Cmd) (undo func(), err error)) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.setups = append(r.setups, setup)
+	}
+}
+
+// Next reads the next frame, returning false at the end of the
+// video or on error, see Err.
+func (fr *FrameReader) Next() bool {
+	if fr.err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(fr.pipe, fr.buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = errors.New("ffmpeg: truncated frame")
+		}
+		fr.err = err
+		return false
+	}
+	return true
+}
+
+// Frame returns the frame read by Next, an *image.RGBA or an
+// *image.Gray, whose pixels are overwritten by the next call
+// to Next.
+func (fr *FrameReader) Frame() image.Image {
+	return fr.frame
+}
+
+// Err returns the error that stopped Next, nil at the end of
+// the video.
+func (fr *FrameReader) Err() error {
+	if fr.err == io.EOF {
+		return nil
+	}
+	return fr.err
+}
+
+// Close stops FFmpeg and waits for its exit.
+func (fr *FrameReader) Close() error {
+	fr.cancel()
+	fr.pipe.Close()
+	<-fr.done
+	return nil
+}
@@ -0,0 +1,67 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestReadFrames(t *testing.T) {
+	// 3 frames of 2x1 RGBA pixels: red then blue, green then white, black then black
+	p := fakeBinary(t, `printf '\377\000\000\377\000\000\377\377'
+printf '\000\377\000\377\377\377\377\377'
+printf '\000\000\000\377\000\000\000\377'
+`)
+	fr, err := ffmpeg.ReadFrames(context.TODO(), "in.mp4", ffmpeg.FrameOptions{Width: 2, Height: 1}, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fr.Close()
+
+	var firsts []color.RGBA
+	for fr.Next() {
+		firsts = append(firsts, fr.Frame().(*image.RGBA).RGBAAt(0, 0))
+	}
+	if err = fr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 0, 255}}
+	if len(firsts) != len(want) {
+		t.Fatalf("want %d frames, got %v", len(want), firsts)
+	}
+	for i := range want {
+		if firsts[i] != want[i] {
+			t.Errorf("frame %d: want %v, got %v", i, want[i], firsts[i])
+		}
+	}
+}
+
+func TestReadFramesError(t *testing.T) {
+	p := fakeBinary(t, "printf '\\377\\377'; exit 1\n")
+	fr, err := ffmpeg.ReadFrames(context.TODO(), "in.mp4", ffmpeg.FrameOptions{Width: 2, Height: 1, Gray: true}, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fr.Close()
+	if !fr.Next() {
+		t.Fatalf("want a gray frame, got %v", fr.Err())
+	}
+	if fr.Next() || fr.Err() == nil {
+		t.Error("want the exit error")
+	}
+}
+
+func TestReadFramesClose(t *testing.T) {
+	p := fakeBinary(t, "exec cat /dev/zero\n")
+	fr, err := ffmpeg.ReadFrames(context.TODO(), "in.mp4", ffmpeg.FrameOptions{Width: 4, Height: 4}, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fr.Next() {
+		t.Fatal(fr.Err())
+	}
+	fr.Close() // does not hang on the endless video
+}
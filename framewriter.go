@@ -0,0 +1,129 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// A FrameWriter encodes the video frames written to it.
+type FrameWriter struct {
+	opts  FrameOptions
+	stdin io.WriteCloser
+	done  chan struct{}
+	err   error // of the run, once done
+	rgba  *image.RGBA
+	gray  *image.Gray
+}
+
+// WriteFrames starts FFmpeg encoding the frames of size and, if
+// not 0, frame rate fo (25 by default) written to the FrameWriter
+// into output, with the output options args, e.g. "-c:v libx264
+// -pix_fmt yuv420p", and a HookedRunner built with opts.
+func WriteFrames(ctx context.Context, output, args string, fo FrameOptions, opts ...func(r *HookedRunner)) (*FrameWriter, error) {
+	if fo.Width <= 0 || fo.Height <= 0 {
+		return nil, errors.New("ffmpeg: frame size required")
+	}
+	fps := fo.FPS
+	if fps == 0 {
+		fps = 25
+	}
+	pix := "rgba"
+	if fo.Gray {
+		pix = "gray"
+	}
+	arg := strings.Join([]string{"-y -f rawvideo -pix_fmt", pix,
+		fmt.Sprintf("-s %dx%d -framerate %g -i -", fo.Width, fo.Height, fps), args, output}, " ")
+
+	fw := &FrameWriter{opts: fo, done: make(chan struct{})}
+	ready := make(chan io.WriteCloser, 1)
+	opts = append(opts[:len(opts):len(opts)], withSetup(func(cmd *exec.Cmd) (func(), error) {
+		w, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		ready <- w
+		return func() {}, nil
+	}))
+	go func() {
+		defer close(fw.done)
+		fw.err = HookRunner(opts...).Run(ctx, arg)
+	}()
+
+	select {
+	case fw.stdin = <-ready:
+		return fw, nil
+	case <-fw.done:
+		if fw.err == nil {
+			fw.err = errors.New("ffmpeg: not started")
+		}
+		return nil, fw.err
+	}
+}
+
+// Write writes img as the next frame, converted to RGBA or
+// gray if needed. Its bounds must be of the frame size.
+func (fw *FrameWriter) Write(img image.Image) error {
+	b := img.Bounds()
+	if b.Dx() != fw.opts.Width || b.Dy() != fw.opts.Height {
+		return fmt.Errorf("ffmpeg: frame of %dx%d, want %dx%d", b.Dx(), b.Dy(), fw.opts.Width, fw.opts.Height)
+	}
+
+	if fw.opts.Gray {
+		g, ok := img.(*image.Gray)
+		if !ok || g.Stride != b.Dx() {
+			if fw.gray == nil {
+				fw.gray = image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+			}
+			draw.Draw(fw.gray, fw.gray.Bounds(), img, b.Min, draw.Src)
+			g = fw.gray
+		}
+		return fw.WriteRaw(g.Pix[:b.Dx()*b.Dy()])
+	}
+
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Stride != 4*b.Dx() {
+		if fw.rgba == nil {
+			fw.rgba = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		}
+		draw.Draw(fw.rgba, fw.rgba.Bounds(), img, b.Min, draw.Src)
+		rgba = fw.rgba
+	}
+	return fw.WriteRaw(rgba.Pix[:4*b.Dx()*b.Dy()])
+}
+
+// WriteRaw writes a frame of packed pixels, RGBA or gray.
+func (fw *FrameWriter) WriteRaw(pix []byte) error {
+	size := fw.opts.Width * fw.opts.Height * 4
+	if fw.opts.Gray {
+		size /= 4
+	}
+	if len(pix) != size {
+		return fmt.Errorf("ffmpeg: frame of %d bytes, want %d", len(pix), size)
+	}
+	if _, err := fw.stdin.Write(pix); err != nil {
+		// FFmpeg exited, report why
+		select {
+		case <-fw.done:
+			if fw.err != nil {
+				return fw.err
+			}
+		default:
+		}
+		return err
+	}
+	return nil
+}
+
+// Close ends the video and waits for FFmpeg to finish encoding,
+// returning the error of the run.
+func (fw *FrameWriter) Close() error {
+	fw.stdin.Close()
+	<-fw.done
+	return fw.err
+}
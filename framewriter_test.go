@@ -0,0 +1,60 @@
+package ffmpeg_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestWriteFrames(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.raw")
+	p := fakeBinary(t, `for out; do :; done; cat > "$out"`+"\n")
+	fw, err := ffmpeg.WriteFrames(context.TODO(), out, "-c:v libx264", ffmpeg.FrameOptions{Width: 2, Height: 1}, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	rgba.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	if err = fw.Write(rgba); err != nil {
+		t.Fatal(err)
+	}
+	gray := image.NewGray(image.Rect(0, 0, 2, 1))
+	gray.SetGray(1, 0, color.Gray{255})
+	if err = fw.Write(gray); err != nil {
+		t.Fatal(err)
+	}
+	if err = fw.Write(image.NewRGBA(image.Rect(0, 0, 3, 1))); err == nil {
+		t.Error("want error for a frame of the wrong size")
+	}
+	if err = fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{255, 0, 0, 255, 0, 0, 0, 0, 0, 0, 0, 255, 255, 255, 255, 255}
+	if b, _ := os.ReadFile(out); !bytes.Equal(b, want) {
+		t.Errorf("want %v, got %v", want, b)
+	}
+}
+
+func TestWriteFramesExit(t *testing.T) {
+	p := fakeBinary(t, "exit 1\n")
+	fw, err := ffmpeg.WriteFrames(context.TODO(), "out.mp4", "", ffmpeg.FrameOptions{Width: 2, Height: 1}, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fw.Close(); err == nil {
+		t.Error("want the exit error")
+	}
+
+	if _, err = ffmpeg.WriteFrames(context.TODO(), "out.mp4", "", ffmpeg.FrameOptions{Width: 2, Height: 1},
+		ffmpeg.CustomPath("/nonexistent/ffmpeg")); err == nil {
+		t.Error("want error for a missing binary")
+	}
+}
@@ -0,0 +1,57 @@
+package ffmpeg
+
+import "strings"
+
+// GlobalArgs prepends arg to the args of every run, e.g.
+// "-hide_banner -nostdin -loglevel error -y". An option of arg
+// is left out of the runs setting it, or an option of the same
+// group: -y and -n, -loglevel and -v, -stats and -nostats,
+// -stdin and -nostdin. GlobalArgs can be given several times.
+func GlobalArgs(arg string) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.global = append(r.global, strings.Fields(arg)...)
+	}
+}
+
+// globalGroups maps the global options to the group of those
+// overriding each other.
+var globalGroups = map[string]string{
+	"-y": "-y", "-n": "-y",
+	"-loglevel": "-v", "-v": "-v",
+	"-stats": "-stats", "-nostats": "-stats",
+	"-stdin": "-stdin", "-nostdin": "-stdin",
+}
+
+func group(flag string) string {
+	if g, ok := globalGroups[flag]; ok {
+		return g
+	}
+	return flag
+}
+
+// globalArgs returns the GlobalArgs not overridden by args.
+func (r *HookedRunner) globalArgs(args []string) []string {
+	if len(r.global) == 0 {
+		return nil
+	}
+	set := make(map[string]bool)
+	walkArgs(args, func(flag string, i int) bool {
+		if flag != "" {
+			set[group(flag)] = true
+		}
+		return true
+	})
+
+	var global []string
+	walkArgs(r.global, func(flag string, i int) bool {
+		if set[group(flag)] {
+			return true
+		}
+		global = append(global, r.global[i])
+		if flag != "" && takesValue(flag) && i+1 < len(r.global) {
+			global = append(global, r.global[i+1])
+		}
+		return true
+	})
+	return global
+}
@@ -0,0 +1,37 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestGlobalArgs(t *testing.T) {
+	p, last := argsBinary(t)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p),
+		ffmpeg.GlobalArgs("-hide_banner -nostdin -loglevel error"),
+		ffmpeg.GlobalArgs("-y"))
+
+	for _, c := range []struct{ arg, want string }{
+		{"-i in.mp4 out.mp4", "-hide_banner -nostdin -loglevel error -y -i in.mp4 out.mp4"},
+		{"-n -v warning -i in.mp4 out.mp4", "-hide_banner -nostdin -n -v warning -i in.mp4 out.mp4"},
+		{"-loglevel:v info -stdin -i in.mp4 out.mp4", "-hide_banner -y -loglevel:v info -stdin -i in.mp4 out.mp4"},
+	} {
+		if err := r.Run(context.TODO(), c.arg); err != nil {
+			t.Fatal(err)
+		}
+		if got := last(); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.arg, got, c.want)
+		}
+	}
+
+	// boolean options, negated or not, take no value
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.GlobalArgs("-noautoscale -y -psnr"))
+	if err := r.Run(context.TODO(), "-n -i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := last(), "-noautoscale -psnr -n -i in.mp4 out.mp4"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
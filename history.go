@@ -0,0 +1,160 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A RunRecord is the audit record of a run, see WithHistory.
+type RunRecord struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Path     string        `json:"path"`
+	Args     []string      `json:"args"` // redacted, see WithRedactor
+	Labels   Labels        `json:"labels,omitempty"`
+
+	Pid    int        `json:"pid"`
+	Code   int        `json:"code"` // -1 if killed by a signal
+	Reason StopReason `json:"reason"`
+	Error  string     `json:"error,omitempty"` // redacted
+
+	UserTime   time.Duration `json:"user_time"`
+	SystemTime time.Duration `json:"system_time"`
+	MaxRSS     int64         `json:"max_rss"`
+
+	// Valid is whether the validate of AtomicOutput accepted the
+	// outputs, nil if they were not validated.
+	Valid *bool `json:"valid,omitempty"`
+}
+
+// String returns the command line of rec, redacted.
+func (rec RunRecord) String() string {
+	return CommandLine(exec.Command(rec.Path, rec.Args...))
+}
+
+// A HistoryQuery selects RunRecords.
+type HistoryQuery struct {
+	From, To time.Time    // the range of their Start, To excluded, unbounded if zero
+	Reasons  []StopReason // any if empty
+}
+
+// Match reports whether q selects rec.
+func (q HistoryQuery) Match(rec RunRecord) bool {
+	if !q.From.IsZero() && rec.Start.Before(q.From) || !q.To.IsZero() && !rec.Start.Before(q.To) {
+		return false
+	}
+	if len(q.Reasons) == 0 {
+		return true
+	}
+	for _, r := range q.Reasons {
+		if rec.Reason == r {
+			return true
+		}
+	}
+	return false
+}
+
+// A History stores the RunRecords, e.g. for billing or
+// post-incident analysis. Its methods may be called
+// concurrently.
+type History interface {
+	Add(rec RunRecord) error
+	// Query returns the records selected by q, by Start.
+	Query(q HistoryQuery) ([]RunRecord, error)
+}
+
+// WithHistory adds the RunRecord of every run that started
+// to h once FFmpeg exited. The failures to add it are logged,
+// see Logger.
+func WithHistory(h History) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.history = h
+	}
+}
+
+// record adds the RunRecord of a run to the History of r.
+func (r *HookedRunner) record(rec RunRecord, res *Result, err error) error {
+	rec.Duration = res.Duration
+	rec.Pid, rec.Code, rec.Reason = res.Pid, res.Code, res.Reason
+	rec.UserTime, rec.SystemTime, rec.MaxRSS = res.UserTime, res.SystemTime, res.MaxRSS
+	if err != nil {
+		rec.Error = r.redactor.String(err.Error())
+	}
+	if r.atomic && r.validate != nil && (err == nil || errors.Is(err, ErrInvalidOutput)) {
+		valid := err == nil
+		rec.Valid = &valid
+	}
+	return r.history.Add(rec)
+}
+
+// A FileHistory is a History appending the records to a file,
+// one JSON object per line.
+type FileHistory struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileHistory returns a FileHistory of the file at path,
+// created if needed.
+func NewFileHistory(path string) *FileHistory {
+	return &FileHistory{path: path}
+}
+
+// Add implements History.
+func (h *FileHistory) Add(rec RunRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Query implements History, reading the whole file.
+func (h *FileHistory) Query(q HistoryQuery) ([]RunRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.Open(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []RunRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var rec RunRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("ffmpeg: %s:%d: %w", h.path, n, err)
+		}
+		if q.Match(rec) {
+			recs = append(recs, rec)
+		}
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Start.Before(recs[j].Start) })
+	return recs, sc.Err()
+}
@@ -0,0 +1,64 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestFileHistory(t *testing.T) {
+	dir := t.TempDir()
+	h := ffmpeg.NewFileHistory(filepath.Join(dir, "runs.jsonl"))
+	if recs, err := h.Query(ffmpeg.HistoryQuery{}); err != nil || len(recs) != 0 {
+		t.Fatalf("unexpected records %v: %v", recs, err)
+	}
+
+	p := fakeBinary(t, `for a; do o=$a; done
+case "$o" in *fail*) exit 1;; esac
+echo data > $o
+`)
+	invalid := errors.New("too short")
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.WithHistory(h), ffmpeg.AtomicOutput(func(path string) error {
+		if strings.Contains(path, "short") {
+			return invalid
+		}
+		return nil
+	}))
+	start := time.Now()
+	r.Run(context.TODO(), "-decryption_key secret -i in.mp4 "+filepath.Join(dir, "out.mp4"))
+	r.Run(context.TODO(), "-i in.mp4 "+filepath.Join(dir, "fail.mp4"))
+	r.Run(context.TODO(), "-i in.mp4 "+filepath.Join(dir, "short.mp4"))
+
+	recs, err := h.Query(ffmpeg.HistoryQuery{From: start})
+	if err != nil || len(recs) != 3 {
+		t.Fatalf("unexpected records %+v: %v", recs, err)
+	}
+	ok, failed, short := recs[0], recs[1], recs[2]
+	if ok.Reason != ffmpeg.StopFinished || ok.Path != p || ok.Valid == nil || !*ok.Valid || ok.Duration <= 0 {
+		t.Errorf("unexpected record %+v", ok)
+	}
+	if strings.Contains(strings.Join(ok.Args, " "), "secret") {
+		t.Errorf("args not redacted %v", ok.Args)
+	}
+	if !strings.HasPrefix(ok.String(), p+" -decryption_key REDACTED -i in.mp4 ") {
+		t.Errorf("unexpected command %q", ok.String())
+	}
+	if failed.Reason != ffmpeg.StopFailed || failed.Code != 1 || failed.Error == "" || failed.Valid != nil {
+		t.Errorf("unexpected record %+v", failed)
+	}
+	if short.Valid == nil || *short.Valid || !strings.Contains(short.Error, "too short") {
+		t.Errorf("unexpected record %+v", short)
+	}
+
+	if recs, _ = h.Query(ffmpeg.HistoryQuery{Reasons: []ffmpeg.StopReason{ffmpeg.StopFailed}}); len(recs) != 1 {
+		t.Errorf("want 1 failed, got %+v", recs)
+	}
+	if recs, _ = h.Query(ffmpeg.HistoryQuery{To: start}); len(recs) != 0 {
+		t.Errorf("want none, got %+v", recs)
+	}
+}
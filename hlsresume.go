@@ -0,0 +1,135 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A HLSResume is where an interrupted HLS encode continues,
+// see ResumeHLS.
+type HLSResume struct {
+	Segments int           // complete, the number of the next one
+	Offset   time.Duration // of the next segment in the input
+	Done     bool          // the playlist is complete
+}
+
+// ResumeHLS returns where to continue the interrupted encode of
+// the media playlist at path, e.g. after a restart of the
+// worker: after its first max segments, all if max < 0, up to
+// the first whose file is missing. The playlist is rewritten
+// without the segments after. A missing playlist is resumed
+// from the start.
+func ResumeHLS(path string, max int) (HLSResume, error) {
+	head, segs, done, err := readPlaylist(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return HLSResume{}, nil
+	}
+	if err != nil {
+		return HLSResume{}, err
+	}
+
+	var res HLSResume
+	for _, s := range segs {
+		if res.Segments == max {
+			break
+		}
+		uri := s.lines[len(s.lines)-1]
+		if !filepath.IsAbs(uri) {
+			uri = filepath.Join(filepath.Dir(path), uri)
+		}
+		if _, err := os.Stat(uri); err != nil {
+			break
+		}
+		res.Segments++
+		res.Offset += s.duration
+	}
+	if res.Segments == len(segs) {
+		res.Done = done
+		return res, nil
+	}
+
+	lines := head
+	for _, s := range segs[:res.Segments] {
+		lines = append(lines, s.lines...)
+	}
+	return res, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// InputArgs returns the input args seeking to the resume point,
+// "-ss <offset>", empty from the start.
+func (h HLSResume) InputArgs() string {
+	if h.Offset <= 0 {
+		return ""
+	}
+	return "-ss " + seconds(h.Offset)
+}
+
+// OutputArgs returns the output args of the HLS muxer appending
+// the next segments to the playlist, their timestamps following
+// those of the previous ones, empty from the start.
+func (h HLSResume) OutputArgs() string {
+	if h.Segments == 0 {
+		return ""
+	}
+	return "-start_number " + strconv.Itoa(h.Segments) + " -hls_flags append_list -output_ts_offset " + seconds(h.Offset)
+}
+
+// A hlsSegment is a segment of a playlist: its tags, e.g.
+// #EXTINF, and its URI last.
+type hlsSegment struct {
+	lines    []string
+	duration time.Duration
+}
+
+// readPlaylist returns the lines before the first segment of the
+// media playlist at path, its segments and whether it ended.
+func readPlaylist(path string) (head []string, segs []hlsSegment, done bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer f.Close()
+
+	var cur hlsSegment
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+			continue
+		case line == "#EXT-X-ENDLIST":
+			done = true
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			d, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			if v, err := strconv.ParseFloat(d, 64); err == nil {
+				cur.duration = time.Duration(v * float64(time.Second))
+			}
+		case strings.HasPrefix(line, "#") && cur.lines == nil && len(segs) == 0 && !segmentTag(line):
+			head = append(head, line)
+			continue
+		}
+		cur.lines = append(cur.lines, line)
+		if !strings.HasPrefix(line, "#") {
+			segs = append(segs, cur)
+			cur = hlsSegment{}
+		}
+	}
+	return head, segs, done, sc.Err()
+}
+
+// segmentTag reports whether the tag line applies to the next
+// segment rather than to the playlist.
+func segmentTag(line string) bool {
+	for _, t := range []string{"#EXT-X-DISCONTINUITY", "#EXT-X-PROGRAM-DATE-TIME", "#EXT-X-BYTERANGE", "#EXT-X-KEY", "#EXT-X-MAP"} {
+		if strings.HasPrefix(line, t) {
+			return true
+		}
+	}
+	return false
+}
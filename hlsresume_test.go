@@ -0,0 +1,69 @@
+package ffmpeg_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// writeHLS writes an interrupted playlist of n segments of 6s in
+// dir, with the files of the first files.
+func writeHLS(t *testing.T, dir string, n, files int, end bool) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pl := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n"
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("seg%05d.ts", i)
+		pl += "#EXTINF:6.000000,\n" + name + "\n"
+		if i < files {
+			os.WriteFile(filepath.Join(dir, name), []byte("ts"), 0644)
+		}
+	}
+	if end {
+		pl += "#EXT-X-ENDLIST\n"
+	}
+	p := filepath.Join(dir, "index.m3u8")
+	if err := os.WriteFile(p, []byte(pl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestResumeHLS(t *testing.T) {
+	dir := t.TempDir()
+	if res, err := ffmpeg.ResumeHLS(filepath.Join(dir, "none.m3u8"), -1); err != nil || res != (ffmpeg.HLSResume{}) {
+		t.Errorf("unexpected %+v: %v", res, err)
+	}
+
+	p := writeHLS(t, dir, 3, 2, false)
+	res, err := ffmpeg.ResumeHLS(p, -1)
+	if err != nil || res != (ffmpeg.HLSResume{Segments: 2, Offset: 12 * time.Second}) {
+		t.Fatalf("unexpected %+v: %v", res, err)
+	}
+	if res.InputArgs() != "-ss 12" || res.OutputArgs() != "-start_number 2 -hls_flags append_list -output_ts_offset 12" {
+		t.Errorf("unexpected args %q %q", res.InputArgs(), res.OutputArgs())
+	}
+	b, _ := os.ReadFile(p)
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n" +
+		"#EXTINF:6.000000,\nseg00000.ts\n#EXTINF:6.000000,\nseg00001.ts\n"
+	if string(b) != want {
+		t.Errorf("unexpected playlist:\n%s", b)
+	}
+
+	p = writeHLS(t, dir, 3, 3, true)
+	if res, _ = ffmpeg.ResumeHLS(p, -1); !res.Done || res.Segments != 3 {
+		t.Errorf("want done, got %+v", res)
+	}
+	if res, _ = ffmpeg.ResumeHLS(p, 1); res.Done || res.Segments != 1 || res.Offset != 6*time.Second {
+		t.Errorf("unexpected %+v", res)
+	}
+	if (ffmpeg.HLSResume{}).InputArgs() != "" || (ffmpeg.HLSResume{}).OutputArgs() != "" {
+		t.Error("want no args from the start")
+	}
+}
@@ -0,0 +1,200 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// An Accel is a hardware acceleration backend.
+type Accel string
+
+// Supported hardware acceleration backends.
+const (
+	NVENC        Accel = "nvenc"
+	VAAPI        Accel = "vaapi"
+	QSV          Accel = "qsv"
+	VideoToolbox Accel = "videotoolbox"
+)
+
+// hwaccel returns the -hwaccel method of a.
+func (a Accel) hwaccel() string {
+	if a == NVENC {
+		return "cuda"
+	}
+	return string(a)
+}
+
+// InputArgs returns the input options decoding with a on the
+// device-th device. They apply to the -i that follows them.
+func (a Accel) InputArgs(device int) []string {
+	switch a {
+	case NVENC:
+		return []string{"-hwaccel", "cuda", "-hwaccel_device", strconv.Itoa(device)}
+	case VAAPI:
+		return []string{"-hwaccel", "vaapi", "-hwaccel_device", renderNode(device),
+			"-hwaccel_output_format", "vaapi"}
+	case QSV:
+		return []string{"-hwaccel", "qsv", "-qsv_device", renderNode(device)}
+	case VideoToolbox:
+		return []string{"-hwaccel", "videotoolbox"}
+	}
+	return nil
+}
+
+// Encoder returns the name of the encoder of a for the codec,
+// e.g. NVENC.Encoder("h264") is "h264_nvenc".
+func (a Accel) Encoder(codec string) string {
+	return codec + "_" + string(a)
+}
+
+func renderNode(device int) string {
+	return "/dev/dri/renderD" + strconv.Itoa(128+device)
+}
+
+// softEncoders maps the software encoders HWAccel replaces to
+// their codec.
+var softEncoders = map[string]string{
+	"libx264": "h264",
+	"libx265": "hevc",
+	"h264":    "h264",
+	"hevc":    "hevc",
+}
+
+// HWAccel makes the runner decode and encode with a on the
+// device-th device: the input options are prepended and the
+// software H.264/HEVC encoders given to -c:v (or -vcodec) are
+// replaced by the ones of a. Combined with Fallback, the same
+// arg can be retried in software when the hardware is busy.
+func HWAccel(a Accel, device int) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.rewrites = append(r.rewrites, func(args []string) []string {
+			out := append(a.InputArgs(device), args...)
+			for i := 0; i < len(out)-1; i++ {
+				switch out[i] {
+				case "-c:v", "-codec:v", "-vcodec":
+				default:
+					continue
+				}
+				codec, ok := softEncoders[out[i+1]]
+				if !ok {
+					continue
+				}
+				out[i+1] = a.Encoder(codec)
+				if a == NVENC {
+					gpu := []string{"-gpu", strconv.Itoa(device)}
+					out = append(out[:i+2], append(gpu, out[i+2:]...)...)
+				}
+			}
+			return out
+		})
+	}
+}
+
+// HWInfo describes the hardware acceleration available on
+// the machine.
+type HWInfo struct {
+	Methods     []string // as listed by -hwaccels
+	RenderNodes []string // /dev/dri/renderD*
+	NvidiaNodes []string // /dev/nvidia0, /dev/nvidia1, ...
+	NvidiaSMI   bool     // whether nvidia-smi is in PATH
+}
+
+// DetectHWAccel lists the -hwaccels of the FFmpeg binary at path
+// and looks for the device nodes and tools of the backends.
+func DetectHWAccel(ctx context.Context, path string) (*HWInfo, error) {
+	b, err := output(ctx, path, "-hide_banner -hwaccels")
+	if err != nil {
+		return nil, err
+	}
+
+	h := &HWInfo{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		h.Methods = append(h.Methods, line)
+	}
+
+	h.RenderNodes, _ = filepath.Glob("/dev/dri/renderD*")
+	h.NvidiaNodes, _ = filepath.Glob("/dev/nvidia[0-9]*")
+	_, err = exec.LookPath("nvidia-smi")
+	h.NvidiaSMI = err == nil
+
+	return h, nil
+}
+
+func (h *HWInfo) hasMethod(m string) bool {
+	for _, x := range h.Methods {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Available returns the backends both supported by the binary
+// and backed by a device, in order of preference.
+func (h *HWInfo) Available() []Accel {
+	var as []Accel
+	for _, a := range []Accel{NVENC, QSV, VAAPI, VideoToolbox} {
+		if !h.hasMethod(a.hwaccel()) {
+			continue
+		}
+		switch a {
+		case NVENC:
+			if len(h.NvidiaNodes) == 0 && !h.NvidiaSMI {
+				continue
+			}
+		case QSV, VAAPI:
+			if len(h.RenderNodes) == 0 {
+				continue
+			}
+		}
+		as = append(as, a)
+	}
+	return as
+}
+
+// A FallbackRunner runs with its primary Runner and, if that
+// fails, retries the same arg with the fallback Runner.
+type FallbackRunner struct {
+	primary  Runner
+	fallback Runner
+	onFail   func(err error)
+}
+
+// Run runs arg with the primary Runner, then with the fallback
+// Runner if the primary failed for another reason than the
+// context being done.
+func (f *FallbackRunner) Run(ctx context.Context, arg string) error {
+	err := f.primary.Run(ctx, arg)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if f.onFail != nil {
+		f.onFail(err)
+	}
+	if ferr := f.fallback.Run(ctx, arg); ferr != nil {
+		return fmt.Errorf("ffmpeg: fallback failed: %w (primary: %v)", ferr, err)
+	}
+	return nil
+}
+
+// Fallback returns a FallbackRunner, typically with a HWAccel
+// runner as primary and a software one as fallback. The onFail
+// function, if not nil, receives the error of the primary.
+func Fallback(primary, fallback Runner, onFail func(err error)) *FallbackRunner {
+	return &FallbackRunner{
+		primary:  primary,
+		fallback: fallback,
+		onFail:   onFail,
+	}
+}
@@ -0,0 +1,62 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestHWAccel(t *testing.T) {
+	p, last := argsBinary(t)
+
+	cases := []struct {
+		accel ffmpeg.Accel
+		want  string
+	}{
+		{ffmpeg.NVENC, "-hwaccel cuda -hwaccel_device 1 -i in.mp4 -c:v h264_nvenc -gpu 1 -c:a aac out.mp4"},
+		{ffmpeg.VAAPI, "-hwaccel vaapi -hwaccel_device /dev/dri/renderD129 -hwaccel_output_format vaapi -i in.mp4 -c:v h264_vaapi -c:a aac out.mp4"},
+		{ffmpeg.VideoToolbox, "-hwaccel videotoolbox -i in.mp4 -c:v h264_videotoolbox -c:a aac out.mp4"},
+	}
+	for _, c := range cases {
+		r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.HWAccel(c.accel, 1))
+		if err := r.Run(context.TODO(), "-i in.mp4 -c:v libx264 -c:a aac out.mp4"); err != nil {
+			t.Fatal(err)
+		}
+		if got := last(); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.accel, got, c.want)
+		}
+	}
+}
+
+func TestHWInfoAvailable(t *testing.T) {
+	h := &ffmpeg.HWInfo{
+		Methods:     []string{"vdpau", "cuda", "vaapi", "qsv"},
+		RenderNodes: []string{"/dev/dri/renderD128"},
+	}
+	want := []ffmpeg.Accel{ffmpeg.QSV, ffmpeg.VAAPI}
+	if got := h.Available(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	h.NvidiaSMI = true
+	if got := h.Available(); got[0] != ffmpeg.NVENC {
+		t.Errorf("want NVENC first, got %v", got)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	busy := errors.New("OpenEncodeSessionEx failed: out of memory")
+	hw := &fakeRunner{err: busy}
+	sw := &fakeRunner{}
+
+	var failed error
+	f := ffmpeg.Fallback(hw, sw, func(err error) { failed = err })
+	if err := f.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if failed != busy || len(sw.args) != 1 {
+		t.Errorf("fallback not run: %v %v", failed, sw.args)
+	}
+}
@@ -0,0 +1,312 @@
+/*
+Package install downloads pinned static FFmpeg builds into a
+cache directory, e.g. for tests and containerless deployments:
+
+	path, err := install.Install(ctx, install.Builds{
+		"linux/amd64": {URL: "https://.../ffmpeg-release-amd64-static.tar.xz", SHA256: "..."},
+	})
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(path))
+*/
+package install
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// A Build is a downloadable FFmpeg build: a .zip, .tar, .tar.gz,
+// .tgz or .tar.xz archive, or a bare binary.
+type Build struct {
+	URL    string
+	SHA256 string // hex checksum of the file at URL
+
+	// Binaries lists the base names of the binaries to extract,
+	// "ffmpeg" if empty. The ".exe" suffix is added on Windows.
+	Binaries []string
+}
+
+// Builds maps "GOOS/GOARCH" platforms to their Build.
+type Builds map[string]Build
+
+// Errors returned by Install.
+var (
+	ErrUnsupported = errors.New("install: no build for the platform")
+	ErrChecksum    = errors.New("install: checksum mismatch")
+	ErrNotFound    = errors.New("install: binary not found in the archive")
+)
+
+// An Installer downloads builds into its cache directory.
+type Installer struct {
+	dir    string
+	client *http.Client
+	osArch string
+}
+
+// New returns an Installer caching into the "practigo-ffmpeg"
+// directory of os.UserCacheDir unless set with CacheDir.
+func New(opts ...func(i *Installer)) *Installer {
+	i := &Installer{
+		client: http.DefaultClient,
+		osArch: runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if d, err := os.UserCacheDir(); err == nil {
+		i.dir = filepath.Join(d, "practigo-ffmpeg")
+	} else {
+		i.dir = filepath.Join(os.TempDir(), "practigo-ffmpeg")
+	}
+
+	for _, o := range opts {
+		o(i)
+	}
+
+	return i
+}
+
+// CacheDir sets the directory the builds are installed in.
+func CacheDir(dir string) func(i *Installer) {
+	return func(i *Installer) {
+		i.dir = dir
+	}
+}
+
+// Client sets the HTTP client downloading the builds.
+func Client(c *http.Client) func(i *Installer) {
+	return func(i *Installer) {
+		i.client = c
+	}
+}
+
+// Platform overrides the "GOOS/GOARCH" platform to install for.
+func Platform(osArch string) func(i *Installer) {
+	return func(i *Installer) {
+		i.osArch = osArch
+	}
+}
+
+// Install installs with a default Installer, see New.
+func Install(ctx context.Context, builds Builds) (string, error) {
+	return New().Install(ctx, builds)
+}
+
+// Install returns the path of the first binary of the build for
+// the platform, downloading, verifying and extracting the build
+// unless it is in the cache already. The other binaries are in
+// the same directory.
+func (i *Installer) Install(ctx context.Context, builds Builds) (string, error) {
+	b, ok := builds[i.osArch]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, i.osArch)
+	}
+	names := append([]string(nil), b.Binaries...)
+	if len(names) == 0 {
+		names = []string{"ffmpeg"}
+	}
+	if strings.HasPrefix(i.osArch, "windows/") {
+		for j, n := range names {
+			names[j] = n + ".exe"
+		}
+	}
+
+	sum := strings.ToLower(b.SHA256)
+	if len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("install: invalid SHA256 %q", b.SHA256)
+	}
+	dir := filepath.Join(i.dir, sum[:16])
+	bin := filepath.Join(dir, names[0])
+	if _, err := os.Stat(bin); err == nil {
+		return bin, nil
+	}
+
+	if err := os.MkdirAll(i.dir, 0755); err != nil {
+		return "", err
+	}
+	archive, err := i.download(ctx, b.URL, sum)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(archive)
+
+	// extract aside then rename, so that concurrent installs
+	// never see a partial directory
+	tmp, err := os.MkdirTemp(i.dir, "extract-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	if err = extract(archive, b.URL, tmp, names); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp, dir); err != nil {
+		if _, serr := os.Stat(bin); serr == nil {
+			return bin, nil // installed concurrently
+		}
+		return "", err
+	}
+	return bin, nil
+}
+
+// download downloads url into a temporary file of the cache
+// directory and verifies its checksum.
+func (i *Installer) download(ctx context.Context, url, sum string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("install: GET %s: %s", url, resp.Status)
+	}
+
+	f, err := os.CreateTemp(i.dir, "download-")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && hex.EncodeToString(h.Sum(nil)) != sum {
+		err = fmt.Errorf("%w for %s", ErrChecksum, url)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// extract extracts the named binaries of the archive at file,
+// downloaded from url, into dir.
+func extract(file, url, dir string, names []string) error {
+	want := make(map[string]bool)
+	for _, n := range names {
+		want[n] = true
+	}
+	found := make(map[string]bool)
+
+	save := func(name string, r io.Reader) error {
+		base := path.Base(name)
+		if !want[base] || found[base] {
+			return nil
+		}
+		found[base] = true
+		f, err := os.OpenFile(filepath.Join(dir, base), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, r)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	u := strings.ToLower(strings.SplitN(url, "?", 2)[0])
+	var err error
+	switch {
+	case strings.HasSuffix(u, ".zip"):
+		err = extractZip(file, save)
+	case strings.HasSuffix(u, ".tar.gz"), strings.HasSuffix(u, ".tgz"),
+		strings.HasSuffix(u, ".tar.xz"), strings.HasSuffix(u, ".tar"):
+		err = extractTar(file, u, save)
+	default:
+		var f *os.File
+		if f, err = os.Open(file); err == nil {
+			err = save(names[0], f)
+			f.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		if !found[n] {
+			return fmt.Errorf("%w: %s", ErrNotFound, n)
+		}
+	}
+	return nil
+}
+
+func extractZip(file string, save func(name string, r io.Reader) error) error {
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = save(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTar(file, url string, save func(name string, r io.Reader) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch {
+	case strings.HasSuffix(url, ".xz"):
+		if r, err = xz.NewReader(f); err != nil {
+			return err
+		}
+	case strings.HasSuffix(url, "gz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err = save(h.Name, tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package install_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/practigo/ffmpeg/install"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestInstall(t *testing.T) {
+	archive := tarGz(t, map[string]string{
+		"ffmpeg-6.1-amd64-static/ffmpeg":  "#!/bin/sh\necho ffmpeg\n",
+		"ffmpeg-6.1-amd64-static/ffprobe": "#!/bin/sh\necho ffprobe\n",
+		"ffmpeg-6.1-amd64-static/GPL.txt": "license",
+	})
+	sum := sha256.Sum256(archive)
+
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write(archive)
+	}))
+	defer srv.Close()
+
+	builds := install.Builds{"linux/amd64": {
+		URL:      srv.URL + "/ffmpeg-release-amd64-static.tar.gz",
+		SHA256:   hex.EncodeToString(sum[:]),
+		Binaries: []string{"ffmpeg", "ffprobe"},
+	}}
+	in := install.New(install.CacheDir(t.TempDir()), install.Platform("linux/amd64"))
+
+	p, err := in.Install(context.TODO(), builds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(p) != "ffmpeg" {
+		t.Errorf("unexpected path %s", p)
+	}
+	if b, _ := os.ReadFile(filepath.Join(filepath.Dir(p), "ffprobe")); string(b) != "#!/bin/sh\necho ffprobe\n" {
+		t.Errorf("ffprobe not installed: %q", b)
+	}
+
+	// cached
+	if p2, err := in.Install(context.TODO(), builds); err != nil || p2 != p || hits != 1 {
+		t.Errorf("not cached: %s %v %d", p2, err, hits)
+	}
+
+	if _, err = install.New(install.Platform("plan9/386")).Install(context.TODO(), builds); !errors.Is(err, install.ErrUnsupported) {
+		t.Errorf("want ErrUnsupported, got %v", err)
+	}
+}
+
+func TestInstallChecksum(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	in := install.New(install.CacheDir(dir), install.Platform("linux/amd64"))
+	_, err := in.Install(context.TODO(), install.Builds{"linux/amd64": {
+		URL:    srv.URL + "/ffmpeg",
+		SHA256: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+	}})
+	if !errors.Is(err, install.ErrChecksum) {
+		t.Errorf("want ErrChecksum, got %v", err)
+	}
+	if es, _ := os.ReadDir(dir); len(es) != 0 {
+		t.Errorf("leftovers in cache: %v", es)
+	}
+}
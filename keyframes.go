@@ -0,0 +1,37 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keyframes returns the timestamps of the keyframes of the first
+// video stream of input, in order, with the ffprobe binary at
+// probe. It reads the packet flags, without decoding the video.
+func Keyframes(ctx context.Context, probe, input string) ([]time.Duration, error) {
+	b, err := output(ctx, probe, "-v error -select_streams v:0 -show_entries packet=pts_time,flags -print_format csv=p=0 "+input)
+	if err != nil {
+		return nil, err
+	}
+
+	var kfs []time.Duration
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		pts, flags, ok := strings.Cut(s.Text(), ",")
+		if !ok || !strings.Contains(flags, "K") {
+			continue
+		}
+		f, err := strconv.ParseFloat(pts, 64)
+		if err != nil {
+			continue // N/A
+		}
+		kfs = append(kfs, time.Duration(f*float64(time.Second)))
+	}
+	sort.Slice(kfs, func(i, j int) bool { return kfs[i] < kfs[j] })
+	return kfs, s.Err()
+}
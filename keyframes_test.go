@@ -0,0 +1,25 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestKeyframes(t *testing.T) {
+	p := fakeBinary(t, `printf '0.000000,K__\n0.040000,___\n2.000000,K_\nN/A,K__\n0.080000,__D\n4.000000,K__\n'`+"\n")
+	kfs, err := ffmpeg.Keyframes(context.TODO(), p, "in.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []time.Duration{0, 2 * time.Second, 4 * time.Second}; !reflect.DeepEqual(kfs, want) {
+		t.Errorf("got %v, want %v", kfs, want)
+	}
+
+	if _, err = ffmpeg.Keyframes(context.TODO(), fakeBinary(t, "exit 1\n"), "in.mp4"); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,99 @@
+package ffmpeg
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Labels are the metadata of a run, e.g. its job ID, tenant or
+// request ID, to correlate its events, logs, errors, metrics,
+// traces and RunRecord.
+type Labels map[string]string
+
+// String returns l as "k1=v1 k2=v2", sorted by key.
+func (l Labels) String() string {
+	kvs := make([]string, 0, len(l))
+	for _, k := range l.keys() {
+		kvs = append(kvs, k+"="+l[k])
+	}
+	return strings.Join(kvs, " ")
+}
+
+func (l Labels) keys() []string {
+	ks := make([]string, 0, len(l))
+	for k := range l {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+// merge returns a copy of l with the labels of o, overriding
+// those of l, nil if both are empty.
+func (l Labels) merge(o Labels) Labels {
+	if len(l) == 0 && len(o) == 0 {
+		return nil
+	}
+	m := make(Labels, len(l)+len(o))
+	for k, v := range l {
+		m[k] = v
+	}
+	for k, v := range o {
+		m[k] = v
+	}
+	return m
+}
+
+type labelsKey struct{}
+
+// WithLabels returns a copy of ctx with the labels of ctx and
+// l, overriding them, for the runs with the context.
+func WithLabels(ctx context.Context, l Labels) context.Context {
+	return context.WithValue(ctx, labelsKey{}, LabelsFrom(ctx).merge(l))
+}
+
+// LabelsFrom returns the labels of ctx, see WithLabels. They
+// must not be modified.
+func LabelsFrom(ctx context.Context) Labels {
+	l, _ := ctx.Value(labelsKey{}).(Labels)
+	return l
+}
+
+// DefaultLabels sets labels of every run, e.g. the host, those
+// of their context overriding them. The labels of a run are
+// logged, see Logger, given to the subscribers of
+// SubscribeLabels, set in its RunRecord and in its errors, see
+// RunError.
+func DefaultLabels(l Labels) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.labels = r.labels.merge(l)
+	}
+}
+
+// SubscribeLabels is like Subscribe, f being also called with
+// the labels of the run of the event.
+func SubscribeLabels(f func(e Event, l Labels)) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.labelSubs = append(r.labelSubs, f)
+	}
+}
+
+// runLabels returns the labels of a run of r with ctx.
+func (r *HookedRunner) runLabels(ctx context.Context) Labels {
+	return r.labels.merge(LabelsFrom(ctx))
+}
+
+// logAttr returns the labels of a run as a log attribute, with
+// ok false if it has none.
+func (l Labels) logAttr() (attr slog.Attr, ok bool) {
+	if len(l) == 0 {
+		return slog.Attr{}, false
+	}
+	args := make([]interface{}, 0, 2*len(l))
+	for _, k := range l.keys() {
+		args = append(args, k, l[k])
+	}
+	return slog.Group("labels", args...), true
+}
@@ -0,0 +1,61 @@
+package ffmpeg_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestLabels(t *testing.T) {
+	ctx := ffmpeg.WithLabels(context.TODO(), ffmpeg.Labels{"job": "42", "tenant": "acme"})
+	ctx = ffmpeg.WithLabels(ctx, ffmpeg.Labels{"request": "r1", "tenant": "other"})
+	if l := ffmpeg.LabelsFrom(ctx).String(); l != "job=42 request=r1 tenant=other" {
+		t.Errorf("unexpected labels %q", l)
+	}
+	if ffmpeg.LabelsFrom(context.TODO()) != nil {
+		t.Error("want no labels")
+	}
+
+	var logs bytes.Buffer
+	var exited ffmpeg.Labels
+	h := ffmpeg.NewFileHistory(t.TempDir() + "/runs.jsonl")
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(fakeBinary(t, "exit 1")),
+		ffmpeg.DefaultLabels(ffmpeg.Labels{"host": "w1", "job": "0"}),
+		ffmpeg.Logger(slog.New(slog.NewTextHandler(&logs, nil)), slog.LevelInfo),
+		ffmpeg.WithHistory(h),
+		ffmpeg.SubscribeLabels(func(e ffmpeg.Event, l ffmpeg.Labels) {
+			if _, ok := e.(ffmpeg.Exited); ok {
+				exited = l
+			}
+		}))
+
+	err := r.Run(ctx, "-i in.mp4 out.mp4")
+	var re *ffmpeg.RunError
+	var ee *exec.ExitError
+	if !errors.As(err, &re) || !errors.As(err, &ee) {
+		t.Fatalf("want labelled exit error, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "exit status 1 [host=w1 job=42 request=r1 tenant=other] (command: ") {
+		t.Errorf("unexpected error %q", err)
+	}
+	if exited.String() != re.Labels.String() {
+		t.Errorf("unexpected event labels %v", exited)
+	}
+	if !strings.Contains(logs.String(), "labels.job=42") || !strings.Contains(logs.String(), "labels.host=w1") {
+		t.Errorf("labels not logged:\n%s", logs.String())
+	}
+	if recs, _ := h.Query(ffmpeg.HistoryQuery{}); len(recs) != 1 || recs[0].Labels["request"] != "r1" {
+		t.Errorf("unexpected records %+v", recs)
+	}
+
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath(fakeBinary(t, "exit 1")))
+	if err = r.Run(context.TODO(), ""); !errors.As(err, &re) || re.Labels != nil {
+		t.Errorf("unexpected %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Rendition is a variant of a Ladder.
+type Rendition struct {
+	Name         string // e.g. "720p", the directory of its playlist
+	Width        int    // 0 keeps the aspect ratio
+	Height       int
+	VideoBitrate string // e.g. "3000k"
+	AudioBitrate string // e.g. "128k"
+	Profile      string // e.g. "main", FFmpeg's default if empty
+}
+
+// A Ladder transcodes Input into HLS renditions in Dir, each in
+// its own directory, with a master playlist. It writes no DASH
+// manifest, which wants the audio and video in separate fMP4
+// streams: use the dash muxer of FFmpeg for DASH.
+type Ladder struct {
+	Input      string
+	Dir        string
+	Renditions []Rendition
+
+	VideoCodec string        // libx264 by default
+	AudioCodec string        // aac by default
+	NoAudio    bool          // drop the audio
+	Segment    time.Duration // 6s by default
+
+	// Parallel runs a job per rendition instead of a single
+	// one splitting the decoded video, faster when encoders do
+	// not use all the CPUs.
+	Parallel bool
+
+	// Resume makes Run continue the renditions of an interrupted
+	// Run after their last segments, see ResumeHLS, instead of
+	// starting over. Without Parallel, they all continue after
+	// the segments of the one that got the least far.
+	Resume bool
+}
+
+const masterPlaylist = "master.m3u8"
+
+// Args returns the arg of the single FFmpeg run transcoding all
+// the renditions.
+func (l Ladder) Args() string {
+	return l.args(HLSResume{})
+}
+
+func (l Ladder) args(res HLSResume) string {
+	var graph []string
+	split := fmt.Sprintf("[0:v]split=%d", len(l.Renditions))
+	for i := range l.Renditions {
+		split += fmt.Sprintf("[s%d]", i)
+	}
+	graph = append(graph, split)
+	for i, r := range l.Renditions {
+		graph = append(graph, fmt.Sprintf("[s%d]%s[v%d]", i, r.scale(), i))
+	}
+
+	args := []string{"-y", res.InputArgs(), "-i", l.Input, "-filter_complex", strings.Join(graph, ";")}
+	for i, r := range l.Renditions {
+		args = append(args, "-map", fmt.Sprintf("[v%d]", i), l.output(r, res))
+	}
+	return strings.Join(strings.Fields(strings.Join(args, " ")), " ")
+}
+
+// Jobs returns the args of the runs transcoding a rendition
+// each, see Parallel.
+func (l Ladder) Jobs() []string {
+	return l.jobs(make([]HLSResume, len(l.Renditions)))
+}
+
+func (l Ladder) jobs(res []HLSResume) []string {
+	jobs := make([]string, len(l.Renditions))
+	for i, r := range l.Renditions {
+		job := strings.Join([]string{"-y", res[i].InputArgs(), "-i", l.Input, "-vf", r.scale(), "-map 0:v:0", l.output(r, res[i])}, " ")
+		jobs[i] = strings.Join(strings.Fields(job), " ")
+	}
+	return jobs
+}
+
+func (r Rendition) scale() string {
+	w := r.Width
+	if w == 0 {
+		w = -2
+	}
+	return fmt.Sprintf("scale=%d:%d", w, r.Height)
+}
+
+// output returns the encoding and HLS options of r, continuing
+// after res.
+func (l Ladder) output(r Rendition, res HLSResume) string {
+	vc, ac, seg := l.VideoCodec, l.AudioCodec, l.Segment
+	if vc == "" {
+		vc = "libx264"
+	}
+	if ac == "" {
+		ac = "aac"
+	}
+	if seg == 0 {
+		seg = 6 * time.Second
+	}
+
+	args := []string{"-c:v", vc}
+	if r.VideoBitrate != "" {
+		args = append(args, "-b:v", r.VideoBitrate)
+	}
+	if r.Profile != "" {
+		args = append(args, "-profile:v", r.Profile)
+	}
+	// aligned keyframes to switch renditions at any segment
+	args = append(args, "-force_key_frames", "expr:gte(t,n_forced*"+seconds(seg)+")")
+	if l.NoAudio {
+		args = append(args, "-an")
+	} else {
+		args = append(args, "-map 0:a:0? -c:a", ac)
+		if r.AudioBitrate != "" {
+			args = append(args, "-b:a", r.AudioBitrate)
+		}
+	}
+	dir := filepath.Join(l.Dir, r.Name)
+	args = append(args, "-f hls -hls_time", seconds(seg), "-hls_playlist_type vod")
+	if a := res.OutputArgs(); a != "" {
+		args = append(args, a)
+	}
+	args = append(args, "-hls_segment_filename", filepath.Join(dir, "seg%05d.ts"), filepath.Join(dir, "index.m3u8"))
+	return strings.Join(args, " ")
+}
+
+// MasterPlaylist returns the HLS master playlist of l.
+func (l Ladder) MasterPlaylist() string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range l.Renditions {
+		bw := bitrate(r.VideoBitrate)
+		if !l.NoAudio {
+			bw += bitrate(r.AudioBitrate)
+		}
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d", bw)
+		if r.Width > 0 {
+			fmt.Fprintf(&b, ",RESOLUTION=%dx%d", r.Width, r.Height)
+		}
+		fmt.Fprintf(&b, ",NAME=%q\n%s/index.m3u8\n", r.Name, r.Name)
+	}
+	return b.String()
+}
+
+// bitrate parses bitrates like "128k" or "3M" in bits/s.
+func bitrate(s string) int64 {
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "k"), strings.HasSuffix(s, "K"):
+		mult, s = 1000, s[:len(s)-1]
+	case strings.HasSuffix(s, "M"):
+		mult, s = 1000000, s[:len(s)-1]
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(f * float64(mult))
+}
+
+// Run creates the directories of l, transcodes the renditions
+// with r and writes the master playlist. The renditions must
+// have distinct names.
+func (l Ladder) Run(ctx context.Context, r Runner) error {
+	if len(l.Renditions) == 0 {
+		return errors.New("ffmpeg: ladder without renditions")
+	}
+	names := make(map[string]bool)
+	for _, rd := range l.Renditions {
+		if rd.Name == "" || names[rd.Name] {
+			return fmt.Errorf("ffmpeg: ladder rendition name %q empty or duplicate", rd.Name)
+		}
+		names[rd.Name] = true
+	}
+	for _, rd := range l.Renditions {
+		if err := os.MkdirAll(filepath.Join(l.Dir, rd.Name), 0755); err != nil {
+			return err
+		}
+	}
+
+	res, err := l.resume()
+	if err != nil {
+		return err
+	}
+	if !l.Parallel {
+		if !res[0].Done {
+			if err := r.Run(ctx, l.args(res[0])); err != nil {
+				return err
+			}
+		}
+	} else {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var once sync.Once
+		var first error
+		var wg sync.WaitGroup
+		for i, arg := range l.jobs(res) {
+			if res[i].Done {
+				continue
+			}
+			wg.Add(1)
+			go func(name, arg string) {
+				defer wg.Done()
+				if err := r.Run(ctx, arg); err != nil {
+					once.Do(func() {
+						first = fmt.Errorf("ffmpeg: rendition %s: %w", name, err)
+						cancel()
+					})
+				}
+			}(l.Renditions[i].Name, arg)
+		}
+		wg.Wait()
+		if first != nil {
+			return first
+		}
+	}
+
+	return os.WriteFile(filepath.Join(l.Dir, masterPlaylist), []byte(l.MasterPlaylist()), 0644)
+}
+
+// resume returns where the renditions continue, see Resume: the
+// same for all but if Parallel.
+func (l Ladder) resume() ([]HLSResume, error) {
+	res := make([]HLSResume, len(l.Renditions))
+	if !l.Resume {
+		return res, nil
+	}
+	playlist := func(r Rendition) string {
+		return filepath.Join(l.Dir, r.Name, "index.m3u8")
+	}
+	for i, r := range l.Renditions {
+		var err error
+		if res[i], err = ResumeHLS(playlist(r), -1); err != nil {
+			return nil, err
+		}
+	}
+	if l.Parallel || len(res) == 0 {
+		return res, nil
+	}
+
+	least := res[0]
+	for _, rs := range res[1:] {
+		if rs.Segments < least.Segments || rs.Segments == least.Segments && !rs.Done {
+			least = rs
+		}
+	}
+	for i, r := range l.Renditions {
+		if res[i] == least {
+			continue
+		}
+		// drop the segments after the least
+		var err error
+		if res[i], err = ResumeHLS(playlist(r), least.Segments); err != nil {
+			return nil, err
+		}
+	}
+	for i := range res {
+		res[i] = least
+	}
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/practigo/ffmpeg/ffmpegtest"
+)
+
+var renditions = []ffmpeg.Rendition{
+	{Name: "720p", Width: 1280, Height: 720, VideoBitrate: "3000k", AudioBitrate: "128k", Profile: "main"},
+	{Name: "360p", Height: 360, VideoBitrate: "800k", AudioBitrate: "96k"},
+}
+
+func TestLadderArgs(t *testing.T) {
+	l := ffmpeg.Ladder{Input: "in.mp4", Dir: "/out", Renditions: renditions}
+	want := "-y -i in.mp4 -filter_complex [0:v]split=2[s0][s1];[s0]scale=1280:720[v0];[s1]scale=-2:360[v1] " +
+		"-map [v0] -c:v libx264 -b:v 3000k -profile:v main -force_key_frames expr:gte(t,n_forced*6) " +
+		"-map 0:a:0? -c:a aac -b:a 128k -f hls -hls_time 6 -hls_playlist_type vod " +
+		"-hls_segment_filename /out/720p/seg%05d.ts /out/720p/index.m3u8 " +
+		"-map [v1] -c:v libx264 -b:v 800k -force_key_frames expr:gte(t,n_forced*6) " +
+		"-map 0:a:0? -c:a aac -b:a 96k -f hls -hls_time 6 -hls_playlist_type vod " +
+		"-hls_segment_filename /out/360p/seg%05d.ts /out/360p/index.m3u8"
+	if a := l.Args(); a != want {
+		t.Errorf("want\n%s\ngot\n%s", want, a)
+	}
+	if jobs := l.Jobs(); len(jobs) != 2 || !strings.HasPrefix(jobs[1], "-y -i in.mp4 -vf scale=-2:360 -map 0:v:0 -c:v libx264") {
+		t.Errorf("unexpected jobs %q", jobs)
+	}
+}
+
+func TestLadderRun(t *testing.T) {
+	dir := t.TempDir()
+	m := ffmpegtest.NewMock()
+	l := ffmpeg.Ladder{Input: "in.mp4", Dir: dir, Renditions: renditions, Parallel: true}
+	if err := l.Run(context.TODO(), m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Calls()) != 2 {
+		t.Errorf("want 2 runs, got %q", m.Args())
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "master.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=3128000,RESOLUTION=1280x720,NAME="720p"
+720p/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=896000,NAME="360p"
+360p/index.m3u8
+`
+	if string(b) != want {
+		t.Errorf("unexpected master playlist:\n%s", b)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "360p")); err != nil {
+		t.Error(err)
+	}
+
+	if err = (ffmpeg.Ladder{Input: "in.mp4", Dir: dir}).Run(context.TODO(), m); err == nil {
+		t.Error("want an error without renditions")
+	}
+	for _, rds := range [][]ffmpeg.Rendition{
+		{{Height: 720}},
+		{{Name: "720p", Height: 720}, {Name: "720p", Height: 720}},
+	} {
+		if err = (ffmpeg.Ladder{Input: "in.mp4", Dir: dir, Renditions: rds}).Run(context.TODO(), m); err == nil {
+			t.Errorf("%+v: want an error", rds)
+		}
+	}
+}
+
+func TestLadderResume(t *testing.T) {
+	dir := t.TempDir()
+	writeHLS(t, filepath.Join(dir, "720p"), 3, 3, false)
+	writeHLS(t, filepath.Join(dir, "360p"), 2, 2, false)
+
+	m := ffmpegtest.NewMock()
+	l := ffmpeg.Ladder{Input: "in.mp4", Dir: dir, Renditions: renditions, Resume: true}
+	if err := l.Run(context.TODO(), m); err != nil {
+		t.Fatal(err)
+	}
+	args := m.Args()
+	if len(args) != 1 || !strings.HasPrefix(args[0], "-y -ss 12 -i in.mp4") ||
+		strings.Count(args[0], "-start_number 2 -hls_flags append_list -output_ts_offset 12") != 2 {
+		t.Errorf("unexpected args %q", args)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "720p", "index.m3u8")); strings.Contains(string(b), "seg00002.ts") {
+		t.Errorf("segment not dropped:\n%s", b)
+	}
+
+	// done
+	writeHLS(t, filepath.Join(dir, "720p"), 3, 3, true)
+	writeHLS(t, filepath.Join(dir, "360p"), 3, 3, true)
+	m = ffmpegtest.NewMock()
+	l.Parallel = true
+	if err := l.Run(context.TODO(), m); err != nil || len(m.Calls()) != 0 {
+		t.Errorf("unexpected runs %q: %v", m.Args(), err)
+	}
+}
@@ -0,0 +1,148 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLimited is returned by a fail-fast Limiter when a run
+// is not admitted.
+var ErrLimited = errors.New("ffmpeg: run limit reached")
+
+// A Limiter caps the number of concurrent runs and/or the rate
+// at which runs start, across all the Runners it wraps. Share
+// one Limiter to protect a whole program.
+type Limiter struct {
+	sem      chan struct{} // nil if unlimited
+	failFast bool
+
+	mu     sync.Mutex
+	rate   float64 // tokens per second, 0 if unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Limit returns a Limiter, unlimited unless configured with
+// MaxConcurrent and/or RateLimit.
+func Limit(opts ...func(l *Limiter)) *Limiter {
+	l := &Limiter{}
+
+	for _, o := range opts {
+		o(l)
+	}
+
+	return l
+}
+
+// MaxConcurrent allows at most n runs at the same time, at
+// least 1.
+func MaxConcurrent(n int) func(l *Limiter) {
+	if n < 1 {
+		n = 1 // else no run is ever admitted
+	}
+	return func(l *Limiter) {
+		l.sem = make(chan struct{}, n)
+	}
+}
+
+// RateLimit admits perSecond runs per second on average with
+// bursts of up to burst runs (a token bucket), at least 1.
+func RateLimit(perSecond float64, burst int) func(l *Limiter) {
+	if burst < 1 {
+		burst = 1 // else no run is ever admitted
+	}
+	return func(l *Limiter) {
+		l.rate = perSecond
+		l.burst = float64(burst)
+		l.tokens = float64(burst)
+	}
+}
+
+// FailFast makes runs not admitted right away return ErrLimited
+// instead of waiting.
+func FailFast() func(l *Limiter) {
+	return func(l *Limiter) {
+		l.failFast = true
+	}
+}
+
+// Wrap returns a Runner running r within the limits of l.
+// Wrap is a Middleware.
+func (l *Limiter) Wrap(r Runner) Runner {
+	return RunnerFunc(func(ctx context.Context, arg string) error {
+		if l.failFast {
+			// the slot first, not to waste a token on a run
+			// not admitted
+			if l.sem != nil {
+				select {
+				case l.sem <- struct{}{}:
+				default:
+					return ErrLimited
+				}
+				defer func() { <-l.sem }()
+			}
+			if err := l.admit(ctx); err != nil {
+				return err
+			}
+			return r.Run(ctx, arg)
+		}
+
+		if err := l.admit(ctx); err != nil {
+			return err
+		}
+		if l.sem != nil {
+			select {
+			case l.sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-l.sem }()
+		}
+		return r.Run(ctx, arg)
+	})
+}
+
+// Running returns the number of runs in progress, if l
+// limits the concurrency.
+func (l *Limiter) Running() int {
+	return len(l.sem)
+}
+
+// admit takes a token, waiting for one unless failing fast.
+func (l *Limiter) admit(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if !l.last.IsZero() {
+			l.tokens += now.Sub(l.last).Seconds() * l.rate
+			if l.tokens > l.burst {
+				l.tokens = l.burst
+			}
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if l.failFast {
+			return ErrLimited
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestLimiterConcurrency(t *testing.T) {
+	var running, peak int32
+	slow := ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+
+	r := ffmpeg.Limit(ffmpeg.MaxConcurrent(2)).Wrap(slow)
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Run(context.TODO(), "")
+		}()
+	}
+	wg.Wait()
+	if peak != 2 {
+		t.Errorf("want peak of 2 runs, got %d", peak)
+	}
+}
+
+func TestLimiterFailFast(t *testing.T) {
+	block := make(chan struct{})
+	l := ffmpeg.Limit(ffmpeg.MaxConcurrent(1), ffmpeg.FailFast())
+	r := l.Wrap(ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error {
+		<-block
+		return nil
+	}))
+
+	go r.Run(context.TODO(), "")
+	for l.Running() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := r.Run(context.TODO(), ""); !errors.Is(err, ffmpeg.ErrLimited) {
+		t.Errorf("want ErrLimited, got %v", err)
+	}
+	close(block)
+
+	// the runs not admitted keep the tokens
+	block = make(chan struct{})
+	l = ffmpeg.Limit(ffmpeg.MaxConcurrent(0), ffmpeg.RateLimit(0.01, 2), ffmpeg.FailFast())
+	r = l.Wrap(ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error {
+		<-block
+		return nil
+	}))
+	done := make(chan error)
+	go func() { done <- r.Run(context.TODO(), "") }()
+	for l.Running() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := r.Run(context.TODO(), ""); !errors.Is(err, ffmpeg.ErrLimited) {
+		t.Errorf("want ErrLimited, got %v", err)
+	}
+	close(block)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Run(context.TODO(), ""); err != nil {
+		t.Errorf("want the token left, got %v", err)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	nop := ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error { return nil })
+	r := ffmpeg.Limit(ffmpeg.RateLimit(20, 2)).Wrap(nop)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		r.Run(context.TODO(), "")
+	}
+	// 2 from the burst, then 2 at 50ms intervals
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Errorf("rate not limited: 4 runs in %s", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := ffmpeg.Limit(ffmpeg.RateLimit(100, 0)).Wrap(nop).Run(ctx, ""); err != nil {
+		t.Errorf("burst 0: %v", err)
+	}
+
+	ff := ffmpeg.Limit(ffmpeg.RateLimit(1, 1), ffmpeg.FailFast()).Wrap(nop)
+	ff.Run(context.TODO(), "")
+	if err := ff.Run(context.TODO(), ""); !errors.Is(err, ffmpeg.ErrLimited) {
+		t.Errorf("want ErrLimited, got %v", err)
+	}
+}
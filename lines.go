@@ -0,0 +1,76 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os/exec"
+)
+
+// LineHook calls h with every line FFmpeg writes to stderr while
+// it runs. Both \n and \r end a line, so the stats FFmpeg keeps
+// rewriting in place come as separate lines. A Stderr set by the
+// pre hook still receives the output. LineHook can be given
+// several times; the hooks are called in order from a single
+// goroutine and should not block.
+func LineHook(h func(line string)) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.lines = append(r.lines, h)
+	}
+}
+
+// attachLines tees the stderr of cmd to the line hooks of r
+// and to em for progress. The returned function must be called
+// once cmd has exited; it returns after the last line was handled.
+func (r *HookedRunner) attachLines(cmd *exec.Cmd, em *emitter) func() {
+	if len(r.lines) == 0 && em == nil {
+		return func() {}
+	}
+
+	pr, pw := io.Pipe()
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, pw)
+	} else {
+		cmd.Stderr = pw
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s := bufio.NewScanner(pr)
+		s.Buffer(make([]byte, 4096), 1<<20)
+		s.Split(ScanLines)
+		for s.Scan() {
+			if len(s.Bytes()) == 0 {
+				continue
+			}
+			line := s.Text()
+			for _, h := range r.lines {
+				h(line)
+			}
+			em.line(line)
+		}
+		// never block the writer, e.g. after a too long line
+		io.Copy(io.Discard, pr)
+	}()
+
+	return func() {
+		pw.Close()
+		<-done
+	}
+}
+
+// ScanLines is the bufio.SplitFunc of LineHook, splitting on \n
+// or \r. A \r\n gives an empty token.
+func ScanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
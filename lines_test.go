@@ -0,0 +1,37 @@
+package ffmpeg_test
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestLineHook(t *testing.T) {
+	p := fakeBinary(t, `printf 'Input #0\nframe=1\rframe=2\rdone' >&2`+"\n")
+
+	var lines []string
+	var raw bytes.Buffer
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p),
+		ffmpeg.PreHook(func(cmd *exec.Cmd) error {
+			cmd.Stderr = &raw
+			return nil
+		}),
+		ffmpeg.LineHook(func(line string) {
+			lines = append(lines, line)
+		}))
+	if err := r.Run(context.TODO(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Input #0", "frame=1", "frame=2", "done"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+	if raw.String() != "Input #0\nframe=1\rframe=2\rdone" {
+		t.Errorf("pre hook stderr got %q", raw.String())
+	}
+}
@@ -0,0 +1,57 @@
+package ffmpeg
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"time"
+)
+
+// Logger makes the runner log its lifecycle to l: the resolved
+// path and final args (redacted, see WithRedactor), the pid
+// once started, and the exit code and duration once exited.
+// These are logged at level; failures to start and non-zero
+// exits are logged at slog.LevelError.
+func Logger(l *slog.Logger, level slog.Level) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.logger = l
+		r.level = level
+	}
+}
+
+func (r *HookedRunner) log(ctx context.Context, msg string, args ...interface{}) {
+	if r.logger != nil {
+		r.logger.Log(ctx, r.level, msg, r.withLabels(ctx, args)...)
+	}
+}
+
+func (r *HookedRunner) logErr(ctx context.Context, msg string, err error) {
+	if r.logger != nil {
+		r.logger.Log(ctx, slog.LevelError, msg, r.withLabels(ctx, []interface{}{"err", err})...)
+	}
+}
+
+// withLabels appends the labels of the run with ctx to the
+// log args.
+func (r *HookedRunner) withLabels(ctx context.Context, args []interface{}) []interface{} {
+	if attr, ok := r.runLabels(ctx).logAttr(); ok {
+		return append(args, attr)
+	}
+	return args
+}
+
+func (r *HookedRunner) logExit(ctx context.Context, cmd *exec.Cmd, d time.Duration, err error) {
+	if r.logger == nil {
+		return
+	}
+	args := []interface{}{"pid", cmd.Process.Pid, "duration", d}
+	if cmd.ProcessState != nil {
+		args = append(args, "code", cmd.ProcessState.ExitCode())
+	}
+	level := r.level
+	if err != nil {
+		args = append(args, "err", err)
+		level = slog.LevelError
+	}
+	r.logger.Log(ctx, level, "ffmpeg exited", r.withLabels(ctx, args)...)
+}
@@ -0,0 +1,34 @@
+package ffmpeg_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p := fakeBinary(t, "exit 3\n")
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.Logger(l, slog.LevelDebug))
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err == nil {
+		t.Fatal("want exit error")
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		`level=DEBUG msg="ffmpeg resolved" path=` + p + ` args="[-i in.mp4 out.mp4]"`,
+		`level=DEBUG msg="ffmpeg started" pid=`,
+		`level=ERROR msg="ffmpeg exited" pid=`,
+		`code=3 err="exit status 3"`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q in:\n%s", s, out)
+		}
+	}
+}
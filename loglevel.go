@@ -0,0 +1,106 @@
+package ffmpeg
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A LogLevel is the level of a FFmpeg log line, the lower the
+// more severe, as the -loglevel values.
+type LogLevel int
+
+// The LogLevels of FFmpeg.
+const (
+	LogPanic   LogLevel = 0
+	LogFatal   LogLevel = 8
+	LogError   LogLevel = 16
+	LogWarning LogLevel = 24
+	LogInfo    LogLevel = 32
+	LogVerbose LogLevel = 40
+	LogDebug   LogLevel = 48
+	LogTrace   LogLevel = 56
+)
+
+var logLevels = map[string]LogLevel{
+	"panic": LogPanic, "fatal": LogFatal, "error": LogError, "warning": LogWarning,
+	"info": LogInfo, "verbose": LogVerbose, "debug": LogDebug, "trace": LogTrace,
+}
+
+func (l LogLevel) String() string {
+	for s, v := range logLevels {
+		if v == l {
+			return s
+		}
+	}
+	return "LogLevel(" + strconv.Itoa(int(l)) + ")"
+}
+
+// Slog returns the slog.Level of l: error and more severe ones
+// map to slog.LevelError, verbose and less severe ones to
+// slog.LevelDebug.
+func (l LogLevel) Slog() slog.Level {
+	switch {
+	case l <= LogError:
+		return slog.LevelError
+	case l <= LogWarning:
+		return slog.LevelWarn
+	case l <= LogInfo:
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+// A LogRecord is a stderr line of FFmpeg, e.g.
+// "[h264 @ 0x55d0c8] [warning] mmco: unref short failure".
+type LogRecord struct {
+	Component string // "h264", "" for the lines without context
+	Level     LogLevel
+	Message   string
+}
+
+var logLineRe = regexp.MustCompile(`^(?:\[([^\]]+)\] )?\[(panic|fatal|error|warning|info|verbose|debug|trace)\] (.*)$`)
+
+// parseLogLine parses a line written with -loglevel +level.
+func parseLogLine(line string) (LogRecord, bool) {
+	m := logLineRe.FindStringSubmatch(line)
+	if m == nil {
+		return LogRecord{}, false
+	}
+	component, _, _ := strings.Cut(m[1], " @ ")
+	return LogRecord{Component: component, Level: logLevels[m[2]], Message: m[3]}, true
+}
+
+// LogHook calls h with the stderr lines of level min or more
+// severe, e.g. LogWarning, except the stats. The runner adds
+// "-loglevel +level" to the args for FFmpeg to prefix the lines
+// with their level, which the -loglevel of the arg still sets.
+// LogHook can be given several times.
+func LogHook(min LogLevel, h func(rec LogRecord)) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.levels = true
+		r.lines = append(r.lines, func(line string) {
+			rec, ok := parseLogLine(line)
+			if !ok || rec.Level > min {
+				return
+			}
+			if _, stats := parseStats(rec.Message); !stats {
+				h(rec)
+			}
+		})
+	}
+}
+
+// LogStderr logs the stderr lines of level min or more severe
+// to l, at their Slog level, with the component as attribute.
+func LogStderr(l *slog.Logger, min LogLevel) func(r *HookedRunner) {
+	return LogHook(min, func(rec LogRecord) {
+		args := []interface{}{}
+		if rec.Component != "" {
+			args = append(args, "component", rec.Component)
+		}
+		l.Log(context.Background(), rec.Level.Slog(), rec.Message, args...)
+	})
+}
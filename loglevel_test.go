@@ -0,0 +1,75 @@
+package ffmpeg_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+const fakeLevels = `echo "$@" >&2
+echo "[h264 @ 0x55d0c8] [warning] mmco: unref short failure" >&2
+echo "[info] Press [q] to stop" >&2
+echo "[info] frame=  100 fps= 25 time=00:00:04.00 speed=1x" >&2
+echo "[error] Conversion failed!" >&2
+`
+
+func TestLogHook(t *testing.T) {
+	var recs []ffmpeg.LogRecord
+	var args string
+	var progress int
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(fakeBinary(t, fakeLevels)),
+		ffmpeg.LogHook(ffmpeg.LogWarning, func(rec ffmpeg.LogRecord) { recs = append(recs, rec) }),
+		ffmpeg.LineHook(func(line string) {
+			if args == "" {
+				args = line
+			}
+		}),
+		ffmpeg.Subscribe(func(e ffmpeg.Event) {
+			if _, ok := e.(ffmpeg.Progress); ok {
+				progress++
+			}
+		}))
+	if err := r.Run(context.TODO(), "-v warning -i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+
+	if args != "-loglevel +level -v warning -i in.mp4 out.mp4" {
+		t.Errorf("unexpected args %q", args)
+	}
+	want := []ffmpeg.LogRecord{
+		{Component: "h264", Level: ffmpeg.LogWarning, Message: "mmco: unref short failure"},
+		{Level: ffmpeg.LogError, Message: "Conversion failed!"},
+	}
+	if len(recs) != 2 || recs[0] != want[0] || recs[1] != want[1] {
+		t.Errorf("got %+v, want %+v", recs, want)
+	}
+	if progress != 1 {
+		t.Errorf("want the progress with the level prefix, got %d", progress)
+	}
+}
+
+func TestLogStderr(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(fakeBinary(t, fakeLevels)), ffmpeg.LogStderr(l, ffmpeg.LogInfo))
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{
+		`level=WARN msg="mmco: unref short failure" component=h264`,
+		`level=INFO msg="Press [q] to stop"`,
+		`level=ERROR msg="Conversion failed!"`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("%q not logged in:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "frame=") {
+		t.Errorf("stats logged in:\n%s", out)
+	}
+}
@@ -0,0 +1,148 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Chapter is a chapter of a media file.
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration
+	Title string
+	Tags  map[string]string // the tags other than the title
+}
+
+// Metadata are the tags and chapters of a media file.
+type Metadata struct {
+	Tags     map[string]string   // of the container
+	Streams  []map[string]string // the tags, by stream index
+	Chapters []Chapter           // by start
+}
+
+// ReadMetadata returns the Metadata of input, read with the
+// ffprobe binary at probe.
+func ReadMetadata(ctx context.Context, probe, input string) (*Metadata, error) {
+	b, err := output(ctx, probe, "-v error -print_format json -show_format -show_streams -show_chapters "+input)
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		ProbeInfo
+		Chapters []struct {
+			Start Seconds           `json:"start_time"`
+			End   Seconds           `json:"end_time"`
+			Tags  map[string]string `json:"tags"`
+		} `json:"chapters"`
+	}
+	if err = json.Unmarshal(b, &report); err != nil {
+		return nil, err
+	}
+
+	m := &Metadata{Tags: report.Format.Tags}
+	for _, s := range report.Streams {
+		m.Streams = append(m.Streams, s.Tags)
+	}
+	for _, c := range report.Chapters {
+		ch := Chapter{Start: c.Start.Duration(), End: c.End.Duration(), Title: c.Tags["title"]}
+		for k, v := range c.Tags {
+			if k == "title" {
+				continue
+			}
+			if ch.Tags == nil {
+				ch.Tags = make(map[string]string)
+			}
+			ch.Tags[k] = v
+		}
+		m.Chapters = append(m.Chapters, ch)
+	}
+	return m, nil
+}
+
+// AddChapter inserts c in the Chapters, by start.
+func (m *Metadata) AddChapter(c Chapter) {
+	i := sort.Search(len(m.Chapters), func(i int) bool {
+		return m.Chapters[i].Start > c.Start
+	})
+	m.Chapters = append(m.Chapters, Chapter{})
+	copy(m.Chapters[i+1:], m.Chapters[i:])
+	m.Chapters[i] = c
+}
+
+// RemoveChapter removes the i-th chapter.
+func (m *Metadata) RemoveChapter(i int) {
+	m.Chapters = append(m.Chapters[:i], m.Chapters[i+1:]...)
+}
+
+// RenameChapter sets the title of the i-th chapter.
+func (m *Metadata) RenameChapter(i int, title string) {
+	m.Chapters[i].Title = title
+}
+
+// FFMetadata returns m as a FFMETADATA file, the stream tags
+// as [STREAM] sections.
+func (m *Metadata) FFMetadata() string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	writeTags(&b, m.Tags)
+	for _, tags := range m.Streams {
+		b.WriteString("[STREAM]\n")
+		writeTags(&b, tags)
+	}
+	for _, c := range m.Chapters {
+		b.WriteString("[CHAPTER]\nTIMEBASE=1/1000\n")
+		b.WriteString("START=" + strconv.FormatInt(c.Start.Milliseconds(), 10) + "\n")
+		b.WriteString("END=" + strconv.FormatInt(c.End.Milliseconds(), 10) + "\n")
+		tags := map[string]string{}
+		for k, v := range c.Tags {
+			tags[k] = v
+		}
+		if c.Title != "" {
+			tags["title"] = c.Title
+		}
+		writeTags(&b, tags)
+	}
+	return b.String()
+}
+
+// writeTags writes tags in key order, escaped.
+func writeTags(b *strings.Builder, tags map[string]string) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(escapeRunes(k, "=;#\\\n") + "=" + escapeRunes(tags[k], "=;#\\\n") + "\n")
+	}
+}
+
+// WriteMetadata copies the streams of input to output with the
+// tags and chapters of m, replacing those of input, through a
+// temporary FFMETADATA file.
+func WriteMetadata(ctx context.Context, r Runner, input, output string, m *Metadata) error {
+	f, err := os.CreateTemp("", "ffmetadata-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(m.FFMetadata())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-i", input, "-f ffmetadata -i", f.Name(), "-map 0 -map_metadata 1 -map_chapters 1"}
+	for i := range m.Streams {
+		n := strconv.Itoa(i)
+		args = append(args, "-map_metadata:s:"+n, "1:s:"+n)
+	}
+	return r.Run(ctx, strings.Join(append(args, "-c copy", output), " "))
+}
@@ -0,0 +1,106 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+const fakeChapters = `cat <<JSON
+{
+    "chapters": [
+        {"id": 0, "time_base": "1/1000", "start": 0, "start_time": "0.000000", "end": 60000, "end_time": "60.000000",
+         "tags": {"title": "Intro"}},
+        {"id": 1, "time_base": "1/1000", "start": 60000, "start_time": "60.000000", "end": 120000, "end_time": "120.000000",
+         "tags": {"title": "Part 1", "artist": "A=B"}}
+    ],
+    "streams": [
+        {"index": 0, "codec_type": "video", "tags": {"handler_name": "VideoHandler"}},
+        {"index": 1, "codec_type": "audio", "tags": {"language": "eng", "title": "Director's cut; stereo"}}
+    ],
+    "format": {"filename": "in.mp4", "duration": "120.000000", "tags": {"title": "My movie", "comment": "#1\nline 2"}}
+}
+JSON
+`
+
+func TestReadMetadata(t *testing.T) {
+	m, err := ffmpeg.ReadMetadata(context.TODO(), fakeBinary(t, fakeChapters), "in.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tags["title"] != "My movie" || len(m.Streams) != 2 || m.Streams[1]["language"] != "eng" {
+		t.Errorf("unexpected tags %+v", m)
+	}
+	if len(m.Chapters) != 2 || m.Chapters[1].Start != time.Minute || m.Chapters[1].Title != "Part 1" || m.Chapters[1].Tags["artist"] != "A=B" {
+		t.Fatalf("unexpected chapters %+v", m.Chapters)
+	}
+
+	m.RemoveChapter(0)
+	m.AddChapter(ffmpeg.Chapter{Start: 0, End: 30 * time.Second, Title: "Cold open"})
+	m.AddChapter(ffmpeg.Chapter{Start: 30 * time.Second, End: time.Minute, Title: "Credits"})
+	m.RenameChapter(2, "Part one")
+
+	want := `;FFMETADATA1
+comment=\#1\
+line 2
+title=My movie
+[STREAM]
+handler_name=VideoHandler
+[STREAM]
+language=eng
+title=Director's cut\; stereo
+[CHAPTER]
+TIMEBASE=1/1000
+START=0
+END=30000
+title=Cold open
+[CHAPTER]
+TIMEBASE=1/1000
+START=30000
+END=60000
+title=Credits
+[CHAPTER]
+TIMEBASE=1/1000
+START=60000
+END=120000
+artist=A\=B
+title=Part one
+`
+	if got := m.FFMetadata(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteMetadata(t *testing.T) {
+	dir := t.TempDir()
+	// keeps the args and a copy of the FFMETADATA input
+	p := fakeBinary(t, `echo "$@" > `+dir+`/args
+while [ $# -gt 0 ]; do [ "$1" = ffmetadata ] && cp "$3" `+dir+`/meta; shift; done
+`)
+	m := &ffmpeg.Metadata{Tags: map[string]string{"title": "Mine"}, Streams: []map[string]string{{}, {"language": "fra"}}}
+	if err := ffmpeg.WriteMetadata(context.TODO(), ffmpeg.HookRunner(ffmpeg.CustomPath(p)), "in.mp4", "out.mp4", m); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(filepath.Join(dir, "args"))
+	fs := strings.Fields(string(b))
+	if len(fs) < 6 {
+		t.Fatalf("unexpected args %q", b)
+	}
+	want := "-i in.mp4 -f ffmetadata -i " + fs[5] + " -map 0 -map_metadata 1 -map_chapters 1 " +
+		"-map_metadata:s:0 1:s:0 -map_metadata:s:1 1:s:1 -c copy out.mp4"
+	if strings.TrimSpace(string(b)) != want {
+		t.Errorf("got %q, want %q", b, want)
+	}
+	if meta, _ := os.ReadFile(filepath.Join(dir, "meta")); string(meta) != m.FFMetadata() {
+		t.Errorf("unexpected FFMETADATA %q", meta)
+	}
+	if _, err := os.Stat(fs[5]); !os.IsNotExist(err) {
+		t.Errorf("FFMETADATA file not removed: %v", err)
+	}
+}
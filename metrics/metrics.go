@@ -0,0 +1,149 @@
+/*
+Package metrics exposes Prometheus metrics about the
+activity of ffmpeg Runners.
+*/
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A Collector counts the runs of the Runners it wraps.
+// It is a prometheus.Collector to be registered with a
+// prometheus.Registerer.
+type Collector struct {
+	labels []string // of the runs, after "job"
+
+	started   *prometheus.CounterVec
+	succeeded *prometheus.CounterVec
+	failed    *prometheus.CounterVec
+	restarts  *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	running   *prometheus.GaugeVec
+}
+
+// New returns a Collector whose metrics are prefixed with
+// namespace (e.g. "transcoder_ffmpeg_runs_started_total")
+// and labelled with "job" and labels, the ffmpeg.Labels of the
+// context of the runs with these keys, empty if missing. The
+// labels should have few values, e.g. "tenant" but not a
+// request ID.
+func New(namespace string, labels ...string) *Collector {
+	runLabels := labels
+	labels = append([]string{"job"}, labels...)
+	counter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ffmpeg",
+			Name:      name,
+			Help:      help,
+		}, labels)
+	}
+
+	return &Collector{
+		labels:    runLabels,
+		started:   counter("runs_started_total", "Number of runs started."),
+		succeeded: counter("runs_succeeded_total", "Number of runs exited successfully."),
+		failed:    counter("runs_failed_total", "Number of runs exited with an error."),
+		restarts:  counter("restarts_total", "Number of runs restarted by a supervisor."),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "ffmpeg",
+			Name:      "run_duration_seconds",
+			Help:      "Duration of the runs.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 14), // up to ~68min
+		}, labels),
+		running: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "ffmpeg",
+			Name:      "running",
+			Help:      "Number of runs in progress.",
+		}, labels),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.started.Describe(ch)
+	c.succeeded.Describe(ch)
+	c.failed.Describe(ch)
+	c.restarts.Describe(ch)
+	c.duration.Describe(ch)
+	c.running.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.started.Collect(ch)
+	c.succeeded.Collect(ch)
+	c.failed.Collect(ch)
+	c.restarts.Collect(ch)
+	c.duration.Collect(ch)
+	c.running.Collect(ch)
+}
+
+// Restarted counts a restart of a job, for supervisors
+// running the job again after it exited, with the values of
+// the labels of the Collector, in order.
+func (c *Collector) Restarted(job string, labels ...string) {
+	c.restarts.WithLabelValues(c.values(job, labels)...).Inc()
+}
+
+// values returns the label values of job with the labels of
+// the Collector, missing ones empty.
+func (c *Collector) values(job string, labels []string) []string {
+	vs := make([]string, len(c.labels)+1)
+	vs[0] = job
+	copy(vs[1:], labels)
+	return vs
+}
+
+// Wrap returns a Runner counting the runs of r with the
+// job label.
+func (c *Collector) Wrap(r ffmpeg.Runner, job string) ffmpeg.Runner {
+	return &runner{c: c, r: r, job: job}
+}
+
+type runner struct {
+	c   *Collector
+	r   ffmpeg.Runner
+	job string
+}
+
+func (r *runner) Run(ctx context.Context, arg string) error {
+	c := r.c
+	l := ffmpeg.LabelsFrom(ctx)
+	labels := make([]string, len(c.labels))
+	for i, k := range c.labels {
+		labels[i] = l[k]
+	}
+	vs := c.values(r.job, labels)
+
+	c.started.WithLabelValues(vs...).Inc()
+	running := c.running.WithLabelValues(vs...)
+	running.Inc()
+	start := time.Now()
+
+	err := r.r.Run(ctx, arg)
+
+	running.Dec()
+	c.duration.WithLabelValues(vs...).Observe(time.Since(start).Seconds())
+	if err != nil {
+		c.failed.WithLabelValues(vs...).Inc()
+	} else {
+		c.succeeded.WithLabelValues(vs...).Inc()
+	}
+	return err
+}
+
+// Middleware returns a ffmpeg.Middleware wrapping Runners
+// with c, see Wrap.
+func (c *Collector) Middleware(job string) ffmpeg.Middleware {
+	return func(r ffmpeg.Runner) ffmpeg.Runner {
+		return c.Wrap(r, job)
+	}
+}
@@ -0,0 +1,82 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/practigo/ffmpeg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type errRunner struct{ err error }
+
+func (r errRunner) Run(ctx context.Context, arg string) error { return r.err }
+
+func TestCollector(t *testing.T) {
+	c := metrics.New("test")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	ok := c.Wrap(errRunner{}, "thumb")
+	bad := c.Wrap(errRunner{errors.New("exit status 1")}, "vod")
+	ok.Run(context.TODO(), "")
+	ok.Run(context.TODO(), "")
+	bad.Run(context.TODO(), "")
+	c.Restarted("vod")
+
+	want := `
+# HELP test_ffmpeg_runs_failed_total Number of runs exited with an error.
+# TYPE test_ffmpeg_runs_failed_total counter
+test_ffmpeg_runs_failed_total{job="vod"} 1
+# HELP test_ffmpeg_runs_succeeded_total Number of runs exited successfully.
+# TYPE test_ffmpeg_runs_succeeded_total counter
+test_ffmpeg_runs_succeeded_total{job="thumb"} 2
+# HELP test_ffmpeg_restarts_total Number of runs restarted by a supervisor.
+# TYPE test_ffmpeg_restarts_total counter
+test_ffmpeg_restarts_total{job="vod"} 1
+# HELP test_ffmpeg_running Number of runs in progress.
+# TYPE test_ffmpeg_running gauge
+test_ffmpeg_running{job="thumb"} 0
+test_ffmpeg_running{job="vod"} 0
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"test_ffmpeg_runs_failed_total", "test_ffmpeg_runs_succeeded_total",
+		"test_ffmpeg_restarts_total", "test_ffmpeg_running")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorLabels(t *testing.T) {
+	c := metrics.New("test", "tenant")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.Wrap(errRunner{}, "vod")
+	r.Run(ffmpeg.WithLabels(context.TODO(), ffmpeg.Labels{"tenant": "acme", "request": "r1"}), "")
+	r.Run(context.TODO(), "")
+	c.Restarted("vod", "acme")
+
+	want := `
+# HELP test_ffmpeg_runs_succeeded_total Number of runs exited successfully.
+# TYPE test_ffmpeg_runs_succeeded_total counter
+test_ffmpeg_runs_succeeded_total{job="vod",tenant=""} 1
+test_ffmpeg_runs_succeeded_total{job="vod",tenant="acme"} 1
+# HELP test_ffmpeg_restarts_total Number of runs restarted by a supervisor.
+# TYPE test_ffmpeg_restarts_total counter
+test_ffmpeg_restarts_total{job="vod",tenant="acme"} 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"test_ffmpeg_runs_succeeded_total", "test_ffmpeg_restarts_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,27 @@
+package ffmpeg
+
+import "context"
+
+// The RunnerFunc type is an adapter to allow the use of
+// ordinary functions as Runners.
+type RunnerFunc func(ctx context.Context, arg string) error
+
+// Run calls f(ctx, arg).
+func (f RunnerFunc) Run(ctx context.Context, arg string) error {
+	return f(ctx, arg)
+}
+
+// A Middleware wraps a Runner to add behavior around its runs,
+// e.g. retries, metrics, logging, rate limiting or tracing.
+type Middleware func(r Runner) Runner
+
+// Chain returns a Middleware applying ms in order, the first
+// one being the outermost: Chain(a, b)(r) is a(b(r)).
+func Chain(ms ...Middleware) Middleware {
+	return func(r Runner) Runner {
+		for i := len(ms) - 1; i >= 0; i-- {
+			r = ms[i](r)
+		}
+		return r
+	}
+}
@@ -0,0 +1,32 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	named := func(name string) ffmpeg.Middleware {
+		return func(next ffmpeg.Runner) ffmpeg.Runner {
+			return ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error {
+				calls = append(calls, name)
+				return next.Run(ctx, arg)
+			})
+		}
+	}
+
+	r := ffmpeg.Chain(named("a"), named("b"), named("c"))(
+		ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error {
+			calls = append(calls, arg)
+			return nil
+		}))
+	r.Run(context.TODO(), "run")
+
+	if got := strings.Join(calls, ","); got != "a,b,c,run" {
+		t.Errorf("unexpected order %s", got)
+	}
+}
@@ -0,0 +1,100 @@
+package ffmpeg
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rotation returns the clockwise rotation, 0, 90, 180 or 270
+// degrees, the players apply to display s, from its display
+// matrix or its "rotate" tag, e.g. 90 for a portrait phone video.
+func (s ProbeStream) Rotation() int {
+	deg := 0
+	if v, ok := s.Tags["rotate"]; ok {
+		deg, _ = strconv.Atoi(v)
+	}
+	for _, sd := range s.SideData {
+		if sd.Type != "Display Matrix" {
+			continue
+		}
+		// counter-clockwise
+		if f, err := strconv.ParseFloat(sd.Fields["rotation"], 64); err == nil {
+			deg = -int(math.Round(f))
+		}
+	}
+	deg = (deg%360 + 360) % 360
+	return (deg + 45) / 90 * 90 % 360
+}
+
+// uprights are the filters undoing the clockwise rotations.
+var uprights = map[int]string{90: "transpose=clock", 180: "hflip,vflip", 270: "transpose=cclock"}
+
+// rotation returns the Rotation of the first video of info.
+func rotation(info *ProbeInfo) int {
+	if vs := info.StreamsOf("video"); len(vs) > 0 {
+		return vs[0].Rotation()
+	}
+	return 0
+}
+
+// An Orient writes Input to Output with its first video upright.
+type Orient struct {
+	Input  string
+	Output string
+	Args   string // the output args, e.g. "-c:v libx264 -c:a copy"
+
+	// Copy copies the streams, which can't be rotated then:
+	// their rotation is set as the metadata the players apply.
+	Copy bool
+
+	// Version is that of FFmpeg, 6.0 or newer if nil, whose
+	// -display_rotation replaces the rotate tag.
+	Version *VersionInfo
+}
+
+// Arg returns the arg writing the Input described by info. The
+// video is rotated with transpose, its rotation then cleared,
+// unless Copy.
+func (o Orient) Arg(info *ProbeInfo) string {
+	rot := rotation(info)
+	modern := o.Version == nil || o.Version.Supports(DisplayRotation)
+
+	args := []string{"-y"}
+	var out []string
+	switch {
+	case rot == 0: // upright already
+	case o.Copy && modern:
+		args = append(args, "-display_rotation:v:0", strconv.Itoa((360-rot)%360))
+	case o.Copy:
+		out = append(out, "-metadata:s:v:0", "rotate="+strconv.Itoa(rot))
+	default:
+		if modern {
+			args = append(args, "-display_rotation:v:0 0")
+		} else {
+			args = append(args, "-noautorotate")
+			out = append(out, "-metadata:s:v:0 rotate=0")
+		}
+		out = append(out, "-filter:v:0", uprights[rot])
+	}
+	args = append(args, "-i", o.Input, "-map 0:v:0 -map 0:a?")
+	if o.Copy {
+		args = append(args, "-c copy")
+	}
+	args = append(args, out...)
+	if o.Args != "" {
+		args = append(args, o.Args)
+	}
+	return strings.Join(append(args, o.Output), " ")
+}
+
+// Run probes the Input with the ffprobe binary at probe, writes
+// the Output with r and returns the rotation of the Input.
+func (o Orient) Run(ctx context.Context, r Runner, probe string) (int, error) {
+	info, err := Probe(ctx, probe, o.Input)
+	if err != nil {
+		return 0, err
+	}
+	return rotation(info), r.Run(ctx, o.Arg(info))
+}
@@ -0,0 +1,63 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestRotation(t *testing.T) {
+	for in, want := range map[string]int{
+		`{}`:                          0,
+		`{"tags": {"rotate": "90"}}`:  90,
+		`{"tags": {"rotate": "-90"}}`: 270,
+		`{"side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90}]}`:  90,
+		`{"side_data_list": [{"side_data_type": "Display Matrix", "rotation": 180}]}`:  180,
+		`{"side_data_list": [{"side_data_type": "Display Matrix", "rotation": 90.0}]}`: 270,
+	} {
+		var s ffmpeg.ProbeStream
+		if err := json.Unmarshal([]byte(in), &s); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Rotation(); got != want {
+			t.Errorf("%s: got %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestOrientArg(t *testing.T) {
+	info := &ffmpeg.ProbeInfo{Streams: []ffmpeg.ProbeStream{{CodecType: "video", Tags: map[string]string{"rotate": "90"}}}}
+	old := &ffmpeg.VersionInfo{Major: 5, Minor: 1}
+	for _, c := range []struct {
+		o    ffmpeg.Orient
+		want string
+	}{
+		{ffmpeg.Orient{Args: "-c:v libx264"},
+			"-y -display_rotation:v:0 0 -i in.mov -map 0:v:0 -map 0:a? -filter:v:0 transpose=clock -c:v libx264 out.mp4"},
+		{ffmpeg.Orient{Version: old},
+			"-y -noautorotate -i in.mov -map 0:v:0 -map 0:a? -metadata:s:v:0 rotate=0 -filter:v:0 transpose=clock out.mp4"},
+		{ffmpeg.Orient{Copy: true},
+			"-y -display_rotation:v:0 270 -i in.mov -map 0:v:0 -map 0:a? -c copy out.mp4"},
+		{ffmpeg.Orient{Copy: true, Version: old},
+			"-y -i in.mov -map 0:v:0 -map 0:a? -c copy -metadata:s:v:0 rotate=90 out.mp4"},
+	} {
+		c.o.Input, c.o.Output = "in.mov", "out.mp4"
+		if a := c.o.Arg(info); a != c.want {
+			t.Errorf("got %q, want %q", a, c.want)
+		}
+	}
+}
+
+func TestOrientRun(t *testing.T) {
+	p, last := argsBinary(t)
+	o := ffmpeg.Orient{Input: "in.mp4", Output: "out.mp4"}
+	rot, err := o.Run(context.TODO(), ffmpeg.HookRunner(ffmpeg.CustomPath(p)), fakeBinary(t, fakeProbe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rot != 0 || last() != "-y -i in.mp4 -map 0:v:0 -map 0:a? out.mp4" {
+		t.Errorf("unexpected run %d %q", rot, last())
+	}
+}
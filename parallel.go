@@ -0,0 +1,189 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A ParallelTranscoder transcodes the video of long inputs as
+// keyframe-aligned chunks encoded concurrently, the audio as a
+// whole, and stitches the results together.
+type ParallelTranscoder struct {
+	videoArgs string
+	audioArgs string
+	opts      []func(r *HookedRunner)
+	workers   int
+	chunk     time.Duration
+	probe     string
+	progress  func(done, total time.Duration)
+}
+
+// NewParallel returns a ParallelTranscoder encoding the video
+// with videoArgs, e.g. "-c:v libx264 -crf 20", and the audio with
+// audioArgs, e.g. "-c:a aac -b:a 128k", or dropping the audio if
+// audioArgs is empty. FFmpeg runs with a HookedRunner built with
+// opts.
+func NewParallel(videoArgs, audioArgs string, opts ...func(r *HookedRunner)) *ParallelTranscoder {
+	return &ParallelTranscoder{
+		videoArgs: videoArgs,
+		audioArgs: audioArgs,
+		opts:      opts,
+		workers:   runtime.NumCPU(),
+		chunk:     time.Minute,
+	}
+}
+
+// Workers sets the number of chunks encoded at the same time,
+// at least 1, the number of CPUs by default.
+func (p *ParallelTranscoder) Workers(n int) *ParallelTranscoder {
+	p.workers = max(n, 1)
+	return p
+}
+
+// ChunkDuration sets the target duration of the chunks, a
+// minute by default. The chunks are cut at the next keyframe.
+func (p *ParallelTranscoder) ChunkDuration(d time.Duration) *ParallelTranscoder {
+	p.chunk = d
+	return p
+}
+
+// Probe sets the ffprobe binary probing the inputs, to report
+// the total of the progress and skip the audio of inputs having
+// none.
+func (p *ParallelTranscoder) Probe(path string) *ParallelTranscoder {
+	p.probe = path
+	return p
+}
+
+// OnProgress calls f with the duration of video encoded so far
+// over all the chunks, and the duration of the input if probed.
+func (p *ParallelTranscoder) OnProgress(f func(done, total time.Duration)) *ParallelTranscoder {
+	p.progress = f
+	return p
+}
+
+// Transcode transcodes input into output. The first failure
+// cancels the other runs.
+func (p *ParallelTranscoder) Transcode(ctx context.Context, input, output string) error {
+	var total time.Duration
+	audio := p.audioArgs != ""
+	if p.probe != "" {
+		info, err := Probe(ctx, p.probe, input)
+		if err != nil {
+			return err
+		}
+		total = info.Format.Duration.Duration()
+		audio = audio && len(info.StreamsOf("audio")) > 0
+	}
+
+	ws, err := NewWorkspace("")
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	split := fmt.Sprintf("-y -i %s -map 0:v:0 -c copy -f segment -segment_time %s -reset_timestamps 1 %s",
+		input, seconds(p.chunk), ws.Path("chunk%05d.mkv"))
+	if err = HookRunner(p.opts...).Run(ctx, split); err != nil {
+		return fmt.Errorf("ffmpeg: split: %w", err)
+	}
+	chunks, err := filepath.Glob(ws.Path("chunk*.mkv"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(chunks)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu    sync.Mutex
+		first error
+		done  = make([]time.Duration, len(chunks))
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, p.workers)
+	)
+	failed := func(err error) {
+		mu.Lock()
+		if first == nil {
+			first = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	if audio {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			arg := fmt.Sprintf("-y -i %s -vn -map 0:a:0 %s %s", input, p.audioArgs, ws.Path("audio.mka"))
+			if err := HookRunner(p.opts...).Run(ctx, arg); err != nil {
+				failed(fmt.Errorf("ffmpeg: audio: %w", err))
+			}
+		}()
+	}
+
+	encoded := make([]string, len(chunks))
+	for i, c := range chunks {
+		encoded[i] = ws.Path(fmt.Sprintf("enc%05d.mkv", i))
+		wg.Add(1)
+		go func(i int, c string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			opts := p.opts
+			if p.progress != nil {
+				opts = append(opts[:len(opts):len(opts)], Subscribe(func(e Event) {
+					pr, ok := e.(Progress)
+					if !ok {
+						return
+					}
+					mu.Lock()
+					done[i] = pr.Time
+					var sum time.Duration
+					for _, d := range done {
+						sum += d
+					}
+					mu.Unlock()
+					p.progress(sum, total)
+				}))
+			}
+			arg := fmt.Sprintf("-y -i %s -an %s %s", c, p.videoArgs, encoded[i])
+			if err := HookRunner(opts...).Run(ctx, arg); err != nil {
+				failed(fmt.Errorf("ffmpeg: chunk %d: %w", i, err))
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	if first != nil {
+		return first
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	list := ws.Path("list.ffconcat")
+	if err = os.WriteFile(list, []byte(ConcatList(encoded)), 0644); err != nil {
+		return err
+	}
+	args := []string{"-y -f concat -safe 0 -i", list}
+	if audio {
+		args = append(args, "-i", ws.Path("audio.mka"), "-map 0:v -map 1:a")
+	}
+	args = append(args, "-c copy", output)
+	if err = HookRunner(p.opts...).Run(ctx, strings.Join(args, " ")); err != nil {
+		return fmt.Errorf("ffmpeg: stitch: %w", err)
+	}
+	return nil
+}
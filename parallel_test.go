@@ -0,0 +1,78 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// fakeParallel splits into 3 chunks, encodes each as 3s of
+// video, and records the stitch args and list.
+const fakeParallel = `for out; do :; done
+case "$*" in
+*"-f segment"*) for i in 0 1 2; do touch "$(echo "$out" | sed "s/%05d/0000$i/")"; done;;
+*"-f concat"*) echo "$@" > $RECORD/args; cp "$7" $RECORD/list;;
+*"-an"*) echo "frame=  75 fps= 25 time=00:00:03.00 speed=2x" >&2; touch "$out";;
+*) touch "$out";;
+esac
+`
+
+func TestParallelTranscoder(t *testing.T) {
+	dir := t.TempDir()
+	p := fakeBinary(t, strings.ReplaceAll(fakeParallel, "$RECORD", dir))
+
+	var mu sync.Mutex
+	var done, total time.Duration
+	pt := ffmpeg.NewParallel("-c:v libx264 -crf 20", "-c:a aac", ffmpeg.CustomPath(p)).
+		Workers(2).
+		ChunkDuration(30 * time.Second).
+		Probe(fakeBinary(t, fakeProbe)).
+		OnProgress(func(d, t time.Duration) {
+			mu.Lock()
+			if d > done {
+				done = d
+			}
+			total = t
+			mu.Unlock()
+		})
+	if err := pt.Transcode(context.TODO(), "in.mp4", "out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if !strings.Contains(string(args), "-map 0:v -map 1:a -c copy out.mp4") {
+		t.Errorf("unexpected stitch args %q", args)
+	}
+	list, _ := os.ReadFile(filepath.Join(dir, "list"))
+	if n := strings.Count(string(list), "file '"); n != 3 {
+		t.Errorf("want 3 chunks stitched, got %q", list)
+	}
+	mu.Lock()
+	if done != 9*time.Second || total != 10*time.Second {
+		t.Errorf("unexpected progress %s/%s", done, total)
+	}
+	mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := pt.Workers(0).Transcode(ctx, "in.mp4", "out.mp4"); err != nil {
+		t.Errorf("0 workers: %v", err)
+	}
+}
+
+func TestParallelTranscoderFailure(t *testing.T) {
+	p := fakeBinary(t, `case "$*" in *-an*) exit 1;; esac
+for out; do :; done
+touch "$(echo "$out" | sed "s/%05d/00000/")"
+`)
+	err := ffmpeg.NewParallel("-c:v libx264", "", ffmpeg.CustomPath(p)).Transcode(context.TODO(), "in.mp4", "out.mp4")
+	if err == nil || !strings.Contains(err.Error(), "chunk 0") {
+		t.Errorf("want chunk error, got %v", err)
+	}
+}
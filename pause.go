@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrPauseUnsupported is returned by Pause and Resume on the
+// platforms without SIGSTOP and SIGCONT, see CanPause.
+var ErrPauseUnsupported = errors.New("ffmpeg: pause not supported on this platform")
+
+// A Process is a handle on a running FFmpeg, see OnProcess.
+type Process struct {
+	proc *os.Process
+
+	mu      sync.Mutex
+	paused  bool
+	onPause []func(paused bool) // the clocks of the run
+}
+
+// OnProcess calls f with the Process of every run once FFmpeg
+// started, e.g. for a scheduler to pause the background encodes
+// while a live job runs. OnProcess can be given several times.
+func OnProcess(f func(p *Process)) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.onProcess = append(r.onProcess, f)
+	}
+}
+
+// Pid returns the process ID of FFmpeg.
+func (p *Process) Pid() int {
+	return p.proc.Pid
+}
+
+// Pause suspends FFmpeg with SIGSTOP. A paused FFmpeg reports
+// no progress: the clocks of Timeout and StallTimeout stop until
+// it is resumed. A paused FFmpeg is resumed before the exit hook
+// (see DoneHook) stops it.
+func (p *Process) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := pause(p.proc); err != nil {
+		return err
+	}
+	p.set(true)
+	return nil
+}
+
+// Resume resumes a paused FFmpeg with SIGCONT.
+func (p *Process) Resume() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := resume(p.proc); err != nil {
+		return err
+	}
+	p.set(false)
+	return nil
+}
+
+// cont resumes FFmpeg if paused, before the exit hook.
+func (p *Process) cont() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused && resume(p.proc) == nil {
+		p.set(false)
+	}
+}
+
+// set records the state with p.mu held, notifying the clocks.
+func (p *Process) set(paused bool) {
+	if p.paused == paused {
+		return
+	}
+	p.paused = paused
+	for _, f := range p.onPause {
+		f(paused)
+	}
+}
+
+// Paused reports whether FFmpeg is paused.
+func (p *Process) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
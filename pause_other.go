@@ -0,0 +1,16 @@
+//go:build !unix
+
+package ffmpeg
+
+import "os"
+
+// CanPause reports whether Pause and Resume are supported.
+const CanPause = false
+
+func pause(p *os.Process) error {
+	return ErrPauseUnsupported
+}
+
+func resume(p *os.Process) error {
+	return ErrPauseUnsupported
+}
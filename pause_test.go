@@ -0,0 +1,132 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestPause(t *testing.T) {
+	if !ffmpeg.CanPause {
+		t.Skip("pause not supported")
+	}
+	ticks := filepath.Join(t.TempDir(), "ticks")
+	size := func() int64 {
+		fi, err := os.Stat(ticks)
+		if err != nil {
+			return 0
+		}
+		return fi.Size()
+	}
+
+	procs := make(chan *ffmpeg.Process, 1)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(fakeBinary(t, "while :; do echo x >> "+ticks+"; sleep 0.02; done\n")),
+		ffmpeg.OnProcess(func(p *ffmpeg.Process) { procs <- p }))
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx, "-i in.mp4 out.mp4")
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	p := <-procs
+	time.Sleep(100 * time.Millisecond)
+	if err := p.Pause(); err != nil || !p.Paused() {
+		t.Fatalf("not paused: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	paused := size()
+	time.Sleep(200 * time.Millisecond)
+	if size() != paused {
+		t.Error("ran while paused")
+	}
+
+	if err := p.Resume(); err != nil || p.Paused() {
+		t.Fatalf("not resumed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if size() == paused {
+		t.Error("not running once resumed")
+	}
+}
+
+func TestPauseClocks(t *testing.T) {
+	if !ffmpeg.CanPause {
+		t.Skip("pause not supported")
+	}
+	var stalls atomic.Int32
+	procs := make(chan *ffmpeg.Process, 1)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep"),
+		ffmpeg.Timeout(150*time.Millisecond),
+		ffmpeg.StallTimeout(50*time.Millisecond),
+		ffmpeg.Subscribe(func(e ffmpeg.Event) {
+			if _, ok := e.(ffmpeg.Stalled); ok {
+				stalls.Add(1)
+			}
+		}),
+		ffmpeg.OnProcess(func(p *ffmpeg.Process) {
+			p.Pause()
+			procs <- p
+		}))
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.Run(context.TODO(), "10")
+	}()
+
+	p := <-procs
+	select {
+	case err := <-errc:
+		t.Fatalf("stopped while paused: %v", err)
+	case <-time.After(400 * time.Millisecond):
+	}
+	if n := stalls.Load(); n != 0 {
+		t.Errorf("%d stalls while paused", n)
+	}
+	p.Resume()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ffmpeg.ErrTimeout) {
+			t.Errorf("want timeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no timeout once resumed")
+	}
+	if stalls.Load() == 0 {
+		t.Error("no stall once resumed")
+	}
+}
+
+func TestPauseExitHook(t *testing.T) {
+	if !ffmpeg.CanPause {
+		t.Skip("pause not supported")
+	}
+	started := make(chan struct{})
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep"),
+		ffmpeg.DoneHook(func(cmd *exec.Cmd) { cmd.Process.Signal(syscall.SIGTERM) }),
+		ffmpeg.OnProcess(func(p *ffmpeg.Process) {
+			p.Pause()
+			close(started)
+		}))
+	ctx, cancel := context.WithCancel(context.TODO())
+	go func() {
+		<-started
+		cancel()
+	}()
+	start := time.Now()
+	r.Run(ctx, "10")
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("paused FFmpeg not stopped, took %s", d)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package ffmpeg
+
+import (
+	"os"
+	"syscall"
+)
+
+// CanPause reports whether Pause and Resume are supported.
+const CanPause = true
+
+func pause(p *os.Process) error {
+	return p.Signal(syscall.SIGSTOP)
+}
+
+func resume(p *os.Process) error {
+	return p.Signal(syscall.SIGCONT)
+}
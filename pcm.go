@@ -0,0 +1,114 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The PCM sample formats of ReadAudio.
+const (
+	S16LE = "s16le" // signed 16-bit little-endian
+	F32LE = "f32le" // 32-bit float little-endian
+)
+
+// AudioOptions are the options of ReadAudio. The zero value
+// reads 16kHz mono S16LE, suited to speech recognition.
+type AudioOptions struct {
+	Format     string // S16LE or F32LE
+	SampleRate int
+	Channels   int
+}
+
+func (ao *AudioOptions) defaults() {
+	if ao.Format == "" {
+		ao.Format = S16LE
+	}
+	if ao.SampleRate == 0 {
+		ao.SampleRate = 16000
+	}
+	if ao.Channels == 0 {
+		ao.Channels = 1
+	}
+}
+
+// frameSize returns the bytes of a sample of every channel.
+func (ao *AudioOptions) frameSize() int {
+	if ao.Format == F32LE {
+		return 4 * ao.Channels
+	}
+	return 2 * ao.Channels
+}
+
+// An AudioReader is an io.Reader of the interleaved PCM samples
+// of an input, FFmpeg being blocked while they are not read.
+type AudioReader struct {
+	opts   AudioOptions
+	pipe   *io.PipeReader
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu    sync.Mutex
+	bytes int64
+}
+
+// ReadAudio starts FFmpeg decoding the first audio stream of
+// input into an AudioReader, with a HookedRunner built with
+// opts. The AudioReader must be closed. It fails for a Format
+// other than S16LE and F32LE.
+func ReadAudio(ctx context.Context, input string, ao AudioOptions, opts ...func(r *HookedRunner)) (*AudioReader, error) {
+	ao.defaults()
+	if ao.Format != S16LE && ao.Format != F32LE {
+		return nil, fmt.Errorf("ffmpeg: unsupported PCM format %q", ao.Format)
+	}
+	arg := strings.Join([]string{"-nostdin -v error -i", input, "-map 0:a:0 -vn -f", ao.Format,
+		"-ar", strconv.Itoa(ao.SampleRate), "-ac", strconv.Itoa(ao.Channels), "-"}, " ")
+
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(ctx)
+	ar := &AudioReader{opts: ao, pipe: pr, cancel: cancel, done: make(chan struct{})}
+	opts = append(opts[:len(opts):len(opts)], withSetup(func(cmd *exec.Cmd) (func(), error) {
+		cmd.Stdout = pw
+		return func() {}, nil
+	}))
+	go func() {
+		defer close(ar.done)
+		pw.CloseWithError(HookRunner(opts...).Run(ctx, arg))
+	}()
+	return ar, nil
+}
+
+// Read implements io.Reader. It returns io.EOF at the end of
+// the audio, or the error of the run.
+func (ar *AudioReader) Read(p []byte) (int, error) {
+	n, err := ar.pipe.Read(p)
+	ar.mu.Lock()
+	ar.bytes += int64(n)
+	ar.mu.Unlock()
+	return n, err
+}
+
+// Samples returns the number of samples per channel read.
+func (ar *AudioReader) Samples() int64 {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.bytes / int64(ar.opts.frameSize())
+}
+
+// Position returns the timestamp of the next sample to read.
+func (ar *AudioReader) Position() time.Duration {
+	return time.Duration(ar.Samples() * int64(time.Second) / int64(ar.opts.SampleRate))
+}
+
+// Close stops FFmpeg and waits for its exit.
+func (ar *AudioReader) Close() error {
+	ar.cancel()
+	ar.pipe.Close()
+	<-ar.done
+	return nil
+}
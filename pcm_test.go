@@ -0,0 +1,48 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestReadAudio(t *testing.T) {
+	args := t.TempDir() + "/args"
+	// 0.5s of 16kHz mono s16le
+	p := fakeBinary(t, `echo "$@" > `+args+"\nhead -c 16000 /dev/zero\n")
+	ar, err := ffmpeg.ReadAudio(context.TODO(), "in.mp4", ffmpeg.AudioOptions{}, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ar.Close()
+
+	buf := make([]byte, 8000)
+	if _, err = io.ReadFull(ar, buf); err != nil {
+		t.Fatal(err)
+	}
+	if ar.Samples() != 4000 || ar.Position() != 250*time.Millisecond {
+		t.Errorf("unexpected position %d samples, %s", ar.Samples(), ar.Position())
+	}
+	n, err := io.Copy(io.Discard, ar)
+	if err != nil || n != 8000 || ar.Position() != 500*time.Millisecond {
+		t.Errorf("unexpected end after %d bytes at %s: %v", n, ar.Position(), err)
+	}
+}
+
+func TestReadAudioError(t *testing.T) {
+	p := fakeBinary(t, "exit 1\n")
+	if _, err := ffmpeg.ReadAudio(context.TODO(), "in.mp4", ffmpeg.AudioOptions{Format: "s24le"}, ffmpeg.CustomPath(p)); err == nil {
+		t.Error("want an error for s24le")
+	}
+	ar, err := ffmpeg.ReadAudio(context.TODO(), "in.mp4", ffmpeg.AudioOptions{Format: ffmpeg.F32LE, Channels: 2}, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ar.Close()
+	if _, err = io.ReadAll(ar); err == nil {
+		t.Error("want the exit error")
+	}
+}
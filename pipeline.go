@@ -0,0 +1,152 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"text/template"
+)
+
+// A Step is a stage of a Pipeline: a FFmpeg run or a Go func.
+type Step struct {
+	Name string
+
+	// Arg is a text/template of the FFmpeg arg, executed with
+	// the StepEnv, e.g.
+	// "-i {{.Output "normalize"}} -c:v libx264 {{.Out}}".
+	Arg string
+
+	// Output is the file name of the output of the step in the
+	// workspace, {{.Out}} in Arg. Steps writing their output
+	// out of the workspace need none.
+	Output string
+
+	// Func, if set, is run instead of FFmpeg, e.g. to probe
+	// the input or to move the results.
+	Func func(ctx context.Context, env StepEnv) error
+}
+
+// A StepEnv is the environment a Step runs in.
+type StepEnv struct {
+	Dir string // the shared workspace
+	Out string // the path of the Output of the step
+
+	outputs map[string]string
+}
+
+// Output returns the path of the Output of the previous step
+// name, or "" if there is none.
+func (e StepEnv) Output(name string) string {
+	return e.outputs[name]
+}
+
+// A StepProgress is the Progress of a step of a Pipeline.
+type StepProgress struct {
+	Index int // of the step, from 0
+	Steps int
+	Name  string
+	Progress
+}
+
+// A StepError reports the failure of a step of a Pipeline.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return "ffmpeg: step " + e.Step + ": " + e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// A Pipeline runs steps in order in a shared Workspace,
+// removed once the pipeline is over.
+type Pipeline struct {
+	root     string
+	steps    []Step
+	opts     []func(r *HookedRunner)
+	progress func(p StepProgress)
+}
+
+// NewPipeline returns an empty Pipeline running FFmpeg with a
+// HookedRunner built with opts.
+func NewPipeline(opts ...func(r *HookedRunner)) *Pipeline {
+	return &Pipeline{opts: opts}
+}
+
+// Then appends s to the steps of p and returns p.
+func (p *Pipeline) Then(s Step) *Pipeline {
+	p.steps = append(p.steps, s)
+	return p
+}
+
+// OnProgress calls f with the Progress of every FFmpeg step.
+func (p *Pipeline) OnProgress(f func(sp StepProgress)) *Pipeline {
+	p.progress = f
+	return p
+}
+
+// In sets the directory the workspaces are created in,
+// os.TempDir() by default.
+func (p *Pipeline) In(root string) *Pipeline {
+	p.root = root
+	return p
+}
+
+// Run runs the steps in order until one fails, and returns a
+// *StepError for it.
+func (p *Pipeline) Run(ctx context.Context) error {
+	ws, err := NewWorkspace(p.root)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+	dir := ws.Dir
+
+	outputs := make(map[string]string)
+	for i, s := range p.steps {
+		env := StepEnv{Dir: dir, outputs: outputs}
+		if s.Output != "" {
+			env.Out = filepath.Join(dir, s.Output)
+		}
+		if err = p.run(ctx, i, s, env); err != nil {
+			return &StepError{Step: s.Name, Err: err}
+		}
+		if env.Out != "" {
+			outputs[s.Name] = env.Out
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) run(ctx context.Context, i int, s Step, env StepEnv) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.Func != nil {
+		return s.Func(ctx, env)
+	}
+
+	t, err := template.New(s.Name).Parse(s.Arg)
+	if err != nil {
+		return err
+	}
+	var arg bytes.Buffer
+	if err = t.Execute(&arg, env); err != nil {
+		return fmt.Errorf("arg: %w", err)
+	}
+
+	opts := p.opts
+	if p.progress != nil {
+		opts = append(opts[:len(opts):len(opts)], Subscribe(func(e Event) {
+			if pr, ok := e.(Progress); ok {
+				p.progress(StepProgress{Index: i, Steps: len(p.steps), Name: s.Name, Progress: pr})
+			}
+		}))
+	}
+	return HookRunner(opts...).Run(ctx, arg.String())
+}
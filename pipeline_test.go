@@ -0,0 +1,67 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// fakeCopy copies the input to the output, failing on inputs
+// named fail.
+const fakeCopy = `echo "frame=  10 fps=25" >&2
+case "$2" in *fail*) exit 1;; esac
+cp "$2" "$3"
+`
+
+func TestPipeline(t *testing.T) {
+	dir := t.TempDir()
+	in, final := filepath.Join(dir, "in.mp4"), filepath.Join(dir, "final.mp4")
+	if err := os.WriteFile(in, []byte("media"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var workspace string
+	var progress []ffmpeg.StepProgress
+	p := ffmpeg.NewPipeline(ffmpeg.CustomPath(fakeBinary(t, fakeCopy))).
+		Then(ffmpeg.Step{Name: "normalize", Arg: "-i " + in + " {{.Out}}", Output: "norm.mp4"}).
+		Then(ffmpeg.Step{Name: "check", Func: func(ctx context.Context, env ffmpeg.StepEnv) error {
+			workspace = env.Dir
+			_, err := os.Stat(env.Output("normalize"))
+			return err
+		}}).
+		Then(ffmpeg.Step{Name: "transcode", Arg: `-i {{.Output "normalize"}} ` + final}).
+		OnProgress(func(sp ffmpeg.StepProgress) { progress = append(progress, sp) })
+	if err := p.Run(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := os.ReadFile(final); err != nil || string(b) != "media" {
+		t.Errorf("unexpected output %q: %v", b, err)
+	}
+	if _, err := os.Stat(workspace); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("workspace not removed: %v", err)
+	}
+	if len(progress) != 2 || progress[1].Index != 2 || progress[1].Steps != 3 || progress[1].Frame != 10 {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+}
+
+func TestPipelineFailure(t *testing.T) {
+	ran := false
+	p := ffmpeg.NewPipeline(ffmpeg.CustomPath(fakeBinary(t, fakeCopy))).
+		Then(ffmpeg.Step{Name: "transcode", Arg: "-i fail.mp4 {{.Out}}", Output: "out.mp4"}).
+		Then(ffmpeg.Step{Name: "package", Func: func(ctx context.Context, env ffmpeg.StepEnv) error {
+			ran = true
+			return nil
+		}})
+
+	err := p.Run(context.TODO())
+	var se *ffmpeg.StepError
+	if !errors.As(err, &se) || se.Step != "transcode" || ran {
+		t.Errorf("want transcode StepError, got %v (next step ran: %v)", err, ran)
+	}
+}
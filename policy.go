@@ -0,0 +1,408 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Policy restricts the args FFmpeg may be run with, to help
+// passing user-influenced values to FFmpeg safely.
+//
+// The inputs (values of -i) and outputs (arguments that are
+// not options) are checked against Protocols and Root, and the
+// graphs of the lavfi inputs (-f lavfi -i) against Filters.
+// The values of the known options naming other files are not:
+// forbid such options with Flags. Those of the other options,
+// e.g. of encoders or muxers, are checked like outputs in case
+// they name files, see Options.
+type Policy struct {
+	// Protocols lists the allowed protocols of inputs and
+	// outputs, e.g. "file", "pipe", "rtsp", "https". Paths
+	// without a scheme are "file" and "-" is "pipe". An empty
+	// list allows any protocol.
+	Protocols []string
+
+	// Flags lists the forbidden options. Stream specifiers are
+	// ignored, so "-filter_script" also forbids "-filter_script:v".
+	// "-/" forbids all the options whose value FFmpeg reads from
+	// a file, e.g. "-/vf".
+	Flags []string
+
+	// Filters lists the filters forbidden in -vf, -af, -filter,
+	// -filter_complex and lavfi inputs, e.g. "movie" which reads
+	// any file.
+	Filters []string
+
+	// Options lists the options, in addition to the usual ones
+	// of FFmpeg, whose values are not files, e.g. "-x264opts".
+	Options []string
+
+	// Root, if not empty, is the directory all the files read
+	// or written must be in.
+	Root string
+}
+
+// ForbiddenFlags are options reading or writing files other
+// than the inputs and outputs, for Policy.Flags.
+var ForbiddenFlags = []string{
+	"-filter_script", "-filter_complex_script", "-attach",
+	"-dump_attachment", "-passlogfile", "-vstats_file", "-report",
+	"-sdp_file", "-stats_file", "-progress", "-/",
+}
+
+// ForbiddenFilters are filters reading or writing files or
+// accepting commands, for Policy.Filters.
+var ForbiddenFilters = []string{
+	"movie", "amovie", "sendcmd", "asendcmd", "zmq", "azmq",
+}
+
+// A PolicyError reports an arg not allowed by a Policy.
+type PolicyError struct {
+	Arg    string
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("ffmpeg: arg %q not allowed: %s", e.Arg, e.Reason)
+}
+
+// noValue lists the options not taking a value, to tell the
+// outputs from the option values. The boolean ones can also be
+// negated with "no", e.g. -noautorotate, see takesValue.
+var noValue = map[string]bool{
+	"-y": true, "-n": true, "-stdin": true, "-hide_banner": true,
+	"-re": true, "-shortest": true, "-an": true, "-vn": true,
+	"-sn": true, "-dn": true, "-stats": true, "-copyts": true,
+	"-start_at_zero": true, "-xerror": true, "-benchmark": true,
+	"-benchmark_all": true, "-ignore_unknown": true,
+	"-copy_unknown": true, "-recast_media": true, "-dump": true,
+	"-hex": true, "-accurate_seek": true, "-autorotate": true,
+	"-autoscale": true, "-bitexact": true, "-vstats": true,
+	"-debug_ts": true, "-psnr": true, "-qphist": true,
+	"-fix_sub_duration": true, "-fix_sub_duration_heartbeat": true,
+	"-find_stream_info": true, "-version": true,
+	"-buildconf": true, "-formats": true, "-muxers": true,
+	"-demuxers": true, "-devices": true, "-codecs": true,
+	"-decoders": true, "-encoders": true, "-bsfs": true,
+	"-protocols": true, "-filters": true, "-pix_fmts": true,
+	"-layouts": true, "-sample_fmts": true, "-dispositions": true,
+	"-colors": true, "-hwaccels": true, "-L": true,
+}
+
+// takesValue reports whether the option flag takes a value,
+// which is assumed of the options not in noValue.
+func takesValue(flag string) bool {
+	if noValue[flag] {
+		return false
+	}
+	return !(strings.HasPrefix(flag, "-no") && noValue["-"+flag[3:]])
+}
+
+// valueFlags lists the usual options whose values are not
+// checked by a Policy, see Policy.Options.
+var valueFlags = flagSet(`
+		-f -c -codec -vcodec -acodec -scodec -dcodec -map
+		-map_metadata -map_chapters -t -to -fs -ss -sseof
+		-itsoffset -itsscale -timestamp -metadata -program
+		-target -frames -vframes -aframes -dframes -threads
+		-filter_threads -filter_complex_threads -loglevel -v -r
+		-fpsmax -s -aspect -pix_fmt -b -ab -vb -q -qscale
+		-profile -level -tag -vtag -atag -stag -aq -ar -ac
+		-sample_fmt -channel_layout -ch_layout -disposition -bsf
+		-pass -vsync -fps_mode -async -max_muxing_queue_size
+		-muxdelay -muxpreload -hwaccel -hwaccel_device
+		-hwaccel_output_format -init_hw_device -filter_hw_device
+		-readrate -stream_loop -thread_queue_size -discard
+		-maxrate -minrate -bufsize -g -bf -crf -cq -qp -preset
+		-tune -movflags -flags -fflags -x264-params -x265-params
+		-hls_time -hls_list_size -hls_playlist_type -hls_flags
+		-segment_time -segment_format -start_number
+		-output_ts_offset -avoid_negative_ts -rtsp_transport
+		-timeout -stimeout -rw_timeout -user_agent
+		-analyzeduration -probesize -color_primaries -color_trc
+		-colorspace -color_range -sc_threshold -keyint_min
+		-force_key_frames -strict -max_delay -rc_lookahead
+		-reconnect -reconnect_streamed -reconnect_delay_max
+		-alpha_mode -pixel_format -video_size -framerate
+		-sample_rate -channels -max_interleave_delta -headers
+		-cookies -decryption_key -encryption_key -seekable
+`)
+
+// flagSet returns the set of the space separated flags.
+func flagSet(flags string) map[string]bool {
+	set := make(map[string]bool)
+	for _, f := range strings.Fields(flags) {
+		set[f] = true
+	}
+	return set
+}
+
+var filterFlags = map[string]bool{
+	"-vf": true, "-af": true, "-filter": true, "-filter_complex": true, "-lavfi": true,
+}
+
+// schemeRe matches the protocol of an URL, whose options may
+// follow it before the ":" as FFmpeg reads them, e.g.
+// "subfile,,start,0,end,0,,:in.mp4".
+var schemeRe = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]+)(:|,.*:)`)
+
+// Validate checks args against p. It returns a *PolicyError for
+// the first arg not allowed.
+func (p *Policy) Validate(args []string) error {
+	var err error
+	lavfi := false // the next input is a filter graph
+	walkArgs(args, func(flag string, i int) bool {
+		a := args[i]
+		if flag == "" {
+			// an output
+			lavfi = false
+			err = p.checkTarget(a)
+			return err == nil
+		}
+		for _, f := range p.Flags {
+			if flag == f || f == "-/" && strings.HasPrefix(flag, "-/") {
+				err = &PolicyError{Arg: a, Reason: "forbidden option"}
+				return false
+			}
+		}
+		if !takesValue(flag) || i+1 == len(args) {
+			return true
+		}
+		v := args[i+1]
+		switch {
+		case flag == "-i" && lavfi:
+			lavfi = false
+			err = p.checkFilters(v)
+		case flag == "-i":
+			err = p.checkTarget(v)
+		case filterFlags[flag]:
+			err = p.checkFilters(v)
+		case flag == "-f":
+			lavfi = v == "lavfi"
+		case !p.known(flag):
+			err = p.checkValue(a, v)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// known reports whether the values of the option flag are not
+// files.
+func (p *Policy) known(flag string) bool {
+	if valueFlags[flag] {
+		return true
+	}
+	for _, o := range p.Options {
+		if o == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValue checks the value v of the unknown option a, which
+// may name a file, or be an option if a takes no value.
+func (p *Policy) checkValue(a, v string) error {
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return nil
+	}
+	if strings.HasPrefix(v, "-") && v != "-" {
+		return &PolicyError{Arg: a, Reason: "unknown option"}
+	}
+	if err := p.checkTarget(v); err != nil {
+		return &PolicyError{Arg: a + " " + v, Reason: "unknown option, " + err.(*PolicyError).Reason}
+	}
+	return nil
+}
+
+// walkArgs calls f for every option of args with its flag
+// stripped of any stream specifier and its index, and for
+// every output with an empty flag, until f returns false.
+// The values of the options are skipped.
+func walkArgs(args []string, f func(flag string, i int) bool) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			if !f("", i) {
+				return
+			}
+			continue
+		}
+
+		flag := a
+		if j := strings.IndexByte(flag, ':'); j > 0 {
+			flag = flag[:j]
+		}
+		if !f(flag, i) {
+			return
+		}
+		if takesValue(flag) {
+			i++
+		}
+	}
+}
+
+// outputs returns the indexes of the outputs in args.
+func outputs(args []string) []int {
+	var is []int
+	walkArgs(args, func(flag string, i int) bool {
+		if flag == "" {
+			is = append(is, i)
+		}
+		return true
+	})
+	return is
+}
+
+func (p *Policy) checkTarget(t string) error {
+	proto, path := "file", t
+	if t == "-" {
+		proto = "pipe"
+	} else if m := schemeRe.FindStringSubmatch(t); m != nil {
+		proto = strings.ToLower(m[1])
+		path = strings.TrimPrefix(t[len(m[0]):], "//")
+	}
+
+	if len(p.Protocols) > 0 {
+		allowed := false
+		for _, a := range p.Protocols {
+			allowed = allowed || a == proto
+		}
+		if !allowed {
+			return &PolicyError{Arg: t, Reason: "protocol " + proto + " not allowed"}
+		}
+	}
+
+	if proto == "file" && p.Root != "" && !within(p.Root, path) {
+		return &PolicyError{Arg: t, Reason: "outside of " + p.Root}
+	}
+	return nil
+}
+
+func (p *Policy) checkFilters(graph string) error {
+	for _, name := range filterNames(graph) {
+		for _, f := range p.Filters {
+			if name == f {
+				return &PolicyError{Arg: graph, Reason: "filter " + f + " not allowed"}
+			}
+		}
+	}
+	return nil
+}
+
+// filterNames returns the names of the filters of graph, read
+// as FFmpeg parses them: unquoted, unescaped and without their
+// instance names (@name).
+func filterNames(graph string) []string {
+	var names []string
+	s := graph
+	for {
+		s = skipLabels(s)
+		name, rest := filterToken(s, "=,;[")
+		if i := strings.IndexByte(name, '@'); i >= 0 {
+			name = name[:i]
+		}
+		names = append(names, name)
+		if s = rest; strings.HasPrefix(s, "=") {
+			_, s = filterToken(s[1:], "[],;")
+		}
+		if s = skipLabels(s); s == "" {
+			return names
+		}
+		s = s[1:] // , or ;
+	}
+}
+
+// skipLabels skips the link labels, e.g. "[0:v]", at the start
+// of s.
+func skipLabels(s string) string {
+	for s = strings.TrimLeft(s, filterSpaces); strings.HasPrefix(s, "["); s = strings.TrimLeft(s, filterSpaces) {
+		_, s = filterToken(s[1:], "]")
+		s = strings.TrimPrefix(s, "]")
+	}
+	return s
+}
+
+const filterSpaces = " \n\t\r"
+
+// filterToken returns the token at the start of s up to a byte
+// of term, and the rest of s, like av_get_token: the quotes (')
+// and escapes (\) are removed, and the spaces around trimmed.
+func filterToken(s, term string) (token, rest string) {
+	s = strings.TrimLeft(s, filterSpaces)
+	var b []byte
+	end := 0 // of the token without its trailing spaces
+	i := 0
+	for i < len(s) && strings.IndexByte(term, s[i]) < 0 {
+		switch c := s[i]; {
+		case c == '\\':
+			if i++; i < len(s) {
+				b = append(b, s[i])
+				i++
+			}
+			end = len(b)
+		case c == '\'':
+			for i++; i < len(s) && s[i] != '\''; i++ {
+				b = append(b, s[i])
+			}
+			i++
+			end = len(b)
+		default:
+			b = append(b, c)
+			i++
+			if strings.IndexByte(filterSpaces, c) < 0 {
+				end = len(b)
+			}
+		}
+	}
+	return string(b[:end]), s[min(i, len(s)):]
+}
+
+// within reports whether path is in the directory root, once
+// both are absolute and their existing symlinks resolved.
+func within(root, path string) bool {
+	r, err := resolve(root)
+	if err != nil {
+		return false
+	}
+	p, err := resolve(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(r, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolve returns the absolute path of p with the symlinks of
+// its longest existing prefix resolved.
+func resolve(p string) (string, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	rest := ""
+	for {
+		if r, err := filepath.EvalSymlinks(p); err == nil {
+			return filepath.Join(r, rest), nil
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(p, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		p = parent
+	}
+}
+
+// WithPolicy makes the runner validate the args given to Run
+// against p, before any option such as HWAccel rewrites them,
+// and refuse to run them with a *PolicyError.
+func WithPolicy(p *Policy) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.policy = p
+	}
+}
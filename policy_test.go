@@ -0,0 +1,82 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestPolicy(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "in"), 0755)
+	os.Symlink("/etc", filepath.Join(root, "in", "etc"))
+
+	p := &ffmpeg.Policy{
+		Protocols: []string{"file", "pipe", "rtsp"},
+		Flags:     ffmpeg.ForbiddenFlags,
+		Filters:   ffmpeg.ForbiddenFilters,
+		Root:      root,
+		Options:   []string{"-x264opts"},
+	}
+
+	ok := []string{
+		"-y -i " + root + "/in/a.mp4 -c:v libx264 " + root + "/out/a.mp4",
+		"-i rtsp://cam/1 -f mpegts -",
+		"-i file:" + root + "/in/a.mp4 -vf scale=640:-2,format=yuv420p pipe:1",
+		"-i " + root + "/in/a.mp4 -filter_complex [0:v]split[a][b] -map [a] " + root + "/a.mp4",
+		"-i " + root + "/in/a.mp4 -psnr -noautoscale -fix_sub_duration " + root + "/a.mp4",
+		"-i " + root + "/in/a,b.mp4 -vf drawtext=text='movie,amovie' " + root + "/a.mp4",
+		"-f lavfi -i testsrc=d=1 -x264opts keyint=25 -hls_segment_filename " + root + "/s%d.ts " + root + "/a.m3u8",
+	}
+	for _, a := range ok {
+		if err := p.Validate(strings.Fields(a)); err != nil {
+			t.Errorf("%s: %v", a, err)
+		}
+	}
+
+	bad := []string{
+		"-i /etc/passwd " + root + "/out.mp4",
+		"-i " + root + "/../x.mp4 " + root + "/out.mp4",
+		"-i " + root + "/in/etc/passwd " + root + "/out.mp4",
+		"-i http://example.com/a.m3u8 " + root + "/out.mp4",
+		"-i concat:/etc/passwd " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 -filter_script:v /etc/x " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 -/vf /etc/x " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 -vf movie=/etc/x[m];[in][m]overlay " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 /tmp/out.mp4",
+		"-i " + root + "/a.mp4 -psnr /etc/passwd",
+		"-i " + root + "/a.mp4 -noautoscale /etc/passwd",
+		"-i " + root + "/a.mp4 -unknown_bool -vf movie=/etc/x " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 -hls_segment_filename /tmp/s%d.ts " + root + "/a.m3u8",
+		"-f lavfi -i movie=/etc/x " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 -vf 'movie'=/etc/passwd " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 -vf mov\\ie=/etc/passwd " + root + "/out.mp4",
+		"-i " + root + "/a.mp4 -vf [in]scale=w='1,2',movie@m=/etc/x[out] " + root + "/out.mp4",
+		"-i subfile,,start,0,end,0,,:/etc/passwd " + root + "/out.mp4",
+	}
+	for _, a := range bad {
+		var pe *ffmpeg.PolicyError
+		if err := p.Validate(strings.Fields(a)); !errors.As(err, &pe) {
+			t.Errorf("%s: want PolicyError, got %v", a, err)
+		}
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	p, _ := argsBinary(t)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.WithPolicy(&ffmpeg.Policy{
+		Protocols: []string{"file"},
+	}))
+	var pe *ffmpeg.PolicyError
+	if err := r.Run(context.TODO(), "-i rtmp://host/app out.mp4"); !errors.As(err, &pe) {
+		t.Errorf("want PolicyError, got %v", err)
+	}
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Error(err)
+	}
+}
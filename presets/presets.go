@@ -0,0 +1,123 @@
+/*
+Package presets provides vetted encode profiles, applied to the
+outputs of a spec.Job:
+
+	out := spec.Output{Path: "out.mp4", VideoBitrate: "4M"}
+	presets.H264Web1080p.Apply(&out)
+
+The fields set on the output are kept, overriding the preset.
+*/
+package presets
+
+import "github.com/practigo/ffmpeg/spec"
+
+// A Preset is an encode profile.
+type Preset struct {
+	Name   string
+	Output spec.Output // the Path and Map are not applied
+}
+
+// Apply sets the fields of o left empty to those of p. The
+// Options are merged, those of o winning.
+func (p Preset) Apply(o *spec.Output) {
+	set := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	set(&o.Format, p.Output.Format)
+	set(&o.VideoCodec, p.Output.VideoCodec)
+	set(&o.VideoBitrate, p.Output.VideoBitrate)
+	set(&o.AudioCodec, p.Output.AudioCodec)
+	set(&o.AudioBitrate, p.Output.AudioBitrate)
+	if o.VideoFilters == nil {
+		o.VideoFilters = append([]string(nil), p.Output.VideoFilters...)
+	}
+	if o.AudioFilters == nil {
+		o.AudioFilters = append([]string(nil), p.Output.AudioFilters...)
+	}
+	if o.HLS == nil && p.Output.HLS != nil {
+		h := *p.Output.HLS
+		o.HLS = &h
+	}
+	if len(p.Output.Options) > 0 {
+		opts := make(map[string]string, len(p.Output.Options)+len(o.Options))
+		for k, v := range p.Output.Options {
+			opts[k] = v
+		}
+		for k, v := range o.Options {
+			opts[k] = v
+		}
+		o.Options = opts
+	}
+}
+
+// Compose returns a Preset applying ps in order, the fields of
+// the later ones overriding those of the earlier ones.
+func Compose(name string, ps ...Preset) Preset {
+	var o spec.Output
+	for i := len(ps) - 1; i >= 0; i-- {
+		ps[i].Apply(&o)
+	}
+	return Preset{Name: name, Output: o}
+}
+
+// The web presets encode H.264/AAC MP4 playable everywhere,
+// with the moov atom first to start playing before the end of
+// the download, and a capped bitrate for streaming.
+var (
+	H264Web1080p = Preset{Name: "h264-web-1080p", Output: spec.Output{
+		VideoCodec:   "libx264",
+		VideoFilters: []string{"scale=-2:1080"},
+		AudioCodec:   "aac",
+		AudioBitrate: "128k",
+		Options: map[string]string{
+			"preset": "medium", "crf": "21", "profile:v": "high", "pix_fmt": "yuv420p",
+			"maxrate": "6M", "bufsize": "12M", "ac": "2", "movflags": "+faststart",
+		},
+	}}
+	H264Web720p = Preset{Name: "h264-web-720p", Output: spec.Output{
+		VideoCodec:   "libx264",
+		VideoFilters: []string{"scale=-2:720"},
+		AudioCodec:   "aac",
+		AudioBitrate: "128k",
+		Options: map[string]string{
+			"preset": "medium", "crf": "22", "profile:v": "high", "pix_fmt": "yuv420p",
+			"maxrate": "3M", "bufsize": "6M", "ac": "2", "movflags": "+faststart",
+		},
+	}}
+)
+
+// HEVCArchive keeps a high quality 10-bit HEVC master, tagged
+// hvc1 for Apple players.
+var HEVCArchive = Preset{Name: "hevc-archive", Output: spec.Output{
+	VideoCodec:   "libx265",
+	AudioCodec:   "aac",
+	AudioBitrate: "192k",
+	Options: map[string]string{
+		"preset": "slow", "crf": "20", "pix_fmt": "yuv420p10le", "tag:v": "hvc1",
+		"movflags": "+faststart",
+	},
+}}
+
+// AACAudioOnly drops the video for a stereo AAC M4A.
+var AACAudioOnly = Preset{Name: "aac-audio-only", Output: spec.Output{
+	AudioCodec:   "aac",
+	AudioBitrate: "160k",
+	Options:      map[string]string{"vn": "", "ac": "2", "movflags": "+faststart"},
+}}
+
+// ProResProxy encodes the ProRes 422 Proxy editing proxies of
+// a MOV, with PCM audio.
+var ProResProxy = Preset{Name: "prores-proxy", Output: spec.Output{
+	Format:     "mov",
+	VideoCodec: "prores_ks",
+	AudioCodec: "pcm_s16le",
+	Options:    map[string]string{"profile:v": "0", "vendor": "apl0", "pix_fmt": "yuv422p10le"},
+}}
+
+// SocialSquare center crops to a 1080x1080 square for social
+// media feeds, on top of H264Web1080p.
+var SocialSquare = Compose("social-square", H264Web1080p, Preset{Output: spec.Output{
+	VideoFilters: []string{`crop=min(iw\,ih):min(iw\,ih)`, "scale=1080:1080", "setsar=1"},
+}})
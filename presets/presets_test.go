@@ -0,0 +1,44 @@
+package presets_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg/presets"
+	"github.com/practigo/ffmpeg/spec"
+)
+
+func TestApply(t *testing.T) {
+	out := spec.Output{Path: "out.mp4", Options: map[string]string{"crf": "18"}}
+	presets.H264Web720p.Apply(&out)
+	j := &spec.Job{Inputs: []spec.Input{{Path: "in.mov"}}, Outputs: []spec.Output{out}}
+	arg, err := j.Arg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-i in.mov -c:v libx264 -vf scale=-2:720 -c:a aac -b:a 128k " +
+		"-ac 2 -bufsize 6M -crf 18 -maxrate 3M -movflags +faststart -pix_fmt yuv420p -preset medium -profile:v high out.mp4"
+	if arg != want {
+		t.Errorf("got  %s\nwant %s", arg, want)
+	}
+	if presets.H264Web720p.Output.Options["crf"] != "22" {
+		t.Error("the preset was modified")
+	}
+}
+
+func TestCompose(t *testing.T) {
+	var out spec.Output
+	presets.SocialSquare.Apply(&out)
+	if out.VideoCodec != "libx264" || !strings.HasPrefix(strings.Join(out.VideoFilters, ","), "crop=") {
+		t.Errorf("unexpected output %+v", out)
+	}
+
+	for _, p := range []presets.Preset{presets.H264Web1080p, presets.HEVCArchive, presets.AACAudioOnly, presets.ProResProxy} {
+		out := spec.Output{Path: "out"}
+		p.Apply(&out)
+		j := &spec.Job{Inputs: []spec.Input{{Path: "in.mov"}}, Outputs: []spec.Output{out}}
+		if _, err := j.Arg(); err != nil {
+			t.Errorf("%s: %v", p.Name, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// ProbeInfo describes a media file as reported by ffprobe.
+type ProbeInfo struct {
+	Format  ProbeFormat   `json:"format"`
+	Streams []ProbeStream `json:"streams"`
+}
+
+// ProbeFormat describes the container of a media file.
+type ProbeFormat struct {
+	Filename   string            `json:"filename"`
+	FormatName string            `json:"format_name"` // e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration   Seconds           `json:"duration"`
+	StartTime  Seconds           `json:"start_time"`
+	Size       int64             `json:"size,string"`
+	BitRate    int64             `json:"bit_rate,string"`
+	Tags       map[string]string `json:"tags"`
+}
+
+// ProbeStream describes a stream of a media file.
+type ProbeStream struct {
+	Index          int               `json:"index"`
+	CodecType      string            `json:"codec_type"` // "video", "audio", "subtitle", "data"
+	CodecName      string            `json:"codec_name"`
+	Profile        string            `json:"profile"`
+	Width          int               `json:"width"`
+	Height         int               `json:"height"`
+	PixFmt         string            `json:"pix_fmt"`
+	ColorRange     string            `json:"color_range"`     // e.g. "tv"
+	ColorSpace     string            `json:"color_space"`     // e.g. "bt2020nc"
+	ColorTransfer  string            `json:"color_transfer"`  // e.g. "smpte2084"
+	ColorPrimaries string            `json:"color_primaries"` // e.g. "bt2020"
+	FrameRate      string            `json:"avg_frame_rate"`  // e.g. "30000/1001"
+	SampleRate     int               `json:"sample_rate,string"`
+	Channels       int               `json:"channels"`
+	ChannelLayout  string            `json:"channel_layout"`
+	Duration       Seconds           `json:"duration"`
+	StartTime      Seconds           `json:"start_time"`
+	BitRate        int64             `json:"bit_rate,string"`
+	Tags           map[string]string `json:"tags"`
+	Disposition    map[string]int    `json:"disposition"` // e.g. "default": 1
+	SideData       []SideData        `json:"side_data_list"`
+}
+
+// Seconds is a duration reported by ffprobe in seconds, as
+// a JSON string.
+type Seconds time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler, "N/A" being 0.
+func (s *Seconds) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		*s = 0
+		return nil
+	}
+	*s = Seconds(f * float64(time.Second))
+	return nil
+}
+
+// Duration returns s as a time.Duration.
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s)
+}
+
+// Probe runs the ffprobe binary at path (as found by
+// exec.LookPath) on input and parses its report of the
+// format and streams.
+func Probe(ctx context.Context, path, input string) (*ProbeInfo, error) {
+	b, err := output(ctx, path, "-v error -print_format json -show_format -show_streams "+input)
+	if err != nil {
+		return nil, err
+	}
+	info := &ProbeInfo{}
+	if err = json.Unmarshal(b, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// StreamsOf returns the streams of type codecType, e.g. "video".
+func (p *ProbeInfo) StreamsOf(codecType string) []ProbeStream {
+	var ss []ProbeStream
+	for _, s := range p.Streams {
+		if s.CodecType == codecType {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
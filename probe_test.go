@@ -0,0 +1,52 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// fakeProbe reports a 10s 1280x720 h264/aac MP4 for inputs
+// named *.mp4, a 9s one for short.mp4, and fails otherwise.
+const fakeProbe = `for in; do :; done
+case "$in" in
+*short.mp4) d=9.000000;;
+*.mp4) d=10.000000;;
+*) echo "$in: Invalid data found when processing input" >&2; exit 1;;
+esac
+cat <<EOF
+{
+    "streams": [
+        {"index": 0, "codec_name": "h264", "profile": "High", "codec_type": "video", "width": 1280, "height": 720,
+         "pix_fmt": "yuv420p", "avg_frame_rate": "25/1", "start_time": "0.000000", "duration": "$d", "bit_rate": "2000000"},
+        {"index": 1, "codec_name": "aac", "codec_type": "audio", "sample_rate": "48000", "channels": 2,
+         "channel_layout": "stereo", "start_time": "0.000000", "duration": "$d", "tags": {"language": "eng"}}
+    ],
+    "format": {"filename": "$in", "format_name": "mov,mp4,m4a,3gp,3g2,mj2", "start_time": "0.000000",
+               "duration": "$d", "size": "2621440", "bit_rate": "2097152", "tags": {"encoder": "Lavf60.3.100"}}
+}
+EOF
+`
+
+func TestProbe(t *testing.T) {
+	info, err := ffmpeg.Probe(context.TODO(), fakeBinary(t, fakeProbe), "in.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format.Duration.Duration() != 10*time.Second || info.Format.Size != 2621440 || info.Format.Tags["encoder"] == "" {
+		t.Errorf("unexpected format %+v", info.Format)
+	}
+	v, a := info.StreamsOf("video"), info.StreamsOf("audio")
+	if len(v) != 1 || v[0].Width != 1280 || v[0].CodecName != "h264" {
+		t.Errorf("unexpected video streams %+v", v)
+	}
+	if len(a) != 1 || a[0].SampleRate != 48000 || a[0].Channels != 2 || a[0].Tags["language"] != "eng" {
+		t.Errorf("unexpected audio streams %+v", a)
+	}
+
+	if _, err = ffmpeg.Probe(context.TODO(), fakeBinary(t, fakeProbe), "in.txt"); err == nil {
+		t.Error("want error")
+	}
+}
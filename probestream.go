@@ -0,0 +1,134 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// A ProbePacket is a packet of a media file as reported by
+// ffprobe -show_packets.
+type ProbePacket struct {
+	StreamIndex int     `json:"stream_index"`
+	CodecType   string  `json:"codec_type"`
+	PTS         Seconds `json:"pts_time"`
+	DTS         Seconds `json:"dts_time"`
+	Duration    Seconds `json:"duration_time"`
+	Size        int64   `json:"size,string"`
+	Flags       string  `json:"flags"` // "K" for keyframes, e.g. "K__"
+}
+
+// A ProbeFrame is a decoded frame of a media file as reported
+// by ffprobe -show_frames.
+type ProbeFrame struct {
+	StreamIndex int     `json:"stream_index"`
+	MediaType   string  `json:"media_type"`
+	KeyFrame    int     `json:"key_frame"`
+	PTS         Seconds `json:"pts_time"`
+	Duration    Seconds `json:"duration_time"`
+	PacketSize  int64   `json:"pkt_size,string"`
+	PictType    string  `json:"pict_type"` // "I", "P" or "B"
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	NbSamples   int     `json:"nb_samples"`
+
+	SideData []SideData `json:"side_data_list"`
+}
+
+// ProbePackets calls f with every packet of the streams of input
+// matching the stream specifier streams (all of them if empty,
+// e.g. "v:0"), as ffprobe reports them, without buffering the
+// report. It stops at the first error of f, and returns it.
+func ProbePackets(ctx context.Context, probe, input, streams string, f func(p ProbePacket) error) error {
+	return probeEntries(ctx, probe, input, streams, "packets", func(dec *json.Decoder) error {
+		var p ProbePacket
+		if err := dec.Decode(&p); err != nil {
+			return err
+		}
+		return f(p)
+	})
+}
+
+// ProbeFrames calls f with every frame of the streams of input,
+// as ProbePackets does. The frames are decoded, which is much
+// slower than reading the packets.
+func ProbeFrames(ctx context.Context, probe, input, streams string, f func(fr ProbeFrame) error) error {
+	return probeEntries(ctx, probe, input, streams, "frames", func(dec *json.Decoder) error {
+		var fr ProbeFrame
+		if err := dec.Decode(&fr); err != nil {
+			return err
+		}
+		return f(fr)
+	})
+}
+
+// probeEntries runs ffprobe -show_<section> and calls next for
+// every entry of the section array.
+func probeEntries(ctx context.Context, probe, input, streams, section string, next func(dec *json.Decoder) error) error {
+	args := []string{"-v error -print_format json -show_" + section}
+	if streams != "" {
+		args = append(args, "-select_streams", streams)
+	}
+	arg := strings.Join(append(args, input), " ")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := HookRunner(CustomPath(probe), withSetup(func(cmd *exec.Cmd) (func(), error) {
+			cmd.Stdout = pw
+			return func() {}, nil
+		})).Run(ctx, arg)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	err := decodeSection(json.NewDecoder(pr), section, next)
+	if err != nil {
+		cancel()
+	}
+	pr.Close()
+	if runErr := <-done; err == nil || (runErr != nil && ctx.Err() == nil) {
+		err = runErr
+	}
+	return err
+}
+
+// decodeSection calls next for every entry of the array of the
+// key section of a JSON object.
+func decodeSection(dec *json.Decoder, section string, next func(dec *json.Decoder) error) error {
+	if t, err := dec.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return fmt.Errorf("ffmpeg: unexpected ffprobe output %v", t)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key != section {
+			var skip json.RawMessage
+			if err = dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err = dec.Token(); err != nil { // [
+			return err
+		}
+		for dec.More() {
+			if err = next(dec); err != nil {
+				return err
+			}
+		}
+		if _, err = dec.Token(); err != nil { // ]
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+const fakeEntries = `case "$*" in
+*-show_packets*) cat <<JSON
+{
+    "packets": [
+        {"codec_type": "video", "stream_index": 0, "pts_time": "0.000000", "dts_time": "0.000000", "duration_time": "0.040000", "size": "24576", "flags": "K__"},
+        {"codec_type": "video", "stream_index": 0, "pts_time": "0.040000", "dts_time": "0.040000", "duration_time": "0.040000", "size": "1024", "flags": "___"}
+    ]
+}
+JSON
+;;
+*-show_frames*) cat <<JSON
+{
+    "frames": [
+        {"media_type": "video", "stream_index": 0, "key_frame": 1, "pts_time": "0.000000", "pkt_size": "24576", "pict_type": "I", "width": 1280, "height": 720},
+        {"media_type": "video", "stream_index": 0, "key_frame": 0, "pts_time": "0.040000", "pkt_size": "1024", "pict_type": "B", "width": 1280, "height": 720}
+    ]
+}
+JSON
+;;
+esac
+`
+
+func TestProbePackets(t *testing.T) {
+	p := fakeBinary(t, fakeEntries)
+	var ps []ffmpeg.ProbePacket
+	err := ffmpeg.ProbePackets(context.TODO(), p, "in.mp4", "v:0", func(pk ffmpeg.ProbePacket) error {
+		ps = append(ps, pk)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 2 || ps[0].Flags != "K__" || ps[0].Size != 24576 || ps[1].PTS.Duration() != 40*time.Millisecond {
+		t.Errorf("unexpected packets %+v", ps)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = ffmpeg.ProbePackets(context.TODO(), p, "in.mp4", "", func(ffmpeg.ProbePacket) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("want the error of f after 1 packet, got %v after %d", err, n)
+	}
+}
+
+func TestProbeFrames(t *testing.T) {
+	var fs []ffmpeg.ProbeFrame
+	err := ffmpeg.ProbeFrames(context.TODO(), fakeBinary(t, fakeEntries), "in.mp4", "v", func(fr ffmpeg.ProbeFrame) error {
+		fs = append(fs, fr)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 2 || fs[0].PictType != "I" || fs[0].KeyFrame != 1 || fs[1].PacketSize != 1024 {
+		t.Errorf("unexpected frames %+v", fs)
+	}
+
+	if err = ffmpeg.ProbeFrames(context.TODO(), fakeBinary(t, "exit 1\n"), "in.mp4", "", func(ffmpeg.ProbeFrame) error { return nil }); err == nil {
+		t.Error("want error")
+	}
+}
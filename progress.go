@@ -0,0 +1,140 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProgressPeriod sets how often FFmpeg reports its progress,
+// with -stats_period. Binaries older than 4.4, or whose version
+// cannot be detected, keep their default period of 0.5s.
+func ProgressPeriod(d time.Duration) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.statsPeriod = d
+	}
+}
+
+// ProgressSocket has FFmpeg report its progress with -progress
+// to a listener of the runner, on network "unix" or "tcp" (on
+// the loopback), instead of the stats of stderr (-nostats). It
+// keeps the progress events when stdout and stderr are already
+// used, e.g. for media data. It is not meaningful for runners
+// of another host, such as a DockerRunner.
+func ProgressSocket(network string) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.progressNet = network
+	}
+}
+
+// progressVar is replaced by the URL of the listener.
+const progressVar = "{progress}"
+
+// progressArgs returns the global args of the progress options
+// for the binary at path.
+func (r *HookedRunner) progressArgs(ctx context.Context, path string) []string {
+	if r.tool != FFmpeg {
+		return nil
+	}
+	var args []string
+	if r.statsPeriod > 0 {
+		if v, err := r.version(ctx, path); err == nil && v.Supports(StatsPeriod) {
+			args = append(args, "-stats_period", seconds(r.statsPeriod))
+		}
+	}
+	if r.progressNet != "" {
+		args = append(args, "-progress", progressVar, "-nostats")
+	}
+	return args
+}
+
+// listenProgress starts the listener of ProgressSocket for cmd.
+// The returned function must be called once cmd has exited; it
+// returns after the last progress was handled.
+func (r *HookedRunner) listenProgress(cmd *exec.Cmd, em *emitter) (func(), error) {
+	if r.progressNet == "" || r.tool != FFmpeg {
+		return func() {}, nil
+	}
+
+	var l net.Listener
+	var url, dir string
+	var err error
+	switch r.progressNet {
+	case "unix":
+		if dir, err = os.MkdirTemp("", "ffmpeg-progress-"); err != nil {
+			return nil, err
+		}
+		sock := filepath.Join(dir, "sock")
+		if l, err = net.Listen("unix", sock); err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		url = "unix:" + sock
+	default:
+		if l, err = net.Listen(r.progressNet, "127.0.0.1:0"); err != nil {
+			return nil, err
+		}
+		url = "tcp://" + l.Addr().String()
+	}
+	for i, a := range cmd.Args {
+		if a == progressVar {
+			cmd.Args[i] = url
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		readProgress(conn, em)
+	}()
+	return func() {
+		l.Close()
+		<-done
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	}, nil
+}
+
+// readProgress emits the blocks of -progress, which end with a
+// "progress=continue" or "progress=end" line.
+func readProgress(conn net.Conn, em *emitter) {
+	var p Progress
+	s := bufio.NewScanner(conn)
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), "=")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch k {
+		case "frame":
+			p.Frame, _ = strconv.ParseInt(v, 10, 64)
+		case "fps":
+			p.FPS, _ = strconv.ParseFloat(v, 64)
+		case "total_size":
+			p.Size, _ = strconv.ParseInt(v, 10, 64)
+		case "out_time_us":
+			us, _ := strconv.ParseInt(v, 10, 64)
+			p.Time = time.Duration(us) * time.Microsecond
+		case "bitrate":
+			p.Bitrate, _ = strconv.ParseFloat(strings.TrimSuffix(v, "kbits/s"), 64)
+		case "speed":
+			p.Speed, _ = strconv.ParseFloat(strings.TrimSuffix(v, "x"), 64)
+		case "progress":
+			em.progress(p)
+			p = Progress{}
+		}
+	}
+}
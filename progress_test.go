@@ -0,0 +1,77 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestProgressPeriod(t *testing.T) {
+	args := filepath.Join(t.TempDir(), "args")
+	p := fakeBinary(t, `[ "$1" = -version ] && echo "ffmpeg version 6.0 Copyright (c) 2000-2023" && exit
+echo "$@" > `+args+"\n")
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.ProgressPeriod(100*time.Millisecond))
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(args); strings.TrimSpace(string(b)) != "-stats_period 0.1 -i in.mp4 out.mp4" {
+		t.Errorf("unexpected args %q", b)
+	}
+
+	// no version, no -stats_period
+	p, last := argsBinary(t)
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.ProgressPeriod(100*time.Millisecond))
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if last() != "-i in.mp4 out.mp4" {
+		t.Errorf("unexpected args %q", last())
+	}
+}
+
+func TestProgressSocket(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("no bash to connect to the listener")
+	}
+	p := fakeBinary(t, `exec bash -c '
+url=$2; port=${url##*:}
+exec 3<>/dev/tcp/127.0.0.1/$port
+printf "frame=123\nfps=25.00\nbitrate= 512.3kbits/s\ntotal_size=262144\nout_time_us=4920000\nspeed=1.01x\nprogress=continue\n" >&3
+printf "frame=250\ntotal_size=524288\nout_time_us=10000000\nspeed=1.02x\nprogress=end\n" >&3
+' -- "$@"
+`)
+	var ps []ffmpeg.Progress
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.ProgressSocket("tcp"), ffmpeg.Subscribe(func(e ffmpeg.Event) {
+		if p, ok := e.(ffmpeg.Progress); ok {
+			ps = append(ps, p)
+		}
+	}))
+	if err := r.Run(context.TODO(), "-i in.mp4 -f mpegts -"); err != nil {
+		t.Fatal(err)
+	}
+	want := ffmpeg.Progress{Frame: 123, FPS: 25, Size: 262144, Time: 4920 * time.Millisecond, Bitrate: 512.3, Speed: 1.01}
+	if len(ps) != 2 || ps[0] != want || ps[1].Time != 10*time.Second {
+		t.Errorf("unexpected progress %+v", ps)
+	}
+}
+
+func TestProgressSocketUnix(t *testing.T) {
+	p, last := argsBinary(t)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.ProgressSocket("unix"))
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	fs := strings.Fields(last())
+	if len(fs) < 3 || fs[0] != "-progress" || !strings.HasPrefix(fs[1], "unix:") || fs[2] != "-nostats" {
+		t.Fatalf("unexpected args %q", last())
+	}
+	if _, err := os.Stat(filepath.Dir(strings.TrimPrefix(fs[1], "unix:"))); !os.IsNotExist(err) {
+		t.Errorf("socket dir not removed: %v", err)
+	}
+}
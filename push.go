@@ -0,0 +1,140 @@
+package ffmpeg
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A Push relays an input, a file or a live source, to RTMP and
+// SRT endpoints, through the tee muxer for several of them so
+// that the input is read and encoded once.
+type Push struct {
+	Input        string
+	Destinations []string // rtmp://, rtmps:// or srt:// URLs
+
+	// Args are the encoding args, by default "-c copy" unless
+	// Probe is set and the input is not H.264/AAC, which FLV
+	// and the players expect.
+	Args  string
+	Probe string // the path of ffprobe
+}
+
+// A PushError reports the destinations a Push failed to relay
+// to, the others having been relayed to the end unless Err is
+// set.
+type PushError struct {
+	Failed map[string]string // the FFmpeg error by destination
+	Err    error             // of the run
+}
+
+func (e *PushError) Error() string {
+	dests := make([]string, 0, len(e.Failed))
+	for d := range e.Failed {
+		dests = append(dests, d)
+	}
+	sort.Strings(dests)
+	msg := "ffmpeg: push failed"
+	for _, d := range dests {
+		msg += "; " + d + ": " + e.Failed[d]
+	}
+	if e.Err != nil {
+		msg += "; " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *PushError) Unwrap() error {
+	return e.Err
+}
+
+// Arg returns the arg relaying the first video and audio
+// streams of the input, the only ones FLV holds, with args.
+// Inputs without a scheme are files, read at their native rate
+// (-re).
+func (p *Push) Arg(args string) string {
+	in := []string{"-nostdin"}
+	if !strings.Contains(p.Input, "://") {
+		in = append(in, "-re")
+	}
+	in = append(in, "-i", p.Input, "-map 0:v:0? -map 0:a:0?", args)
+	if len(p.Destinations) == 1 {
+		return strings.Join(append(in, "-f", pushFormat(p.Destinations[0]), p.Destinations[0]), " ")
+	}
+	tee := make(Tee, len(p.Destinations))
+	for i, d := range p.Destinations {
+		tee[i] = TeeOutput{Path: d, Format: pushFormat(d), IgnoreFailure: true}
+	}
+	return strings.Join(append(in, "-flags +global_header", tee.Args()), " ")
+}
+
+// pushFormat returns the muxer of a destination.
+func pushFormat(dest string) string {
+	if strings.HasPrefix(dest, "srt://") {
+		return "mpegts"
+	}
+	return "flv"
+}
+
+// slaveFailed matches the tee reports of a failed destination.
+var slaveFailed = regexp.MustCompile(`Slave muxer #(\d+) failed: (.*?)(, continuing with|$)`)
+
+// Run relays the input until its end or ctx is done, running
+// FFmpeg with a HookedRunner built with opts. It returns a
+// *PushError if any destination failed.
+func (p *Push) Run(ctx context.Context, opts ...func(r *HookedRunner)) error {
+	args := p.Args
+	if args == "" {
+		args = "-c copy"
+		if p.Probe != "" {
+			info, err := Probe(ctx, p.Probe, p.Input)
+			if err != nil {
+				return err
+			}
+			if !pushCopy(info) {
+				args = "-c:v libx264 -preset veryfast -c:a aac"
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	failed := make(map[string]string)
+	opts = append(opts[:len(opts):len(opts)], LineHook(func(line string) {
+		m := slaveFailed.FindStringSubmatch(line)
+		if m == nil {
+			return
+		}
+		if i, _ := strconv.Atoi(m[1]); i < len(p.Destinations) {
+			mu.Lock()
+			failed[p.Destinations[i]] = m[2]
+			mu.Unlock()
+		}
+	}))
+	err := HookRunner(opts...).Run(ctx, p.Arg(args))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err != nil && len(p.Destinations) == 1 {
+		failed[p.Destinations[0]] = err.Error()
+	}
+	if err != nil || len(failed) > 0 {
+		return &PushError{Failed: failed, Err: err}
+	}
+	return nil
+}
+
+// pushCopy reports whether the streams of info can be relayed
+// without encoding.
+func pushCopy(info *ProbeInfo) bool {
+	for _, s := range info.Streams {
+		switch {
+		case s.CodecType == "video" && s.CodecName != "h264",
+			s.CodecType == "audio" && s.CodecName != "aac" && s.CodecName != "mp3":
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestPushArg(t *testing.T) {
+	p := ffmpeg.Push{Input: "in.mp4", Destinations: []string{"rtmp://a/live/key"}}
+	if got, want := p.Arg("-c copy"), "-nostdin -re -i in.mp4 -map 0:v:0? -map 0:a:0? -c copy -f flv rtmp://a/live/key"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	p = ffmpeg.Push{Input: "srt://in:9000", Destinations: []string{"rtmp://a/live/key", "srt://b:9000?streamid=x|y"}}
+	want := "-nostdin -i srt://in:9000 -map 0:v:0? -map 0:a:0? -c copy -flags +global_header -f tee " +
+		`[f=flv:onfail=ignore]rtmp://a/live/key|[f=mpegts:onfail=ignore]srt://b:9000?streamid=x\|y`
+	if got := p.Arg("-c copy"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPush(t *testing.T) {
+	p, last := argsBinary(t)
+	push := ffmpeg.Push{Input: "in.mp4", Destinations: []string{"rtmp://a/live/key"}, Probe: fakeBinary(t, fakeProbe)}
+	if err := push.Run(context.TODO(), ffmpeg.CustomPath(p)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(last(), "-c copy") {
+		t.Errorf("want a copy of h264/aac, got %q", last())
+	}
+
+	// the second destination fails, the first one goes on
+	p = fakeBinary(t, `echo "[tee @ 0x1] Slave muxer #1 failed: Connection refused, continuing with 1/2 slaves." >&2`+"\n")
+	push.Destinations = append(push.Destinations, "srt://b:9000")
+	err := push.Run(context.TODO(), ffmpeg.CustomPath(p))
+	var pe *ffmpeg.PushError
+	if !errors.As(err, &pe) || pe.Err != nil || len(pe.Failed) != 1 || pe.Failed["srt://b:9000"] != "Connection refused" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
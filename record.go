@@ -0,0 +1,122 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Recorder records a live stream, typically a RTSP camera,
+// into rotating segments, restarting FFmpeg whenever it exits
+// or stalls to record around the clock.
+type Recorder struct {
+	URL string
+
+	// Output is the path of the segments, expanded by strftime
+	// at the start of each, e.g. "/rec/cam1-%Y%m%d-%H%M%S.mp4",
+	// so that a restart of the Recorder, or of the program,
+	// appends segments instead of overwriting the previous ones.
+	Output string
+
+	Segment   time.Duration // 10min by default
+	Transport string        // of RTSP, "tcp" by default
+	Timeout   time.Duration // of the socket, 5s by default
+	Stall     time.Duration // without progress to restart, 30s by default
+	Args      string        // the output args, "-c copy" by default
+
+	// Backoff is the delay before the first restart, doubled
+	// for every restart up to a minute, and reset once a run
+	// lasted a Segment. 1s by default.
+	Backoff time.Duration
+
+	// OnRestart, if set, is called with the error of every run
+	// before it is restarted, ErrStalled for stalls.
+	OnRestart func(err error)
+
+	// Version is that of FFmpeg, see Version, 5.0 or newer if
+	// nil. Before 5.0, the socket timeout of RTSP is -stimeout,
+	// -timeout making it listen for a connection instead.
+	Version *VersionInfo
+}
+
+func (rc *Recorder) defaults() Recorder {
+	c := *rc
+	if c.Segment == 0 {
+		c.Segment = 10 * time.Minute
+	}
+	if c.Transport == "" {
+		c.Transport = "tcp"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.Stall == 0 {
+		c.Stall = 30 * time.Second
+	}
+	if c.Args == "" {
+		c.Args = "-c copy"
+	}
+	if c.Backoff == 0 {
+		c.Backoff = time.Second
+	}
+	return c
+}
+
+// Arg returns the arg of a run of rc. HTTP sources such as HLS
+// reconnect on timeouts.
+func (rc *Recorder) Arg() string {
+	c := rc.defaults()
+	in := []string{"-nostdin"}
+	switch {
+	case strings.HasPrefix(c.URL, "rtsp://"), strings.HasPrefix(c.URL, "rtsps://"):
+		timeout := "-timeout"
+		if c.Version != nil && !c.Version.Supports(RTSPTimeout) {
+			timeout = "-stimeout"
+		}
+		in = append(in, "-rtsp_transport", c.Transport,
+			timeout, strconv.FormatInt(c.Timeout.Microseconds(), 10))
+	case strings.HasPrefix(c.URL, "http://"), strings.HasPrefix(c.URL, "https://"):
+		in = append(in, "-reconnect 1 -reconnect_streamed 1",
+			"-reconnect_delay_max", strconv.Itoa(int(c.Timeout.Seconds())),
+			"-rw_timeout", strconv.FormatInt(c.Timeout.Microseconds(), 10))
+	}
+	return strings.Join(append(in, "-i", c.URL, "-map 0", c.Args,
+		"-f segment -segment_time", seconds(c.Segment),
+		"-reset_timestamps 1 -strftime 1", c.Output), " ")
+}
+
+// Run records until ctx is done, running FFmpeg with a
+// HookedRunner built with opts, and returns ctx.Err().
+func (rc *Recorder) Run(ctx context.Context, opts ...func(r *HookedRunner)) error {
+	c := rc.defaults()
+	arg := rc.Arg()
+	backoff := c.Backoff
+	for {
+		ro := append(opts[:len(opts):len(opts)], StallTimeout(c.Stall), StopOnStall())
+		start := time.Now()
+		err := HookRunner(ro...).Run(ctx, arg)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if errors.Is(err, ErrStalled) {
+			err = ErrStalled
+		}
+		if c.OnRestart != nil {
+			c.OnRestart(err)
+		}
+		if time.Since(start) >= c.Segment {
+			backoff = c.Backoff
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
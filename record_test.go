@@ -0,0 +1,68 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestRecorderArg(t *testing.T) {
+	rc := ffmpeg.Recorder{URL: "rtsp://cam1/stream", Output: "/rec/cam1-%Y%m%d-%H%M%S.mp4", Segment: time.Minute}
+	want := "-nostdin -rtsp_transport tcp -timeout 5000000 -i rtsp://cam1/stream -map 0 -c copy " +
+		"-f segment -segment_time 60 -reset_timestamps 1 -strftime 1 /rec/cam1-%Y%m%d-%H%M%S.mp4"
+	if got := rc.Arg(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	rc.Version = &ffmpeg.VersionInfo{Major: 4, Minor: 4, Patch: 2}
+	if got := rc.Arg(); !strings.Contains(got, " -stimeout 5000000 ") || strings.Contains(got, " -timeout ") {
+		t.Errorf("unexpected 4.x arg %q", got)
+	}
+	rc.Version = &ffmpeg.VersionInfo{Major: 5, Minor: 1}
+	if got := rc.Arg(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	rc.URL = "https://cdn/live.m3u8"
+	if got := rc.Arg(); !strings.Contains(got, "-reconnect 1 -reconnect_streamed 1") || strings.Contains(got, "rtsp") {
+		t.Errorf("unexpected HTTP arg %q", got)
+	}
+}
+
+func TestRecorderRestart(t *testing.T) {
+	runs := filepath.Join(t.TempDir(), "runs")
+	// the first run fails, the second one stalls
+	p := fakeBinary(t, `echo run >> `+runs+`
+[ $(wc -l < `+runs+`) -eq 1 ] && exit 1
+echo "frame=    1 fps=0.0 q=-1.0 size=       0kB time=00:00:00.04 bitrate=N/A speed=1x" >&2
+exec sleep 10
+`)
+	ctx, cancel := context.WithCancel(context.Background())
+	var errs []error
+	rc := ffmpeg.Recorder{
+		URL:     "rtsp://cam1/stream",
+		Output:  "cam1-%s.mp4",
+		Stall:   100 * time.Millisecond,
+		Backoff: 10 * time.Millisecond,
+		OnRestart: func(err error) {
+			if errs = append(errs, err); len(errs) == 2 {
+				cancel()
+			}
+		},
+	}
+	if err := rc.Run(ctx, ffmpeg.CustomPath(p)); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+	if len(errs) != 2 || errs[0] == nil || !errors.Is(errs[1], ffmpeg.ErrStalled) {
+		t.Errorf("unexpected restarts %v", errs)
+	}
+	if b, _ := os.ReadFile(runs); strings.Count(string(b), "run") != 2 {
+		t.Errorf("want 2 runs, got %q", b)
+	}
+}
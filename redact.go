@@ -0,0 +1,106 @@
+package ffmpeg
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the secrets hidden by a Redactor.
+const Redacted = "REDACTED"
+
+// A Redactor hides secrets in command lines before they are
+// surfaced in logs, events, traces or errors: the credentials
+// of URLs (user:pass@), the values of some query parameters
+// and the values of some flags.
+type Redactor struct {
+	Flags  []string // e.g. "-headers"
+	Params []string // e.g. "token", matched case-insensitively
+
+	once    sync.Once
+	flags   map[string]bool
+	paramRe *regexp.Regexp
+}
+
+// DefaultRedactor is used by HookedRunners unless set
+// otherwise with WithRedactor.
+var DefaultRedactor = &Redactor{
+	Flags: []string{
+		"-headers", "-cookies", "-rtmp_pageurl", "-rtmp_conn",
+		"-passphrase", "-decryption_key", "-encryption_key",
+	},
+	Params: []string{
+		"token", "key", "password", "passwd", "passphrase",
+		"secret", "signature", "sig", "auth",
+	},
+}
+
+var userinfoRe = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s?#]+@`)
+
+func (rd *Redactor) init() {
+	rd.flags = make(map[string]bool)
+	for _, f := range rd.Flags {
+		rd.flags[f] = true
+	}
+	if len(rd.Params) > 0 {
+		ps := make([]string, len(rd.Params))
+		for i, p := range rd.Params {
+			ps[i] = regexp.QuoteMeta(p)
+		}
+		rd.paramRe = regexp.MustCompile(`(?i)([?&](?:` + strings.Join(ps, "|") + `)=)[^&\s]*`)
+	}
+}
+
+// Args returns a copy of args with the secrets redacted.
+// A nil Redactor returns args as is.
+func (rd *Redactor) Args(args []string) []string {
+	if rd == nil {
+		return args
+	}
+	rd.once.Do(rd.init)
+
+	out := make([]string, len(args))
+	for i, a := range args {
+		if i > 0 && rd.flags[args[i-1]] {
+			out[i] = Redacted
+			continue
+		}
+		a = userinfoRe.ReplaceAllString(a, "${1}"+Redacted+"@")
+		if rd.paramRe != nil {
+			a = rd.paramRe.ReplaceAllString(a, "${1}"+Redacted)
+		}
+		out[i] = a
+	}
+	return out
+}
+
+// String redacts an arg or command line, see Args.
+func (rd *Redactor) String(s string) string {
+	if rd == nil {
+		return s
+	}
+	return strings.Join(rd.Args(strings.Fields(s)), " ")
+}
+
+// CommandLine is like the package function CommandLine
+// with the secrets redacted, e.g. for dry-run output.
+func (rd *Redactor) CommandLine(cmd *exec.Cmd) string {
+	c := *cmd
+	c.Args = rd.Args(cmd.Args)
+	return CommandLine(&c)
+}
+
+// WithRedactor sets the Redactor hiding the secrets of the
+// command line in logs and events. A nil Redactor disables
+// the redaction.
+func WithRedactor(rd *Redactor) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.redactor = rd
+	}
+}
+
+// Redactor returns the Redactor of r, see WithRedactor.
+func (r *HookedRunner) Redactor() *Redactor {
+	return r.redactor
+}
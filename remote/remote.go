@@ -0,0 +1,293 @@
+/*
+Package remote runs FFmpeg on a remote host over SSH.
+
+The remote login shell must be a POSIX shell.
+*/
+package remote
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/practigo/ffmpeg"
+	"golang.org/x/crypto/ssh"
+)
+
+// A Runner runs FFmpeg through an SSH client. It implements
+// ffmpeg.Runner.
+type Runner struct {
+	client *ssh.Client
+	path   string
+	lines  []func(line string)
+	stdout io.Writer
+	stderr io.Writer
+	grace  time.Duration
+}
+
+// New returns a Runner running "ffmpeg" from the remote PATH
+// through client.
+func New(client *ssh.Client, opts ...func(r *Runner)) *Runner {
+	r := &Runner{
+		client: client,
+		path:   "ffmpeg",
+		grace:  5 * time.Second,
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// Path sets the path of the remote FFmpeg binary.
+func Path(p string) func(r *Runner) {
+	return func(r *Runner) {
+		r.path = p
+	}
+}
+
+// LineHook calls h with every line the remote FFmpeg writes to
+// stderr while it runs, see ffmpeg.LineHook.
+func LineHook(h func(line string)) func(r *Runner) {
+	return func(r *Runner) {
+		r.lines = append(r.lines, h)
+	}
+}
+
+// Stdout sets the writer receiving the remote stdout.
+func Stdout(w io.Writer) func(r *Runner) {
+	return func(r *Runner) {
+		r.stdout = w
+	}
+}
+
+// Stderr sets the writer receiving the remote stderr.
+func Stderr(w io.Writer) func(r *Runner) {
+	return func(r *Runner) {
+		r.stderr = w
+	}
+}
+
+// Grace sets how long a cancelled remote FFmpeg has to exit
+// after SIGTERM before it is sent SIGKILL, 5s by default.
+func Grace(d time.Duration) func(r *Runner) {
+	return func(r *Runner) {
+		r.grace = d
+	}
+}
+
+// A Transfer copies a file between the local and remote hosts.
+type Transfer struct {
+	Local  string
+	Remote string
+}
+
+// A Stage lists the files to upload before a run and to
+// download after it succeeded.
+type Stage struct {
+	Uploads   []Transfer
+	Downloads []Transfer
+
+	// Cleanup removes the remote files of the transfers
+	// once the run is over.
+	Cleanup bool
+}
+
+// Run runs FFmpeg with arg on the remote host and waits for its
+// exit. When ctx is done, the remote FFmpeg is sent SIGTERM, then
+// SIGKILL after the grace period.
+func (r *Runner) Run(ctx context.Context, arg string) error {
+	return r.RunStaged(ctx, arg, Stage{})
+}
+
+// RunStaged is like Run, transferring the files of st with SFTP.
+func (r *Runner) RunStaged(ctx context.Context, arg string, st Stage) error {
+	if len(st.Uploads) == 0 && len(st.Downloads) == 0 {
+		return r.run(ctx, arg)
+	}
+
+	sc, err := sftp.NewClient(r.client)
+	if err != nil {
+		return err
+	}
+	defer sc.Close()
+
+	if st.Cleanup {
+		defer func() {
+			for _, t := range append(st.Uploads, st.Downloads...) {
+				sc.Remove(t.Remote)
+			}
+		}()
+	}
+
+	for _, t := range st.Uploads {
+		if err = upload(sc, t); err != nil {
+			return err
+		}
+	}
+
+	if err = r.run(ctx, arg); err != nil {
+		return err
+	}
+
+	for _, t := range st.Downloads {
+		if err = download(sc, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, arg string) error {
+	sess, err := r.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	sess.Stdout = r.stdout
+	stderr, err := sess.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{r.path}, strings.Fields(arg)...)
+	cmdline := ffmpeg.CommandLine(&exec.Cmd{Path: r.path, Args: args})
+	// the shell reports its pid, which FFmpeg takes over
+	if err = sess.Start("echo $$ >&2; exec " + cmdline); err != nil {
+		return err
+	}
+
+	pidc := make(chan int, 1)
+	lines := make(chan struct{})
+	go func() {
+		defer close(lines)
+		r.readStderr(stderr, pidc)
+	}()
+
+	waitc := make(chan error, 1)
+	go func() {
+		waitc <- sess.Wait()
+	}()
+
+	select {
+	case err = <-waitc:
+	case <-ctx.Done():
+		err = r.stop(sess, pidc, waitc)
+	}
+	<-lines
+	return err
+}
+
+func (r *Runner) readStderr(stderr io.Reader, pidc chan<- int) {
+	br := bufio.NewReader(stderr)
+	if l, err := br.ReadString('\n'); err == nil {
+		pid, _ := strconv.Atoi(strings.TrimSpace(l))
+		pidc <- pid
+	}
+	close(pidc)
+
+	var w io.Writer = io.Discard
+	if r.stderr != nil {
+		w = r.stderr
+	}
+	tee := io.TeeReader(br, w)
+	s := bufio.NewScanner(tee)
+	s.Buffer(make([]byte, 4096), 1<<20)
+	s.Split(ffmpeg.ScanLines)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		for _, h := range r.lines {
+			h(s.Text())
+		}
+	}
+	io.Copy(io.Discard, tee)
+}
+
+// stop terminates the remote FFmpeg and returns its exit error.
+func (r *Runner) stop(sess *ssh.Session, pidc <-chan int, waitc <-chan error) error {
+	var pid int
+	select {
+	case pid = <-pidc:
+	case err := <-waitc:
+		return err
+	}
+
+	// not every server supports signals, so also kill by pid
+	sess.Signal(ssh.SIGTERM)
+	if pid > 0 {
+		r.kill("TERM", pid)
+	}
+	t := time.NewTimer(r.grace)
+	defer t.Stop()
+	select {
+	case err := <-waitc:
+		return err
+	case <-t.C:
+	}
+
+	sess.Signal(ssh.SIGKILL)
+	if pid > 0 {
+		r.kill("KILL", pid)
+	}
+	return <-waitc
+}
+
+func (r *Runner) kill(sig string, pid int) {
+	sess, err := r.client.NewSession()
+	if err != nil {
+		return
+	}
+	defer sess.Close()
+	sess.Run("kill -" + sig + " " + strconv.Itoa(pid))
+}
+
+func upload(sc *sftp.Client, t Transfer) error {
+	src, err := os.Open(t.Local)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = sc.MkdirAll(path.Dir(t.Remote)); err != nil {
+		return err
+	}
+	dst, err := sc.Create(t.Remote)
+	if err != nil {
+		return err
+	}
+	if _, err = dst.ReadFrom(src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func download(sc *sftp.Client, t Transfer) error {
+	src, err := sc.Open(t.Remote)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(t.Local)
+	if err != nil {
+		return err
+	}
+	if _, err = src.WriteTo(dst); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
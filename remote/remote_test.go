@@ -0,0 +1,176 @@
+package remote_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/practigo/ffmpeg/remote"
+	"golang.org/x/crypto/ssh"
+)
+
+// serve runs an SSH server executing commands with the local sh
+// and serving sftp, and returns a client connected to it.
+func serve(t *testing.T) *ssh.Client {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(c, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					ch, reqs, err := nc.Accept()
+					if err != nil {
+						continue
+					}
+					go session(ch, reqs)
+				}
+			}()
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func session(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		var p struct{ Value string }
+		ssh.Unmarshal(req.Payload, &p)
+		switch {
+		case req.Type == "exec":
+			req.Reply(true, nil)
+			cmd := exec.Command("sh", "-c", p.Value)
+			cmd.Stdout = ch
+			cmd.Stderr = ch.Stderr()
+			var status struct{ Code uint32 }
+			if err := cmd.Run(); err != nil {
+				status.Code = 1
+				if ee, ok := err.(*exec.ExitError); ok && ee.ExitCode() > 0 {
+					status.Code = uint32(ee.ExitCode())
+				}
+			}
+			ch.SendRequest("exit-status", false, ssh.Marshal(&status))
+			return
+		case req.Type == "subsystem" && p.Value == "sftp":
+			req.Reply(true, nil)
+			s, err := sftp.NewServer(ch)
+			if err == nil {
+				s.Serve()
+			}
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func fakeBinary(t *testing.T, body string) string {
+	p := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(p, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRunStaged(t *testing.T) {
+	p := fakeBinary(t, `printf "frame=  1 fps=25\rframe=  2 fps=25\r\n" >&2; cp "$2" "$3"`)
+	local, dir := t.TempDir(), t.TempDir()
+	in, out := filepath.Join(local, "in.mp4"), filepath.Join(local, "out.mp4")
+	if err := os.WriteFile(in, []byte("media"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var lines []string
+	r := remote.New(serve(t), remote.Path(p), remote.LineHook(func(line string) {
+		mu.Lock()
+		lines = append(lines, line)
+		mu.Unlock()
+	}))
+
+	rin, rout := dir+"/stage/in.mp4", dir+"/stage/out.mp4"
+	err := r.RunStaged(context.TODO(), "-i "+rin+" "+rout, remote.Stage{
+		Uploads:   []remote.Transfer{{Local: in, Remote: rin}},
+		Downloads: []remote.Transfer{{Local: out, Remote: rout}},
+		Cleanup:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil || string(b) != "media" {
+		t.Errorf("unexpected output %q: %v", b, err)
+	}
+	if _, err = os.Stat(rout); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("remote output not cleaned up: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "frame=  2") {
+		t.Errorf("unexpected lines %q", lines)
+	}
+}
+
+func TestRunExitError(t *testing.T) {
+	r := remote.New(serve(t), remote.Path(fakeBinary(t, "exit 3\n")))
+	var ee *ssh.ExitError
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); !errors.As(err, &ee) || ee.ExitStatus() != 3 {
+		t.Errorf("want exit status 3, got %v", err)
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	r := remote.New(serve(t), remote.Path(fakeBinary(t, "exec sleep 10\n")), remote.Grace(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := r.Run(ctx, "-i in.mp4 out.mp4"); err == nil {
+		t.Error("want error")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("remote ffmpeg not killed, took %s", d)
+	}
+}
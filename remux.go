@@ -0,0 +1,179 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A Remux copies the streams of Input into the container of
+// Output, transcoding only the streams the container can't
+// hold as they are.
+type Remux struct {
+	Input  string
+	Output string
+	Format string // the muxer, by default from the extension of Output
+}
+
+// A RemuxPlan is how a Remux writes the streams of its input,
+// by input stream index.
+type RemuxPlan struct {
+	Format  string
+	Copied  []int
+	Encoded []int
+	Dropped []int // no encoder for the container, e.g. data streams or bitmap subtitles to MP4
+	Arg     string
+}
+
+// A remuxTarget is what a container holds without encoding, by
+// codec type (any codec if missing), and the encoders of the
+// other streams.
+type remuxTarget struct {
+	codecs   map[string][]string
+	encoders map[string]string
+}
+
+// remuxTargets are the containers Remux writes to, by muxer.
+var remuxTargets = map[string]remuxTarget{
+	"mp4": {
+		codecs: map[string][]string{
+			"video":    {"h264", "hevc", "av1", "vp9", "mpeg4"},
+			"audio":    {"aac", "mp3", "ac3", "eac3", "alac", "flac", "opus"},
+			"subtitle": {"mov_text"},
+		},
+		encoders: map[string]string{"video": "libx264", "audio": "aac", "subtitle": "mov_text"},
+	},
+	"mov": {
+		codecs: map[string][]string{
+			"video":    {"h264", "hevc", "prores", "mjpeg", "mpeg4"},
+			"audio":    {"aac", "mp3", "ac3", "eac3", "alac", "pcm_s16le", "pcm_s24le"},
+			"subtitle": {"mov_text"},
+		},
+		encoders: map[string]string{"video": "libx264", "audio": "aac", "subtitle": "mov_text"},
+	},
+	"matroska": {
+		codecs:   map[string][]string{"subtitle": {"subrip", "ass", "ssa", "webvtt", "hdmv_pgs_subtitle", "dvd_subtitle", "dvb_subtitle"}},
+		encoders: map[string]string{"subtitle": "srt"},
+	},
+	"webm": {
+		codecs: map[string][]string{
+			"video":    {"vp8", "vp9", "av1"},
+			"audio":    {"opus", "vorbis"},
+			"subtitle": {"webvtt"},
+		},
+		encoders: map[string]string{"video": "libvpx-vp9", "audio": "libopus", "subtitle": "webvtt"},
+	},
+	"mpegts": {
+		codecs: map[string][]string{
+			"video":    {"h264", "hevc", "mpeg2video", "mpeg1video"},
+			"audio":    {"aac", "mp3", "mp2", "ac3", "eac3"},
+			"subtitle": {"dvb_subtitle"},
+		},
+		encoders: map[string]string{"video": "libx264", "audio": "aac"},
+	},
+	"flv": {
+		codecs:   map[string][]string{"video": {"h264"}, "audio": {"aac", "mp3"}},
+		encoders: map[string]string{"video": "libx264", "audio": "aac"},
+	},
+}
+
+// bitmapSubtitles are the subtitle codecs the text subtitle
+// encoders of the targets can't convert.
+var bitmapSubtitles = []string{"hdmv_pgs_subtitle", "dvd_subtitle", "dvb_subtitle", "xsub"}
+
+// remuxFormats are the muxers by extension.
+var remuxFormats = map[string]string{
+	".mp4":  "mp4",
+	".m4v":  "mp4",
+	".m4a":  "mp4",
+	".mov":  "mov",
+	".mkv":  "matroska",
+	".mka":  "matroska",
+	".webm": "webm",
+	".ts":   "mpegts",
+	".m2ts": "mpegts",
+	".flv":  "flv",
+}
+
+// Plan returns how m writes the streams of its input described
+// by info, with the bitstream filters copying H.264/HEVC from
+// MP4-style inputs to MPEG-TS (Annex B) and ADTS AAC to the
+// MP4-style containers need.
+func (m Remux) Plan(info *ProbeInfo) (*RemuxPlan, error) {
+	format := m.Format
+	if format == "" {
+		format = remuxFormats[strings.ToLower(filepath.Ext(m.Output))]
+	}
+	target, ok := remuxTargets[format]
+	if !ok {
+		return nil, fmt.Errorf("ffmpeg: can't remux to %q", m.Output)
+	}
+	in := info.Format.FormatName
+	adts := in == "mpegts" || in == "aac"
+	avcc := strings.Contains(in, "mp4") || strings.Contains(in, "matroska") || in == "flv"
+
+	p := &RemuxPlan{Format: format}
+	args := []string{"-y -i", m.Input}
+	var codecs []string
+	for _, s := range info.Streams {
+		codec := "copy"
+		switch allowed, listed := target.codecs[s.CodecType]; {
+		case listed && !contains(allowed, s.CodecName),
+			!listed && s.CodecType != "video" && s.CodecType != "audio":
+			codec = target.encoders[s.CodecType]
+			if s.CodecType == "subtitle" && contains(bitmapSubtitles, s.CodecName) {
+				codec = ""
+			}
+		}
+		if codec == "" {
+			p.Dropped = append(p.Dropped, s.Index)
+			continue
+		}
+
+		o := strconv.Itoa(len(p.Copied) + len(p.Encoded))
+		args = append(args, "-map", "0:"+strconv.Itoa(s.Index))
+		codecs = append(codecs, "-c:"+o, codec)
+		if codec != "copy" {
+			p.Encoded = append(p.Encoded, s.Index)
+			continue
+		}
+		p.Copied = append(p.Copied, s.Index)
+		switch {
+		case format == "mpegts" && avcc && (s.CodecName == "h264" || s.CodecName == "hevc"):
+			codecs = append(codecs, "-bsf:"+o, s.CodecName+"_mp4toannexb")
+		case (format == "mp4" || format == "mov" || format == "flv") && adts && s.CodecName == "aac":
+			codecs = append(codecs, "-bsf:"+o, "aac_adtstoasc")
+		}
+	}
+	if len(codecs) == 0 {
+		return nil, fmt.Errorf("ffmpeg: no stream of %s to remux to %s", m.Input, format)
+	}
+	p.Arg = strings.Join(append(append(args, codecs...), "-f", format, m.Output), " ")
+	return p, nil
+}
+
+// contains reports whether ss has s.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Run probes the input with the ffprobe binary at probe, writes
+// the output with r as planned and returns the plan.
+func (m Remux) Run(ctx context.Context, r Runner, probe string) (*RemuxPlan, error) {
+	info, err := Probe(ctx, probe, m.Input)
+	if err != nil {
+		return nil, err
+	}
+	p, err := m.Plan(info)
+	if err != nil {
+		return nil, err
+	}
+	return p, r.Run(ctx, p.Arg)
+}
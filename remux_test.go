@@ -0,0 +1,69 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestRemuxPlan(t *testing.T) {
+	info := &ffmpeg.ProbeInfo{
+		Format: ffmpeg.ProbeFormat{FormatName: "mpegts"},
+		Streams: []ffmpeg.ProbeStream{
+			{Index: 0, CodecType: "video", CodecName: "h264"},
+			{Index: 1, CodecType: "audio", CodecName: "aac"},
+			{Index: 2, CodecType: "audio", CodecName: "mp2"},
+			{Index: 3, CodecType: "data", CodecName: "timed_id3"},
+		},
+	}
+	p, err := ffmpeg.Remux{Input: "in.ts", Output: "out.mp4"}.Plan(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ffmpeg.RemuxPlan{Format: "mp4", Copied: []int{0, 1}, Encoded: []int{2}, Dropped: []int{3},
+		Arg: "-y -i in.ts -map 0:0 -map 0:1 -map 0:2 -c:0 copy -c:1 copy -bsf:1 aac_adtstoasc -c:2 aac -f mp4 out.mp4"}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+
+	info.Format.FormatName = "mov,mp4,m4a,3gp,3g2,mj2"
+	info.Streams[0].CodecName = "prores"
+	if p, err = (ffmpeg.Remux{Input: "in.mov", Output: "out.ts"}).Plan(info); err != nil {
+		t.Fatal(err)
+	}
+	if p.Arg != "-y -i in.mov -map 0:0 -map 0:1 -map 0:2 -c:0 libx264 -c:1 copy -c:2 copy -f mpegts out.ts" {
+		t.Errorf("unexpected arg %q", p.Arg)
+	}
+
+	info.Format.FormatName = "matroska,webm"
+	info.Streams = []ffmpeg.ProbeStream{
+		{Index: 0, CodecType: "video", CodecName: "h264"},
+		{Index: 1, CodecType: "subtitle", CodecName: "hdmv_pgs_subtitle"},
+		{Index: 2, CodecType: "subtitle", CodecName: "subrip"},
+	}
+	if p, err = (ffmpeg.Remux{Input: "in.mkv", Output: "out.mp4"}).Plan(info); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Dropped, []int{1}) || p.Arg != "-y -i in.mkv -map 0:0 -map 0:2 -c:0 copy -c:1 mov_text -f mp4 out.mp4" {
+		t.Errorf("unexpected plan %+v", p)
+	}
+
+	if _, err = (ffmpeg.Remux{Input: "in.mov", Output: "out.xyz"}).Plan(info); err == nil {
+		t.Error("want error for an unknown container")
+	}
+}
+
+func TestRemuxRun(t *testing.T) {
+	p, last := argsBinary(t)
+	m := ffmpeg.Remux{Input: "in.mp4", Output: "out.ts"}
+	plan, err := m.Run(context.TODO(), ffmpeg.HookRunner(ffmpeg.CustomPath(p)), fakeBinary(t, fakeProbe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-y -i in.mp4 -map 0:0 -map 0:1 -c:0 copy -bsf:0 h264_mp4toannexb -c:1 copy -f mpegts out.ts"
+	if plan.Arg != want || last() != want {
+		t.Errorf("got %q, want %q", last(), want)
+	}
+}
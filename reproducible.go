@@ -0,0 +1,79 @@
+package ffmpeg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Reproducible makes the runner write every output the same
+// bit for bit when run again on the same input with the same
+// FFmpeg, e.g. to key a cache by its content: the flags below
+// are injected before every output, unless it sets them.
+//
+//   - -threads threads, 1 if 0: the output of the frame-threaded
+//     encoders, e.g. libx264, depends on the thread count, by
+//     default the CPU count of the machine.
+//   - -fflags +bitexact, -flags:v +bitexact and -flags:a +bitexact:
+//     no version strings, random UIDs or wall clock times are
+//     written, added to the -fflags or -flags the output sets.
+//   - -map_metadata -1: no metadata of the inputs, e.g. their
+//     creation_time, is copied.
+//   - -avoid_negative_ts make_zero: the timestamps start at zero.
+func Reproducible(threads int) func(r *HookedRunner) {
+	if threads <= 0 {
+		threads = 1
+	}
+	return func(r *HookedRunner) {
+		r.rewrites = append(r.rewrites, func(args []string) []string {
+			var out []string
+			from := 0
+			for _, o := range outputs(args) {
+				out = append(out, args[from:o]...)
+				out = append(out, bitexactArgs(out[len(out)-(o-from):], threads)...)
+				out = append(out, args[o])
+				from = o + 1
+			}
+			return append(out, args[from:]...)
+		})
+	}
+}
+
+// bitexactArgs returns the args of Reproducible seg, the args
+// before an output, lacks. The -fflags and -flags of the output
+// are made bitexact in place; those of the inputs are left as is.
+func bitexactArgs(seg []string, threads int) []string {
+	start := 0
+	walkArgs(seg, func(flag string, i int) bool {
+		if flag == "-i" {
+			start = min(i+2, len(seg))
+		}
+		return true
+	})
+	seg = seg[start:]
+	set := make(map[string]bool)
+	walkArgs(seg, func(flag string, i int) bool {
+		set[flag] = true
+		if (flag == "-fflags" || flag == "-flags") && i+1 < len(seg) && !strings.Contains(seg[i+1], "bitexact") {
+			seg[i+1] += "+bitexact"
+		}
+		return true
+	})
+
+	var args []string
+	if !set["-threads"] {
+		args = append(args, "-threads", strconv.Itoa(threads))
+	}
+	if !set["-fflags"] {
+		args = append(args, "-fflags", "+bitexact")
+	}
+	if !set["-flags"] {
+		args = append(args, "-flags:v", "+bitexact", "-flags:a", "+bitexact")
+	}
+	if !set["-map_metadata"] {
+		args = append(args, "-map_metadata", "-1")
+	}
+	if !set["-avoid_negative_ts"] {
+		args = append(args, "-avoid_negative_ts", "make_zero")
+	}
+	return args
+}
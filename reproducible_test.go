@@ -0,0 +1,29 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestReproducible(t *testing.T) {
+	p, last := argsBinary(t)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.Reproducible(0))
+
+	for arg, want := range map[string]string{
+		"-i in.mp4 -c:v libx264 out.mp4": "-i in.mp4 -c:v libx264 -threads 1 -fflags +bitexact -flags:v +bitexact -flags:a +bitexact " +
+			"-map_metadata -1 -avoid_negative_ts make_zero out.mp4",
+		// input flags left as is, output ones made bitexact
+		"-fflags +genpts -i in.mp4 -threads 4 -flags +global_header -map_metadata 0 -f mpegts out.ts -c copy copy.mkv": "-fflags +genpts -i in.mp4 " +
+			"-threads 4 -flags +global_header+bitexact -map_metadata 0 -f mpegts -fflags +bitexact -avoid_negative_ts make_zero out.ts " +
+			"-c copy -threads 1 -fflags +bitexact -flags:v +bitexact -flags:a +bitexact -map_metadata -1 -avoid_negative_ts make_zero copy.mkv",
+	} {
+		if err := r.Run(context.TODO(), arg); err != nil {
+			t.Fatal(err)
+		}
+		if got := last(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
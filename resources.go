@@ -0,0 +1,79 @@
+package ffmpeg
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// I/O scheduling classes for IONice.
+const (
+	IORealtime   = 1
+	IOBestEffort = 2
+	IOIdle       = 3
+)
+
+// Nice runs FFmpeg with the niceness n (-20 to 19, higher
+// is lower priority) through the nice command.
+func Nice(n int) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.wrap = append(r.wrap, "nice", "-n", strconv.Itoa(n))
+	}
+}
+
+// IONice runs FFmpeg with the I/O scheduling class and level
+// (0 to 7, ignored for IOIdle) through the ionice command
+// (Linux only).
+func IONice(class, level int) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.wrap = append(r.wrap, "ionice", "-c", strconv.Itoa(class))
+		if class != IOIdle {
+			r.wrap = append(r.wrap, "-n", strconv.Itoa(level))
+		}
+	}
+}
+
+// CPUAffinity pins FFmpeg to the given CPUs through the taskset
+// command (Linux only).
+func CPUAffinity(cpus ...int) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		cs := make([]string, len(cpus))
+		for i, c := range cpus {
+			cs[i] = strconv.Itoa(c)
+		}
+		r.wrap = append(r.wrap, "taskset", "-c", strings.Join(cs, ","))
+	}
+}
+
+// Threads injects "-threads n" before the output (the last
+// argument) unless the arg already sets -threads.
+func Threads(n int) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.rewrites = append(r.rewrites, func(args []string) []string {
+			if len(args) == 0 {
+				return args
+			}
+			for _, a := range args {
+				if a == "-threads" {
+					return args
+				}
+			}
+			last := len(args) - 1
+			out := append(args[:last:last], "-threads", strconv.Itoa(n))
+			return append(out, args[last])
+		})
+	}
+}
+
+// Cgroup runs FFmpeg in a new cgroup v2 created under parent
+// (a directory in /sys/fs/cgroup the program may create cgroups
+// in) with the memory.max of memoryMax bytes and the cpu.max of
+// cpus CPUs; zero values leave the limit unset. The cgroup is
+// removed once FFmpeg exited. It is only supported on Linux.
+func Cgroup(parent string, memoryMax int64, cpus float64) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.setups = append(r.setups, func(cmd *exec.Cmd) (func(), error) {
+			return cgroupSetup(cmd, parent, memoryMax, cpus)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestResources(t *testing.T) {
+	p, _ := argsBinary(t)
+
+	var line string
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p),
+		ffmpeg.Nice(10), ffmpeg.IONice(ffmpeg.IOIdle, 0), ffmpeg.CPUAffinity(0),
+		ffmpeg.Threads(2),
+		ffmpeg.DryRun(func(cmd *exec.Cmd) {
+			line = ffmpeg.CommandLine(cmd)
+		}))
+	if err := r.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Skip(err) // no nice
+	}
+
+	nice, _ := exec.LookPath("nice")
+	want := nice + " -n 10 ionice -c 3 taskset -c 0 " + p + " -i in.mp4 -threads 2 out.mp4"
+	if line != want {
+		t.Errorf("got %q, want %q", line, want)
+	}
+}
+
+func TestThreads(t *testing.T) {
+	p, last := argsBinary(t)
+
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.Threads(2))
+	r.Run(context.TODO(), "-i in.mp4 out.mp4")
+	if got := last(); got != "-i in.mp4 -threads 2 out.mp4" {
+		t.Errorf("unexpected args %q", got)
+	}
+
+	r.Run(context.TODO(), "-threads 4 -i in.mp4 out.mp4")
+	if got := last(); got != "-threads 4 -i in.mp4 out.mp4" {
+		t.Errorf("unexpected args %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package ffmpeg
+
+import (
+	"os"
+	"time"
+)
+
+// A Result describes an exited FFmpeg process.
+type Result struct {
+	Pid      int
+	Code     int           // the exit code, -1 if killed by a signal
+	Duration time.Duration // wall-clock time
+
+	UserTime   time.Duration
+	SystemTime time.Duration
+	MaxRSS     int64 // in bytes, 0 if unknown on the platform
+
+	// Cancelled is set if the context was done while the
+	// process was running, i.e. the exit hook was called.
+	Cancelled bool
+
+	// TimedOut is set if the process was stopped because
+	// it ran longer than the Timeout.
+	TimedOut bool
+
+	Reason StopReason
+
+	Cmd *CmdSnapshot // with Debug, nil otherwise
+}
+
+// CPUTime returns the user and system CPU time of the process.
+func (r *Result) CPUTime() time.Duration {
+	return r.UserTime + r.SystemTime
+}
+
+// newResult returns the Result of a process, cause being the
+// reason it was stopped by the exit hook or nil.
+func newResult(ps *os.ProcessState, d time.Duration, cause error) *Result {
+	return &Result{
+		Pid:        ps.Pid(),
+		Code:       ps.ExitCode(),
+		Duration:   d,
+		UserTime:   ps.UserTime(),
+		SystemTime: ps.SystemTime(),
+		MaxRSS:     maxRSS(ps),
+		Cancelled:  stopReason(cause, false) == StopCancelled,
+		TimedOut:   cause == ErrTimeout,
+		Reason:     stopReason(cause, ps.Success()),
+	}
+}
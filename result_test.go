@@ -0,0 +1,33 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestRunResult(t *testing.T) {
+	p := fakeBinary(t, "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done; exit 2\n")
+	res, err := ffmpeg.HookRunner(ffmpeg.CustomPath(p)).RunResult(context.TODO(), "")
+	if err == nil {
+		t.Fatal("want exit error")
+	}
+	if res.Code != 2 || res.Pid == 0 || res.Cancelled || res.Duration <= 0 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if res.CPUTime() <= 0 || res.MaxRSS <= 0 {
+		t.Errorf("want resource usage, got %+v", res)
+	}
+}
+
+func TestRunResultCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	res, err := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep")).RunResult(ctx, "10")
+	if err == nil || !res.Cancelled || res.Code != -1 {
+		t.Errorf("unexpected result %+v: %v", res, err)
+	}
+}
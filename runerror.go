@@ -0,0 +1,52 @@
+package ffmpeg
+
+import "encoding/json"
+
+// A RunError is the error of a run with its command and labels,
+// to reproduce the failure by copy-pasting the command.
+type RunError struct {
+	// Command is the command line, redacted, see WithRedactor,
+	// empty if the run failed before it was resolved.
+	Command string
+	Args    []string // of the command, without the path, redacted
+	Labels  Labels
+	Err     error
+}
+
+// Error returns the error followed by the labels and the
+// command, e.g. "exit status 1 [job=42] (command: ffmpeg -i
+// in.mp4 out.mp4)".
+func (e *RunError) Error() string {
+	s := e.Err.Error()
+	if len(e.Labels) > 0 {
+		s += " [" + e.Labels.String() + "]"
+	}
+	if e.Command != "" {
+		s += " (command: " + e.Command + ")"
+	}
+	return s
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON implements json.Marshaler, e.g. for structured
+// logs.
+func (e *RunError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Error   string   `json:"error"`
+		Command string   `json:"command,omitempty"`
+		Args    []string `json:"args,omitempty"`
+		Labels  Labels   `json:"labels,omitempty"`
+	}{e.Err.Error(), e.Command, e.Args, e.Labels})
+}
+
+// runError returns err as a RunError with the command and args
+// of the run, if resolved, and its labels.
+func runError(err error, command string, args []string, l Labels) error {
+	if err == nil || command == "" && len(l) == 0 {
+		return err
+	}
+	return &RunError{Command: command, Args: args, Labels: l, Err: err}
+}
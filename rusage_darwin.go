@@ -0,0 +1,13 @@
+package ffmpeg
+
+import (
+	"os"
+	"syscall"
+)
+
+func maxRSS(ps *os.ProcessState) int64 {
+	if ru, ok := ps.SysUsage().(*syscall.Rusage); ok {
+		return ru.Maxrss // in bytes
+	}
+	return 0
+}
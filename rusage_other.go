@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package ffmpeg
+
+import "os"
+
+func maxRSS(ps *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,80 @@
+/*
+Package boltstore provides a server.Store persisting the jobs
+in a bbolt database file.
+*/
+package boltstore
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/practigo/ffmpeg/server"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("jobs")
+
+// A Store is a server.Store backed by a bbolt database.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database file at path. The file
+// is locked until the Store is closed.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put implements server.Store.
+func (s *Store) Put(rec server.Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(rec.Job.ID), b)
+	})
+}
+
+// Delete implements server.Store.
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(id))
+	})
+}
+
+// List implements server.Store.
+func (s *Store) List() ([]server.Record, error) {
+	var recs []server.Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, v []byte) error {
+			var rec server.Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			recs = append(recs, rec)
+			return nil
+		})
+	})
+	sort.Slice(recs, func(a, b int) bool {
+		return recs[a].Job.Created.Before(recs[b].Job.Created)
+	})
+	return recs, err
+}
@@ -0,0 +1,44 @@
+package boltstore_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg/server"
+	"github.com/practigo/ffmpeg/server/boltstore"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	st, err := boltstore.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, rec := range []server.Record{
+		{Job: server.Job{ID: "1", State: server.Succeeded, Created: now}, Arg: "-i a.mp4 a.mkv"},
+		{Job: server.Job{ID: "2", State: server.Running, Created: now.Add(time.Second)}, Arg: "-i b.mp4 b.mkv"},
+		{Job: server.Job{ID: "3", State: server.Queued, Created: now.Add(2 * time.Second)}, Arg: "-i c.mp4 c.mkv"},
+	} {
+		if err = st.Put(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = st.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	if st, err = boltstore.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	recs, err := st.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[0].Job.ID != "2" || recs[1].Arg != "-i c.mp4 c.mkv" {
+		t.Errorf("unexpected records %+v", recs)
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A JobError is returned by Client.Run when the job failed
+// on the server.
+type JobError struct {
+	Job Job
+}
+
+func (e *JobError) Error() string {
+	return "server: job " + e.Job.ID + " " + e.Job.State + ": " + e.Job.Error
+}
+
+// A Client submits jobs to a Server. It implements ffmpeg.Runner.
+type Client struct {
+	URL  string        // e.g. "http://worker:8080"
+	HTTP *http.Client  // http.DefaultClient if nil
+	Poll time.Duration // of the running jobs, 1s if not positive
+}
+
+// NewClient returns a Client for the Server at url, polling
+// the running jobs every second.
+func NewClient(url string) *Client {
+	return &Client{URL: strings.TrimSuffix(url, "/"), Poll: time.Second}
+}
+
+// Run submits a job running FFmpeg with arg and waits for it
+// to be over. The job is cancelled when ctx is done.
+func (c *Client) Run(ctx context.Context, arg string) error {
+	j, err := c.Submit(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	poll := c.Poll
+	if poll <= 0 {
+		poll = time.Second
+	}
+	t := time.NewTicker(poll)
+	defer t.Stop()
+	for !j.Done() {
+		select {
+		case <-ctx.Done():
+			return c.abandon(ctx, j.ID)
+		case <-t.C:
+		}
+		next, err := c.Status(ctx, j.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return c.abandon(ctx, j.ID)
+			}
+			return err
+		}
+		j = next
+	}
+
+	if j.State != Succeeded {
+		return &JobError{Job: *j}
+	}
+	return nil
+}
+
+// abandon cancels the job with id, which outlives ctx, and
+// returns ctx.Err().
+func (c *Client) abandon(ctx context.Context, id string) error {
+	cctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c.Cancel(cctx, id)
+	return ctx.Err()
+}
+
+// Submit submits a job running FFmpeg with arg.
+func (c *Client) Submit(ctx context.Context, arg string) (*Job, error) {
+	body, err := json.Marshal(map[string]string{"arg": arg})
+	if err != nil {
+		return nil, err
+	}
+	j := &Job{}
+	return j, c.do(ctx, http.MethodPost, "/jobs", bytes.NewReader(body), j)
+}
+
+// Status returns the job with id.
+func (c *Client) Status(ctx context.Context, id string) (*Job, error) {
+	j := &Job{}
+	return j, c.do(ctx, http.MethodGet, "/jobs/"+id, nil, j)
+}
+
+// Cancel cancels the job with id.
+func (c *Client) Cancel(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/jobs/"+id, nil, nil)
+}
+
+// Logs returns the last stderr lines of the job with id.
+func (c *Client) Logs(ctx context.Context, id string) (string, error) {
+	var buf bytes.Buffer
+	err := c.do(ctx, http.MethodGet, "/jobs/"+id+"/logs", nil, &buf)
+	return buf.String(), err
+}
+
+// do sends a request and decodes the response into v, a
+// *bytes.Buffer receiving it as is or a value for JSON.
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server: %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(b))
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *bytes.Buffer:
+		_, err = v.ReadFrom(resp.Body)
+		return err
+	default:
+		if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return errors.New("server: bad response: " + err.Error())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Live is a running job with its process.
+type Live struct {
+	Job
+	Pid    int      `json:"pid"`
+	Uptime float64  `json:"uptime"` // in seconds
+	Lines  []string `json:"lines"`  // the last stderr lines
+}
+
+// Running returns the running jobs, in submission order, with
+// their last n stderr lines, none if n is not positive.
+func (s *Server) Running(n int) []Live {
+	n = max(n, 0)
+	s.mu.Lock()
+	var lives []Live
+	for _, j := range s.jobs {
+		if j.State != Running {
+			continue
+		}
+		logs := j.logs
+		if len(logs) > n {
+			logs = logs[len(logs)-n:]
+		}
+		lives = append(lives, Live{
+			Job:    j.Job,
+			Pid:    j.pid,
+			Uptime: time.Since(j.Started).Seconds(),
+			Lines:  append([]string{}, logs...),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(lives, func(a, b int) bool {
+		x, _ := strconv.Atoi(lives[a].ID)
+		y, _ := strconv.Atoi(lives[b].ID)
+		return x < y
+	})
+	return lives
+}
+
+// Monitor returns a http.Handler serving the Running jobs of s
+// with their last 10 stderr lines, or ?lines=N. The jobs are
+// JSON, or Server-Sent Events for the requests accepting
+// text/event-stream, e.g. from an EventSource, sent every
+// period (1s if not positive).
+func (s *Server) Monitor(period time.Duration) http.Handler {
+	if period <= 0 {
+		period = time.Second
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		n := 10
+		if v := req.URL.Query().Get("lines"); v != "" {
+			var err error
+			if n, err = strconv.Atoi(v); err != nil || n < 0 {
+				http.Error(w, "invalid lines "+v, http.StatusBadRequest)
+				return
+			}
+		}
+		if !strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+			writeJSON(w, http.StatusOK, s.running(n))
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		t := time.NewTicker(period)
+		defer t.Stop()
+		for {
+			b, _ := json.Marshal(s.running(n))
+			if _, err := w.Write([]byte("event: jobs\ndata: " + string(b) + "\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+			select {
+			case <-t.C:
+			case <-req.Context().Done():
+				return
+			}
+		}
+	})
+}
+
+// running returns the Running jobs, never nil to be a JSON
+// array.
+func (s *Server) running(n int) []Live {
+	lives := s.Running(n)
+	if lives == nil {
+		lives = []Live{}
+	}
+	return lives
+}
@@ -0,0 +1,75 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg/server"
+)
+
+func TestMonitor(t *testing.T) {
+	s, _ := start(t)
+	ts := httptest.NewServer(s.Monitor(-1))
+	defer ts.Close()
+
+	j, err := s.Submit("-i slow.mp4 out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, s, j.ID, server.Running)
+	time.Sleep(50 * time.Millisecond) // for the stats line
+
+	res, err := http.Get(ts.URL + "?lines=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lives []server.Live
+	err = json.NewDecoder(res.Body).Decode(&lives)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lives) != 1 || lives[0].ID != j.ID || lives[0].Pid == 0 || lives[0].Uptime <= 0 ||
+		len(lives[0].Lines) != 1 || lives[0].Progress.Frame != 100 {
+		t.Fatalf("unexpected running jobs %+v", lives)
+	}
+	if lives = s.Running(-1); len(lives) != 1 || len(lives[0].Lines) != 0 {
+		t.Errorf("unexpected running jobs %+v", lives)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	if res, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	sc := bufio.NewScanner(res.Body)
+	events := 0
+	for events < 2 && sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if err = json.Unmarshal([]byte(data), &lives); err != nil || len(lives) != 1 {
+			t.Fatalf("unexpected event %q: %v", data, err)
+		}
+		events++
+	}
+	if events != 2 {
+		t.Errorf("got %d events: %v", events, sc.Err())
+	}
+
+	s.Cancel(j.ID)
+	waitState(t, s, j.ID, server.Cancelled)
+	if lives = s.Running(10); len(lives) != 0 {
+		t.Errorf("want no running job, got %+v", lives)
+	}
+}
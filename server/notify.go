@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader is the header of the notifications carrying
+// "sha256=" and the hex HMAC-SHA256 of the body with the secret.
+const SignatureHeader = "X-Ffmpeg-Signature"
+
+// A Notification is the JSON payload posted by a Notifier.
+type Notification struct {
+	Job
+	Duration float64 `json:"duration"` // run duration in seconds
+}
+
+// A Notifier posts a Notification to a URL when a job is over.
+type Notifier struct {
+	URL     string
+	Secret  []byte        // signs the body if not empty
+	HTTP    *http.Client  // with a 30s timeout if nil
+	Timeout time.Duration // of each attempt, 10s if zero
+	Retries int           // attempts after the first one
+	Backoff time.Duration // before the first retry, doubled after each
+}
+
+// defaultClient is the client of the Notifiers without one.
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// Notify makes the Server post the jobs with n when they are
+// over, except the jobs interrupted by Close. They are posted
+// in the background, Close waiting for them.
+func Notify(n *Notifier) func(s *Server) {
+	return func(s *Server) {
+		s.notifiers = append(s.notifiers, n)
+	}
+}
+
+// Notify posts the Notification for j, retrying on network
+// errors and 5xx responses.
+func (n *Notifier) Notify(ctx context.Context, j Job) error {
+	no := Notification{Job: j}
+	if !j.Started.IsZero() && !j.Ended.IsZero() {
+		no.Duration = j.Ended.Sub(j.Started).Seconds()
+	}
+	body, err := json.Marshal(no)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.Backoff
+	for i := 0; ; i++ {
+		retry, err := n.post(ctx, body)
+		if err == nil || !retry || i >= n.Retries {
+			return err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, body []byte) (retry bool, err error) {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(n.Secret, body))
+	}
+
+	hc := n.HTTP
+	if hc == nil {
+		hc = defaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500, fmt.Errorf("server: notify %s: %s", n.URL, resp.Status)
+	}
+	return false, nil
+}
+
+// Sign returns the SignatureHeader value for body, to verify
+// the notifications received.
+func Sign(secret, body []byte) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package server_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/practigo/ffmpeg/server"
+)
+
+func TestNotify(t *testing.T) {
+	secret := []byte("s3cret")
+	var mu sync.Mutex
+	var attempts int
+	var got server.Notification
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		if req.Header.Get(server.SignatureHeader) != server.Sign(secret, body) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &got)
+	}))
+	defer hook.Close()
+
+	s := server.New(server.RunnerOptions(ffmpeg.CustomPath(fakeBinary(t, fakeJob))),
+		server.Notify(&server.Notifier{URL: hook.URL, Secret: secret, Retries: 2}))
+	j, _ := s.Submit("-i fail.mp4 out.mp4")
+	waitState(t, s, j.ID, server.Failed)
+	s.Close() // waits for the notification
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 2 || got.ID != j.ID || got.State != server.Failed || got.Error == "" || got.Progress.Frame != 100 {
+		t.Errorf("unexpected notification after %d attempts: %+v", attempts, got)
+	}
+}
+
+func TestNotifyTimeout(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	hung := make(chan struct{})
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		<-hung
+	}))
+	defer hook.Close()
+	defer close(hung)
+
+	s := server.New(server.RunnerOptions(ffmpeg.CustomPath(fakeBinary(t, fakeJob))),
+		server.MaxRunning(1, server.NoPreemption),
+		server.Notify(&server.Notifier{URL: hook.URL, Timeout: 200 * time.Millisecond}))
+	slow, _ := s.Submit("-i slow.mp4 out.mp4")
+	waitState(t, s, slow.ID, server.Running)
+	queued, _ := s.Submit("-i in.mp4 out.mp4")
+
+	start := time.Now()
+	if !s.Cancel(queued.ID) {
+		t.Fatal("job not found")
+	}
+	if d := time.Since(start); d >= 100*time.Millisecond {
+		t.Errorf("cancel blocked on the notification for %s", d)
+	}
+	s.Close() // waits for the notification to time out
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 1 {
+		t.Errorf("want 1 notification, got %d", attempts)
+	}
+}
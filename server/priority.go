@@ -0,0 +1,123 @@
+package server
+
+import (
+	"strconv"
+)
+
+// A Preemption is what a Server does to a running job when a
+// job of a higher priority is queued and MaxRunning jobs run.
+type Preemption int
+
+// The Preemptions.
+const (
+	// NoPreemption lets the running jobs finish.
+	NoPreemption Preemption = iota
+	// PreemptPause pauses the job, see ffmpeg.Process.Pause,
+	// which is resumed once a slot is free, or re-queued where
+	// pausing is not supported.
+	PreemptPause
+	// PreemptRequeue stops the job with the exit hook, e.g.
+	// gracefully with ffmpeg.StopViaStdin, and queues it again
+	// to be run from the start.
+	PreemptRequeue
+)
+
+// MaxRunning runs at most n jobs at the same time, the others
+// staying Queued, the paused ones not counted. The queued and
+// paused jobs run by priority, then the paused ones first, then
+// by submission order. When p is not NoPreemption, a job queued
+// preempts the running job of the lowest priority lower than
+// its own, of the least progress.
+func MaxRunning(n int, p Preemption) func(s *Server) {
+	return func(s *Server) {
+		s.maxRunning = n
+		s.preempt = p
+	}
+}
+
+// schedule runs the queued and paused jobs while MaxRunning
+// allows it, preempting jobs for them, s.mu being held.
+func (s *Server) schedule() {
+	for !s.closing {
+		next := s.next()
+		if next == nil {
+			return
+		}
+		running, freeing := 0, 0
+		for _, j := range s.jobs {
+			if j.launched && j.State != Paused {
+				running++
+				if j.preempted {
+					freeing++
+				}
+			}
+		}
+
+		if s.maxRunning > 0 && running >= s.maxRunning {
+			// wait for the jobs stopping
+			if freeing > 0 || s.preempt == NoPreemption {
+				return
+			}
+			v := s.victim(next.Priority)
+			if v == nil {
+				return
+			}
+			if s.preempt == PreemptPause && v.proc != nil && v.proc.Pause() == nil {
+				v.State = Paused
+				continue
+			}
+			v.preempted = true
+			v.cancel()
+			return
+		}
+
+		if next.State == Paused {
+			next.proc.Resume()
+			next.State = Running
+		} else {
+			s.launch(next)
+		}
+	}
+}
+
+// next returns the job to run next, nil if none.
+func (s *Server) next() *job {
+	var next *job
+	for _, j := range s.jobs {
+		if j.stopped || (j.launched || j.State != Queued) && j.State != Paused {
+			continue
+		}
+		if next == nil || before(j, next) {
+			next = j
+		}
+	}
+	return next
+}
+
+func before(a, b *job) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if (a.State == Paused) != (b.State == Paused) {
+		return a.State == Paused
+	}
+	x, _ := strconv.Atoi(a.ID)
+	y, _ := strconv.Atoi(b.ID)
+	return x < y
+}
+
+// victim returns the running job to preempt for a job of
+// priority, nil if none.
+func (s *Server) victim(priority int) *job {
+	var v *job
+	for _, j := range s.jobs {
+		if !j.launched || j.State == Paused || j.stopped || j.Priority >= priority {
+			continue
+		}
+		if v == nil || j.Priority < v.Priority ||
+			j.Priority == v.Priority && j.Progress.Time < v.Progress.Time {
+			v = j
+		}
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/practigo/ffmpeg/server"
+)
+
+func newScheduled(t *testing.T, p server.Preemption) *server.Server {
+	s := server.New(server.RunnerOptions(ffmpeg.CustomPath(fakeBinary(t, fakeJob))), server.MaxRunning(1, p))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestMaxRunning(t *testing.T) {
+	s := newScheduled(t, server.NoPreemption)
+	a, _ := s.Submit("-i slow.mp4 a.mp4")
+	waitState(t, s, a.ID, server.Running)
+	b, _ := s.Submit("-i slow.mp4 b.mp4")
+	c, _ := s.SubmitPriority("-i slow.mp4 c.mp4", 5)
+	if c.Priority != 5 || c.State != server.Queued {
+		t.Errorf("unexpected job %+v", c)
+	}
+
+	s.Cancel(a.ID)
+	waitState(t, s, c.ID, server.Running)
+	if j, _ := s.Status(b.ID); j.State != server.Queued {
+		t.Errorf("want b queued, got %s", j.State)
+	}
+
+	// not run yet
+	s.Cancel(b.ID)
+	waitState(t, s, b.ID, server.Cancelled)
+	s.Cancel(c.ID)
+	waitState(t, s, c.ID, server.Cancelled)
+}
+
+func TestPreemptPause(t *testing.T) {
+	if !ffmpeg.CanPause {
+		t.Skip("pause not supported")
+	}
+	s := newScheduled(t, server.PreemptPause)
+	low, _ := s.Submit("-i slow.mp4 batch.mp4")
+	waitState(t, s, low.ID, server.Running)
+
+	high, _ := s.SubmitPriority("-i slow.mp4 live.mp4", 10)
+	waitState(t, s, high.ID, server.Running)
+	waitState(t, s, low.ID, server.Paused)
+	if lives := s.Running(0); len(lives) != 1 || lives[0].ID != high.ID {
+		t.Errorf("unexpected running jobs %+v", lives)
+	}
+
+	s.Cancel(high.ID)
+	waitState(t, s, low.ID, server.Running)
+	s.Cancel(low.ID)
+	waitState(t, s, low.ID, server.Cancelled)
+}
+
+func TestPreemptRequeue(t *testing.T) {
+	s := newScheduled(t, server.PreemptRequeue)
+	low, _ := s.Submit("-i slow.mp4 batch.mp4")
+	waitState(t, s, low.ID, server.Running)
+
+	high, _ := s.SubmitPriority("-i live.mp4 live.mp4", 10)
+	waitState(t, s, high.ID, server.Succeeded)
+	// run again from the start
+	waitState(t, s, low.ID, server.Running)
+	s.Cancel(low.ID)
+	waitState(t, s, low.ID, server.Cancelled)
+}
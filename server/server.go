@@ -0,0 +1,539 @@
+/*
+Package server exposes ffmpeg runs as jobs over HTTP, to deploy
+a transcode worker, and provides a Client running FFmpeg through
+such a worker.
+
+The API is JSON over HTTP:
+
+	POST   /jobs           submit {"arg": "...", "priority": 0}, returns the Job
+	GET    /jobs           list the jobs
+	GET    /jobs/{id}      return the Job
+	DELETE /jobs/{id}      cancel the job
+	GET    /jobs/{id}/logs return the last stderr lines as text
+
+MaxRunning caps the running jobs, run by priority, the jobs of
+a higher priority preempting the others if configured so.
+
+Monitor returns a read-only handler of the running jobs, for
+dashboards.
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// The States of a Job.
+const (
+	Queued    = "queued"
+	Running   = "running"
+	Paused    = "paused" // preempted, see MaxRunning
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	Cancelled = "cancelled"
+)
+
+// A Job is the status of a run as returned by the API.
+type Job struct {
+	ID       string          `json:"id"`
+	Arg      string          `json:"arg"` // redacted by the ffmpeg.DefaultRedactor
+	State    string          `json:"state"`
+	Priority int             `json:"priority,omitempty"`
+	Progress ffmpeg.Progress `json:"progress"`
+	Error    string          `json:"error,omitempty"` // without the command
+	Created  time.Time       `json:"created"`
+	Started  time.Time       `json:"started"`
+	Ended    time.Time       `json:"ended"`
+}
+
+// String describes j for logs, e.g. "job 3 failed: exit
+// status 1 (arg: -i in.mp4 out.mp4)".
+func (j Job) String() string {
+	s := "job " + j.ID + " " + j.State
+	if j.Error != "" {
+		s += ": " + j.Error
+	}
+	return s + " (arg: " + j.Arg + ")"
+}
+
+// Done reports whether the job is over.
+func (j *Job) Done() bool {
+	return j.State == Succeeded || j.State == Failed || j.State == Cancelled
+}
+
+// A Server runs the jobs submitted over HTTP. It implements
+// http.Handler.
+type Server struct {
+	opts      []func(r *ffmpeg.HookedRunner)
+	mw        ffmpeg.Middleware
+	logLines  int
+	store     Store
+	notifiers []*Notifier
+	logger    *slog.Logger
+
+	maxRunning int
+	preempt    Preemption
+
+	mux     *http.ServeMux
+	mu      sync.Mutex
+	seq     int
+	jobs    map[string]*job
+	wg      sync.WaitGroup
+	closing bool
+
+	notifying sync.WaitGroup
+}
+
+type job struct {
+	Job
+	arg     string
+	cancel  context.CancelFunc
+	stopped bool // cancelled by Cancel
+	logs    []string
+	pid     int
+
+	proc      *ffmpeg.Process
+	launched  bool // its run is in progress
+	preempted bool // stopped to be queued again
+}
+
+// New returns a Server.
+func New(opts ...func(s *Server)) *Server {
+	s := &Server{
+		logLines: 100,
+		jobs:     make(map[string]*job),
+		mux:      http.NewServeMux(),
+	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	s.mux.HandleFunc("/jobs", s.handleJobs)
+	s.mux.HandleFunc("/jobs/", s.handleJob)
+	return s
+}
+
+// RunnerOptions sets the options of the HookedRunner running
+// every job.
+func RunnerOptions(opts ...func(r *ffmpeg.HookedRunner)) func(s *Server) {
+	return func(s *Server) {
+		s.opts = opts
+	}
+}
+
+// Use wraps the runner of every job with mw, e.g. a Limiter
+// to cap the concurrent jobs, which then stay Queued.
+func Use(mw ffmpeg.Middleware) func(s *Server) {
+	return func(s *Server) {
+		s.mw = mw
+	}
+}
+
+// LogLines sets how many stderr lines are kept per job, 100
+// by default.
+func LogLines(n int) func(s *Server) {
+	return func(s *Server) {
+		s.logLines = n
+	}
+}
+
+// WithStore persists the jobs in st, see Resume.
+func WithStore(st Store) func(s *Server) {
+	return func(s *Server) {
+		s.store = st
+	}
+}
+
+// Logger sets the logger reporting the failures to persist
+// or notify jobs, slog.Default() by default.
+func Logger(l *slog.Logger) func(s *Server) {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+// Submit starts a job running FFmpeg with arg and returns it.
+// With a Store, the job is only started once saved.
+func (s *Server) Submit(arg string) (Job, error) {
+	return s.SubmitPriority(arg, 0)
+}
+
+// SubmitPriority is like Submit for a job of priority, higher
+// being more urgent, see MaxRunning.
+func (s *Server) SubmitPriority(arg string, priority int) (Job, error) {
+	s.mu.Lock()
+	s.seq++
+	j := &job{
+		Job: Job{
+			ID:       strconv.Itoa(s.seq),
+			Arg:      ffmpeg.DefaultRedactor.String(arg),
+			State:    Queued,
+			Priority: priority,
+			Created:  time.Now(),
+		},
+		arg: arg,
+	}
+	s.mu.Unlock()
+
+	if s.store != nil {
+		if err := s.store.Put(j.record()); err != nil {
+			return Job{}, err
+		}
+	}
+	return s.start(j), nil
+}
+
+// Resume loads the jobs of the Store and runs again the ones
+// that were Queued or Running when the previous Server stopped.
+// It should be called once, before any Submit.
+func (s *Server) Resume() error {
+	if s.store == nil {
+		return nil
+	}
+	recs, err := s.store.List()
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range recs {
+		j := &job{Job: rec.Job, arg: rec.Arg, cancel: func() {}}
+		j.Job.Arg = ffmpeg.DefaultRedactor.String(rec.Arg)
+
+		s.mu.Lock()
+		if n, err := strconv.Atoi(j.ID); err == nil && n > s.seq {
+			s.seq = n
+		}
+		s.jobs[j.ID] = j
+		s.mu.Unlock()
+		if j.Done() {
+			continue
+		}
+
+		j.State, j.Started, j.Progress = Queued, time.Time{}, ffmpeg.Progress{}
+		s.start(j)
+	}
+	return nil
+}
+
+// start queues j, run in the background once scheduled.
+func (s *Server) start(j *job) Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.cancel = func() {}
+	s.jobs[j.ID] = j
+	snapshot := j.Job
+	s.schedule()
+	return snapshot
+}
+
+// launch runs j in the background, s.mu being held. The run
+// has the label "job" set to the ID of j, see ffmpeg.Labels.
+func (s *Server) launch(j *job) {
+	ctx, cancel := context.WithCancel(ffmpeg.WithLabels(context.Background(), ffmpeg.Labels{"job": j.ID}))
+	arg := j.arg
+	j.cancel = cancel
+	j.launched = true
+
+	opts := append(s.opts[:len(s.opts):len(s.opts)],
+		ffmpeg.Subscribe(func(e ffmpeg.Event) { s.event(j, e) }),
+		ffmpeg.LineHook(func(line string) { s.line(j, line) }),
+		ffmpeg.OnProcess(func(p *ffmpeg.Process) {
+			s.mu.Lock()
+			j.proc = p
+			s.mu.Unlock()
+		}))
+	var r ffmpeg.Runner = ffmpeg.HookRunner(opts...)
+	if s.mw != nil {
+		r = s.mw(r)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		err := r.Run(ctx, arg)
+
+		s.mu.Lock()
+		j.launched, j.proc = false, nil
+		if j.preempted && !j.stopped && !s.closing {
+			j.preempted = false
+			j.State, j.Started, j.Progress, j.pid = Queued, time.Time{}, ffmpeg.Progress{}, 0
+			rec := j.record()
+			s.schedule()
+			s.mu.Unlock()
+			s.save(rec)
+			return
+		}
+		j.Ended = time.Now()
+		switch {
+		case ctx.Err() != nil:
+			j.State = Cancelled
+		case err != nil:
+			j.State = Failed
+		default:
+			j.State = Succeeded
+		}
+		if err != nil {
+			// the command is the Arg already
+			var re *ffmpeg.RunError
+			if errors.As(err, &re) {
+				err = re.Err
+			}
+			j.Error = err.Error()
+		}
+		// left as is in the Store to be resumed
+		interrupted := s.closing && !j.stopped && ctx.Err() != nil
+		rec := j.record()
+		s.schedule()
+		s.mu.Unlock()
+		if !interrupted {
+			s.save(rec)
+			s.notify(rec.Job)
+		}
+	}()
+}
+
+// record returns j to persist, s.mu being held.
+func (j *job) record() Record {
+	return Record{Job: j.Job, Arg: j.arg}
+}
+
+// save persists rec, if s has a Store.
+func (s *Server) save(rec Record) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Put(rec); err != nil {
+		s.log().Error("server: failed to save job", "id", rec.Job.ID, "err", err)
+	}
+}
+
+// notify calls the Notifiers of s with j, in the background not
+// to block the caller on a slow URL.
+func (s *Server) notify(j Job) {
+	for _, n := range s.notifiers {
+		s.notifying.Add(1)
+		go func(n *Notifier) {
+			defer s.notifying.Done()
+			if err := n.Notify(context.Background(), j); err != nil {
+				s.log().Error("server: failed to notify job", "id", j.ID, "err", err)
+			}
+		}(n)
+	}
+}
+
+func (s *Server) log() *slog.Logger {
+	if s.logger == nil {
+		return slog.Default()
+	}
+	return s.logger
+}
+
+func (s *Server) event(j *job, e ffmpeg.Event) {
+	s.mu.Lock()
+	switch e := e.(type) {
+	case ffmpeg.Started:
+		j.State = Running
+		j.Started = time.Now()
+		j.pid = e.Pid
+		rec := j.record()
+		s.mu.Unlock()
+		s.save(rec)
+		return
+	case ffmpeg.Progress:
+		j.Progress = e
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) line(j *job, line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(j.logs) >= s.logLines {
+		j.logs = j.logs[1:]
+	}
+	j.logs = append(j.logs, line)
+}
+
+// Status returns the job with id.
+func (s *Server) Status(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return j.Job, true
+}
+
+// Cancel cancels the job with id, which is Cancelled once
+// FFmpeg exited, or right away if it was not run yet.
+func (s *Server) Cancel(id string) bool {
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	if !ok || j.Done() {
+		s.mu.Unlock()
+		return ok
+	}
+	j.stopped = true
+	if !j.launched {
+		j.State, j.Ended = Cancelled, time.Now()
+		rec := j.record()
+		s.mu.Unlock()
+		s.save(rec)
+		s.notify(rec.Job)
+		return true
+	}
+	if j.State == Paused {
+		// resumed to stop, e.g. with ffmpeg.StopViaStdin
+		j.proc.Resume()
+	}
+	s.mu.Unlock()
+	j.cancel()
+	return true
+}
+
+// Logs returns the last stderr lines of the job with id.
+func (s *Server) Logs(id string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	return append([]string(nil), j.logs...), true
+}
+
+// Remove forgets the job with id, which must be over, also
+// deleting it from the Store.
+func (s *Server) Remove(id string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	if ok && !j.Done() {
+		s.mu.Unlock()
+		return errors.New("server: job " + id + " is not over")
+	}
+	delete(s.jobs, id)
+	s.mu.Unlock()
+
+	if s.store != nil {
+		return s.store.Delete(id)
+	}
+	return nil
+}
+
+// Jobs returns all the jobs, in submission order.
+func (s *Server) Jobs() []Job {
+	s.mu.Lock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.Job)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(jobs, func(a, b int) bool {
+		x, _ := strconv.Atoi(jobs[a].ID)
+		y, _ := strconv.Atoi(jobs[b].ID)
+		return x < y
+	})
+	return jobs
+}
+
+// Close cancels all the jobs and waits for them, and for their
+// notifications, to be over.
+// The jobs it interrupts are not saved as Cancelled, so that
+// a new Server resumes them.
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closing = true
+	for _, j := range s.jobs {
+		if j.State == Paused {
+			j.proc.Resume()
+		}
+		j.cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	s.notifying.Wait()
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mux.ServeHTTP(w, req)
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.Jobs())
+	case http.MethodPost:
+		var body struct {
+			Arg      string `json:"arg"`
+			Priority int    `json:"priority"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		j, err := s.SubmitPriority(body.Arg, body.Priority)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, j)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, req *http.Request) {
+	id, sub, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/jobs/"), "/")
+	switch {
+	case sub == "logs" && req.Method == http.MethodGet:
+		logs, ok := s.Logs(id)
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, l := range logs {
+			w.Write([]byte(l + "\n"))
+		}
+	case sub == "" && req.Method == http.MethodGet:
+		j, ok := s.Status(id)
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		writeJSON(w, http.StatusOK, j)
+	case sub == "" && req.Method == http.MethodDelete:
+		if !s.Cancel(id) {
+			http.NotFound(w, req)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case sub == "" || sub == "logs":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
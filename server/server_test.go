@@ -0,0 +1,142 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/practigo/ffmpeg/server"
+)
+
+func fakeBinary(t *testing.T, body string) string {
+	p := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(p, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+const fakeJob = `echo "frame=  100 fps= 25 size=512kB time=00:00:04.00 speed=1x" >&2
+case "$*" in *fail*) echo "boom" >&2; exit 1;; *slow*) exec sleep 10;; esac
+`
+
+func start(t *testing.T) (*server.Server, *server.Client) {
+	s := server.New(server.RunnerOptions(ffmpeg.CustomPath(fakeBinary(t, fakeJob))))
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	c := server.NewClient(ts.URL)
+	c.Poll = 10 * time.Millisecond
+	return s, c
+}
+
+func TestClientRun(t *testing.T) {
+	s, c := start(t)
+	if err := c.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+
+	var je *server.JobError
+	if err := c.Run(context.TODO(), "-i fail.mp4 out.mp4"); !errors.As(err, &je) || je.Job.State != server.Failed {
+		t.Fatalf("want failed job, got %v", err)
+	}
+	if je.Job.Error != "exit status 1" {
+		t.Errorf("unexpected job error %q", je.Job.Error)
+	}
+	logs, err := c.Logs(context.TODO(), je.Job.ID)
+	if err != nil || !strings.Contains(logs, "boom") {
+		t.Errorf("unexpected logs %q: %v", logs, err)
+	}
+
+	// zero value, polling every second
+	if err := (&server.Client{URL: c.URL}).Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 3 || jobs[0].State != server.Succeeded || jobs[0].Progress.Frame != 100 {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	s, c := start(t)
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+	if err := c.Run(ctx, "-i slow.mp4 out.mp4"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded, got %v", err)
+	}
+
+	waitState(t, s, "1", server.Cancelled)
+}
+
+func TestNotFound(t *testing.T) {
+	_, c := start(t)
+	if _, err := c.Status(context.TODO(), "42"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("want 404, got %v", err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	p := fakeBinary(t, fakeJob)
+	st := server.MemoryStore()
+	s := server.New(server.RunnerOptions(ffmpeg.CustomPath(p)), server.WithStore(st))
+	done, _ := s.Submit("-i in.mp4 out.mp4")
+	slow, err := s.Submit("-i slow.mp4 -headers secret out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, s, done.ID, server.Succeeded)
+	waitState(t, s, slow.ID, server.Running)
+	s.Close()
+
+	s = server.New(server.RunnerOptions(ffmpeg.CustomPath(p)), server.WithStore(st))
+	defer s.Close()
+	if err = s.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, s, slow.ID, server.Running)
+	if j, _ := s.Status(done.ID); j.State != server.Succeeded {
+		t.Errorf("unexpected finished job %+v", j)
+	}
+	if j, _ := s.Status(slow.ID); strings.Contains(j.Arg, "secret") {
+		t.Errorf("arg not redacted: %s", j.Arg)
+	}
+	if j, _ := s.Submit("-i in.mp4 out.mp4"); j.ID != "3" {
+		t.Errorf("unexpected new job ID %s", j.ID)
+	}
+}
+
+func waitState(t *testing.T, s *server.Server, id, state string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		j, _ := s.Status(id)
+		if j.State == state {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s not %s: %+v", id, state, j)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobString(t *testing.T) {
+	j := server.Job{ID: "3", Arg: "-i fail.mp4 out.mp4", State: server.Failed, Error: "exit status 1"}
+	if s := j.String(); s != "job 3 failed: exit status 1 (arg: -i fail.mp4 out.mp4)" {
+		t.Errorf("unexpected %q", s)
+	}
+	j = server.Job{ID: "4", Arg: "-i in.mp4 out.mp4", State: server.Queued}
+	if s := j.String(); s != "job 4 queued (arg: -i in.mp4 out.mp4)" {
+		t.Errorf("unexpected %q", s)
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"sort"
+	"sync"
+)
+
+// A Record is a job as persisted in a Store.
+type Record struct {
+	Job Job `json:"job"`
+
+	// Arg is the arg the job runs FFmpeg with, not redacted
+	// to run the job again: secure the Store accordingly.
+	Arg string `json:"arg"`
+}
+
+// A Store persists the jobs of a Server, for a new Server to
+// resume the interrupted ones. See WithStore.
+type Store interface {
+	// Put saves rec, replacing any record with the same ID.
+	Put(rec Record) error
+	// Delete removes the record with id, if any.
+	Delete(id string) error
+	// List returns all the records.
+	List() ([]Record, error)
+}
+
+// MemoryStore returns a Store keeping the records in memory,
+// to resume the jobs of a Server closed by another one in the
+// same process.
+func MemoryStore() Store {
+	return &memStore{recs: make(map[string]Record)}
+}
+
+type memStore struct {
+	mu   sync.Mutex
+	recs map[string]Record
+}
+
+func (m *memStore) Put(rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recs[rec.Job.ID] = rec
+	return nil
+}
+
+func (m *memStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.recs, id)
+	return nil
+}
+
+func (m *memStore) List() ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	recs := make([]Record, 0, len(m.recs))
+	for _, rec := range m.recs {
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(a, b int) bool {
+		return recs[a].Job.Created.Before(recs[b].Job.Created)
+	})
+	return recs, nil
+}
@@ -0,0 +1,21 @@
+package ffmpeg
+
+import (
+	"os"
+	"syscall"
+)
+
+// ForwardSignals stops FFmpeg with the exit hook (see DoneHook
+// and StopViaStdin for a graceful stop) when the program gets
+// one of sigs, SIGINT and SIGTERM by default, instead of it
+// being orphaned, e.g. for a Ctrl-C on a CLI. The signals are
+// not handled by the default Go handlers during the runs, and
+// the error returned wraps ErrInterrupted.
+func ForwardSignals(sigs ...os.Signal) func(r *HookedRunner) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	return func(r *HookedRunner) {
+		r.signals = sigs
+	}
+}
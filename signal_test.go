@@ -0,0 +1,31 @@
+//go:build unix
+
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestForwardSignals(t *testing.T) {
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep"), ffmpeg.ForwardSignals(syscall.SIGUSR1),
+		ffmpeg.PostHook(func(*exec.Cmd) {
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+			}()
+		}))
+	res, err := r.RunResult(context.TODO(), "10")
+	if !errors.Is(err, ffmpeg.ErrInterrupted) || errors.Is(err, ffmpeg.ErrCancelled) {
+		t.Errorf("want interrupted, got %v", err)
+	}
+	if res.Reason != ffmpeg.StopInterrupted || res.Cancelled || res.Duration > 5*time.Second {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,37 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// LiveSnapshot returns a JPEG of the current frame of a live
+// source such as RTSP, RTMP or HLS, running FFmpeg with a
+// HookedRunner built with opts. The analysis of the source is
+// kept short for low latency, and the run is stopped after
+// 10s, unless opts set another Timeout.
+func LiveSnapshot(ctx context.Context, url string, opts ...func(r *HookedRunner)) ([]byte, error) {
+	in := []string{"-nostdin -v error -fflags nobuffer -flags low_delay -analyzeduration 1000000 -probesize 1000000"}
+	if strings.HasPrefix(url, "rtsp://") || strings.HasPrefix(url, "rtsps://") {
+		in = append(in, "-rtsp_transport tcp")
+	}
+	arg := strings.Join(append(in, "-i", url, "-map 0:v:0 -frames:v 1 -q:v 2 -c:v mjpeg -f image2pipe -"), " ")
+
+	var out bytes.Buffer
+	opts = append([]func(r *HookedRunner){Timeout(10 * time.Second)}, opts...)
+	opts = append(opts, withSetup(func(cmd *exec.Cmd) (func(), error) {
+		cmd.Stdout = &out
+		return func() {}, nil
+	}))
+	if err := HookRunner(opts...).Run(ctx, arg); err != nil {
+		return nil, err
+	}
+	if out.Len() == 0 {
+		return nil, errors.New("ffmpeg: no frame")
+	}
+	return out.Bytes(), nil
+}
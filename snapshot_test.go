@@ -0,0 +1,41 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestLiveSnapshot(t *testing.T) {
+	args := filepath.Join(t.TempDir(), "args")
+	p := fakeBinary(t, `echo "$@" > `+args+"\nprintf '\\377\\330jpeg'\n")
+	b, err := ffmpeg.LiveSnapshot(context.TODO(), "rtsp://cam1/stream", ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "\xff\xd8jpeg" {
+		t.Errorf("unexpected snapshot %q", b)
+	}
+	if a, _ := os.ReadFile(args); !strings.Contains(string(a), "-rtsp_transport tcp -i rtsp://cam1/stream -map 0:v:0 -frames:v 1") {
+		t.Errorf("unexpected args %q", a)
+	}
+}
+
+func TestLiveSnapshotTimeout(t *testing.T) {
+	p := fakeBinary(t, "exec sleep 10\n")
+	start := time.Now()
+	_, err := ffmpeg.LiveSnapshot(context.TODO(), "rtmp://live/app", ffmpeg.CustomPath(p), ffmpeg.Timeout(100*time.Millisecond))
+	if !errors.Is(err, ffmpeg.ErrTimeout) || time.Since(start) > 5*time.Second {
+		t.Errorf("want ErrTimeout, got %v", err)
+	}
+
+	if _, err = ffmpeg.LiveSnapshot(context.TODO(), "rtmp://live/app", ffmpeg.CustomPath(fakeBinary(t, ""))); err == nil {
+		t.Error("want an error without a frame")
+	}
+}
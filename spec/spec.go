@@ -0,0 +1,241 @@
+/*
+Package spec describes FFmpeg jobs in YAML or JSON, for config
+driven pipelines, e.g.
+
+	inputs:
+	  - path: in.mov
+	    seek: 10s
+	outputs:
+	  - path: out/index.m3u8
+	    video_codec: libx264
+	    video_bitrate: 3M
+	    video_filters: [scale=1280:-2]
+	    audio_codec: aac
+	    hls: {segment_time: 6s, playlist_type: vod}
+	limits: {timeout: 1h, threads: 4}
+
+The paths and values must not have spaces, the args of a run
+being split on them.
+*/
+package spec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+	"gopkg.in/yaml.v3"
+)
+
+// A Job is a FFmpeg run.
+type Job struct {
+	Inputs        []Input  `json:"inputs" yaml:"inputs"`
+	FilterComplex string   `json:"filter_complex,omitempty" yaml:"filter_complex,omitempty"`
+	Outputs       []Output `json:"outputs" yaml:"outputs"`
+	Limits        Limits   `json:"limits" yaml:"limits,omitempty"`
+}
+
+// An Input is an input of a Job.
+type Input struct {
+	Path     string            `json:"path" yaml:"path"`
+	Format   string            `json:"format,omitempty" yaml:"format,omitempty"`
+	Seek     Duration          `json:"seek,omitempty" yaml:"seek,omitempty"`
+	Duration Duration          `json:"duration,omitempty" yaml:"duration,omitempty"`
+	Options  map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// An Output is an output of a Job.
+type Output struct {
+	Path         string            `json:"path" yaml:"path"`
+	Format       string            `json:"format,omitempty" yaml:"format,omitempty"`
+	Map          []string          `json:"map,omitempty" yaml:"map,omitempty"`
+	VideoCodec   string            `json:"video_codec,omitempty" yaml:"video_codec,omitempty"`
+	VideoBitrate string            `json:"video_bitrate,omitempty" yaml:"video_bitrate,omitempty"`
+	VideoFilters []string          `json:"video_filters,omitempty" yaml:"video_filters,omitempty"`
+	AudioCodec   string            `json:"audio_codec,omitempty" yaml:"audio_codec,omitempty"`
+	AudioBitrate string            `json:"audio_bitrate,omitempty" yaml:"audio_bitrate,omitempty"`
+	AudioFilters []string          `json:"audio_filters,omitempty" yaml:"audio_filters,omitempty"`
+	HLS          *HLS              `json:"hls,omitempty" yaml:"hls,omitempty"`
+	Options      map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// HLS are the options of a HLS output.
+type HLS struct {
+	SegmentTime     Duration `json:"segment_time,omitempty" yaml:"segment_time,omitempty"`
+	PlaylistType    string   `json:"playlist_type,omitempty" yaml:"playlist_type,omitempty"` // "vod" or "event"
+	ListSize        int      `json:"list_size,omitempty" yaml:"list_size,omitempty"`
+	SegmentFilename string   `json:"segment_filename,omitempty" yaml:"segment_filename,omitempty"`
+}
+
+// Limits are the limits of a run of a Job.
+type Limits struct {
+	Timeout     Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	MaxDuration Duration `json:"max_duration,omitempty" yaml:"max_duration,omitempty"` // of the outputs
+	Threads     int      `json:"threads,omitempty" yaml:"threads,omitempty"`
+}
+
+// A Duration is a time.Duration given as "1m30s" or as seconds.
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(b []byte) error {
+	if v, err := time.ParseDuration(string(b)); err == nil {
+		*d = Duration(v)
+		return nil
+	}
+	s, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return errors.New("spec: invalid duration " + strconv.Quote(string(b)))
+	}
+	*d = Duration(s * float64(time.Second))
+	return nil
+}
+
+// IsZero reports whether d is zero, for omitempty.
+func (d Duration) IsZero() bool {
+	return d == 0
+}
+
+func (d Duration) arg() string {
+	return strconv.FormatFloat(time.Duration(d).Seconds(), 'f', -1, 64)
+}
+
+// Unmarshal parses a Job from YAML, or JSON which YAML is a
+// superset of.
+func Unmarshal(b []byte) (*Job, error) {
+	j := &Job{}
+	if err := yaml.Unmarshal(b, j); err != nil {
+		return nil, fmt.Errorf("spec: %w", err)
+	}
+	return j, nil
+}
+
+// Marshal returns the JSON of j, which Unmarshal parses back.
+func Marshal(j *Job) ([]byte, error) {
+	return json.Marshal(j)
+}
+
+// Arg returns the FFmpeg arg of j.
+func (j *Job) Arg() (string, error) {
+	if len(j.Inputs) == 0 || len(j.Outputs) == 0 {
+		return "", errors.New("spec: a job needs inputs and outputs")
+	}
+	a := &args{}
+	for _, in := range j.Inputs {
+		a.add("-f", in.Format)
+		if in.Seek != 0 {
+			a.add("-ss", in.Seek.arg())
+		}
+		if in.Duration != 0 {
+			a.add("-t", in.Duration.arg())
+		}
+		a.options(in.Options)
+		a.add("-i", in.Path)
+	}
+	a.add("-filter_complex", j.FilterComplex)
+
+	for _, out := range j.Outputs {
+		for _, m := range out.Map {
+			a.add("-map", m)
+		}
+		a.add("-c:v", out.VideoCodec)
+		a.add("-b:v", out.VideoBitrate)
+		a.add("-vf", strings.Join(out.VideoFilters, ","))
+		a.add("-c:a", out.AudioCodec)
+		a.add("-b:a", out.AudioBitrate)
+		a.add("-af", strings.Join(out.AudioFilters, ","))
+		if j.Limits.MaxDuration != 0 {
+			a.add("-t", j.Limits.MaxDuration.arg())
+		}
+		if j.Limits.Threads != 0 {
+			a.add("-threads", strconv.Itoa(j.Limits.Threads))
+		}
+		if h := out.HLS; h != nil {
+			a.add("-f", "hls")
+			if h.SegmentTime != 0 {
+				a.add("-hls_time", h.SegmentTime.arg())
+			}
+			a.add("-hls_playlist_type", h.PlaylistType)
+			if h.ListSize != 0 {
+				a.add("-hls_list_size", strconv.Itoa(h.ListSize))
+			}
+			a.add("-hls_segment_filename", h.SegmentFilename)
+		} else {
+			a.add("-f", out.Format)
+		}
+		a.options(out.Options)
+		if out.Path == "" || strings.ContainsAny(out.Path, " \t\n") {
+			return "", fmt.Errorf("spec: invalid output path %q", out.Path)
+		}
+		a.args = append(a.args, out.Path)
+	}
+	if a.err != nil {
+		return "", a.err
+	}
+	return strings.Join(a.args, " "), nil
+}
+
+// RunnerOptions returns the options of the runner of j.
+func (j *Job) RunnerOptions() []func(r *ffmpeg.HookedRunner) {
+	var opts []func(r *ffmpeg.HookedRunner)
+	if j.Limits.Timeout != 0 {
+		opts = append(opts, ffmpeg.Timeout(time.Duration(j.Limits.Timeout)))
+	}
+	return opts
+}
+
+// Run runs j with a HookedRunner built with its RunnerOptions
+// then opts.
+func (j *Job) Run(ctx context.Context, opts ...func(r *ffmpeg.HookedRunner)) error {
+	arg, err := j.Arg()
+	if err != nil {
+		return err
+	}
+	return ffmpeg.HookRunner(append(j.RunnerOptions(), opts...)...).Run(ctx, arg)
+}
+
+// args builds an arg, recording the first invalid value.
+type args struct {
+	args []string
+	err  error
+}
+
+// add appends flag and v, unless v is empty.
+func (a *args) add(flag, v string) {
+	if v == "" {
+		return
+	}
+	if strings.ContainsAny(v, " \t\n") && a.err == nil {
+		a.err = fmt.Errorf("spec: %s %q has spaces", flag, v)
+	}
+	a.args = append(a.args, flag, v)
+}
+
+// options appends opts in key order, an empty value adding the
+// flag alone.
+func (a *args) options(opts map[string]string) {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		flag := "-" + strings.TrimPrefix(k, "-")
+		if opts[k] == "" {
+			a.args = append(a.args, flag)
+			continue
+		}
+		a.add(flag, opts[k])
+	}
+}
@@ -0,0 +1,99 @@
+package spec_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/practigo/ffmpeg/spec"
+)
+
+const job = `
+inputs:
+  - path: in.mov
+    seek: 10s
+    options: {re: ""}
+  - path: logo.png
+filter_complex: "[0:v][1:v]overlay=10:10[v]"
+outputs:
+  - path: out/index.m3u8
+    map: ["[v]", 0:a]
+    video_codec: libx264
+    video_bitrate: 3M
+    audio_codec: aac
+    audio_bitrate: 128k
+    hls: {segment_time: 6, playlist_type: vod, segment_filename: out/%03d.ts}
+  - path: thumb.jpg
+    video_filters: [fps=1, scale=320:-2]
+    options: {frames:v: "1"}
+limits: {timeout: 1h, threads: 4}
+`
+
+func TestArg(t *testing.T) {
+	j, err := spec.Unmarshal([]byte(job))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arg, err := j.Arg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-ss 10 -re -i in.mov -i logo.png -filter_complex [0:v][1:v]overlay=10:10[v] " +
+		"-map [v] -map 0:a -c:v libx264 -b:v 3M -c:a aac -b:a 128k -threads 4 " +
+		"-f hls -hls_time 6 -hls_playlist_type vod -hls_segment_filename out/%03d.ts out/index.m3u8 " +
+		"-vf fps=1,scale=320:-2 -threads 4 -frames:v 1 thumb.jpg"
+	if arg != want {
+		t.Errorf("got  %s\nwant %s", arg, want)
+	}
+
+	// persisted as JSON and back
+	b, err := spec.Marshal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := spec.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, j) {
+		t.Errorf("round trip through %s:\ngot  %+v\nwant %+v", b, back, j)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, s := range []string{
+		`{"inputs": [{"path": "in.mp4"}]}`,
+		`{"inputs": [{"path": "my in.mp4"}], "outputs": [{"path": "out.mp4"}]}`,
+		`{"inputs": [{"path": "in.mp4", "seek": "soon"}], "outputs": [{"path": "out.mp4"}]}`,
+	} {
+		j, err := spec.Unmarshal([]byte(s))
+		if err == nil {
+			_, err = j.Arg()
+		}
+		if err == nil {
+			t.Errorf("%s: want error", s)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "args")
+	p := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(p, []byte("#!/bin/sh\necho \"$@\" > "+out+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	j := &spec.Job{
+		Inputs:  []spec.Input{{Path: "in.mp4"}},
+		Outputs: []spec.Output{{Path: "out.mp4", VideoCodec: "copy"}},
+	}
+	if err := j.Run(context.TODO(), ffmpeg.CustomPath(p)); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(out); strings.TrimSpace(string(b)) != "-i in.mp4 -c:v copy out.mp4" {
+		t.Errorf("unexpected args %q", b)
+	}
+}
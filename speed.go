@@ -0,0 +1,53 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Atempo returns the atempo filters changing the tempo of an
+// audio by factor, keeping its pitch, chained in steps from 0.5
+// to 2 within which atempo sounds best, e.g. "atempo=2,atempo=1.5"
+// for 3. It returns "" if factor is not positive and finite.
+func Atempo(factor float64) string {
+	if !validFactor(factor) {
+		return ""
+	}
+	var steps []string
+	for ; factor > 2; factor /= 2 {
+		steps = append(steps, "atempo=2")
+	}
+	for ; factor < 0.5; factor /= 0.5 {
+		steps = append(steps, "atempo=0.5")
+	}
+	if factor != 1 || len(steps) == 0 {
+		steps = append(steps, "atempo="+strconv.FormatFloat(factor, 'f', -1, 64))
+	}
+	return strings.Join(steps, ",")
+}
+
+// Speed returns the arg writing input to output played factor
+// times faster, e.g. 0.25 for slow motion or 10 for a timelapse,
+// the audio either dropped or at the same tempo with its pitch
+// kept, see Atempo. The frame rate is kept, frames being dropped
+// or duplicated.
+func Speed(input string, factor float64, dropAudio bool, output string) (string, error) {
+	if !validFactor(factor) {
+		return "", fmt.Errorf("ffmpeg: invalid speed factor %v", factor)
+	}
+	f := strconv.FormatFloat(factor, 'f', -1, 64)
+	args := []string{"-y -i", input, "-map 0:v:0 -filter:v", "setpts=PTS/" + f}
+	if dropAudio {
+		args = append(args, "-an")
+	} else {
+		args = append(args, "-map 0:a? -filter:a", Atempo(factor))
+	}
+	return strings.Join(append(args, output), " "), nil
+}
+
+// validFactor reports whether factor is positive and finite.
+func validFactor(factor float64) bool {
+	return factor > 0 && !math.IsInf(factor, 1)
+}
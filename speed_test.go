@@ -0,0 +1,47 @@
+package ffmpeg_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestAtempo(t *testing.T) {
+	for f, want := range map[float64]string{
+		1:     "atempo=1",
+		1.5:   "atempo=1.5",
+		3:     "atempo=2,atempo=1.5",
+		8:     "atempo=2,atempo=2,atempo=2",
+		0.25:  "atempo=0.5,atempo=0.5",
+		0.3:   "atempo=0.5,atempo=0.6",
+		0.125: "atempo=0.5,atempo=0.5,atempo=0.5",
+	} {
+		if got := ffmpeg.Atempo(f); got != want {
+			t.Errorf("%v: got %q, want %q", f, got, want)
+		}
+	}
+	for _, f := range []float64{0, -2, math.Inf(1), math.NaN()} {
+		if got := ffmpeg.Atempo(f); got != "" {
+			t.Errorf("%v: got %q", f, got)
+		}
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	a, err := ffmpeg.Speed("in.mp4", 3, false, "fast.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != "-y -i in.mp4 -map 0:v:0 -filter:v setpts=PTS/3 -map 0:a? -filter:a atempo=2,atempo=1.5 fast.mp4" {
+		t.Errorf("unexpected arg %q", a)
+	}
+	if a, _ = ffmpeg.Speed("in.mp4", 0.25, true, "slow.mp4"); a != "-y -i in.mp4 -map 0:v:0 -filter:v setpts=PTS/0.25 -an slow.mp4" {
+		t.Errorf("unexpected arg %q", a)
+	}
+	for _, f := range []float64{0, math.Inf(1), math.Inf(-1), math.NaN()} {
+		if _, err = ffmpeg.Speed("in.mp4", f, true, "out.mp4"); err == nil {
+			t.Errorf("%v: want error", f)
+		}
+	}
+}
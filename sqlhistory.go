@@ -0,0 +1,83 @@
+package ffmpeg
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// A SQLHistory is a History storing the records in the table
+// ffmpeg_runs of a SQL database, e.g. SQLite, with the "?"
+// placeholders: the start in Unix nanoseconds, the reason and
+// the record as JSON.
+type SQLHistory struct {
+	db *sql.DB
+}
+
+// NewSQLHistory returns a SQLHistory of db, creating the table
+// and its index on start if needed.
+func NewSQLHistory(db *sql.DB) (*SQLHistory, error) {
+	for _, q := range []string{
+		"CREATE TABLE IF NOT EXISTS ffmpeg_runs (start BIGINT NOT NULL, reason VARCHAR(16) NOT NULL, record TEXT NOT NULL)",
+		"CREATE INDEX IF NOT EXISTS ffmpeg_runs_start ON ffmpeg_runs (start)",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			return nil, err
+		}
+	}
+	return &SQLHistory{db: db}, nil
+}
+
+// Add implements History.
+func (h *SQLHistory) Add(rec RunRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.Exec("INSERT INTO ffmpeg_runs (start, reason, record) VALUES (?, ?, ?)",
+		rec.Start.UnixNano(), rec.Reason.String(), string(b))
+	return err
+}
+
+// Query implements History.
+func (h *SQLHistory) Query(q HistoryQuery) ([]RunRecord, error) {
+	query := "SELECT record FROM ffmpeg_runs"
+	var conds []string
+	var args []interface{}
+	if !q.From.IsZero() {
+		conds = append(conds, "start >= ?")
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		conds = append(conds, "start < ?")
+		args = append(args, q.To.UnixNano())
+	}
+	if len(q.Reasons) > 0 {
+		conds = append(conds, "reason IN (?"+strings.Repeat(", ?", len(q.Reasons)-1)+")")
+		for _, r := range q.Reasons {
+			args = append(args, r.String())
+		}
+	}
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	rows, err := h.db.Query(query+" ORDER BY start", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var recs []RunRecord
+	for rows.Next() {
+		var b string
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		var rec RunRecord
+		if err := json.Unmarshal([]byte(b), &rec); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
@@ -0,0 +1,109 @@
+package ffmpeg_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+// fakeDB is a database/sql driver recording the statements and
+// returning the records inserted for every query.
+type fakeDB struct {
+	mu      sync.Mutex
+	execs   []string
+	queries []string
+	args    []driver.Value // of the last query
+	records []string
+}
+
+func (db *fakeDB) Open(name string) (driver.Conn, error) { return fakeConn{db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("no transactions") }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.execs = append(s.db.execs, s.query)
+	if strings.HasPrefix(s.query, "INSERT") {
+		s.db.records = append(s.db.records, args[2].(string))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.queries = append(s.db.queries, s.query)
+	s.db.args = args
+	return &fakeRows{records: append([]string(nil), s.db.records...)}, nil
+}
+
+type fakeRows struct{ records []string }
+
+func (r *fakeRows) Columns() []string { return []string{"record"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.records) == 0 {
+		return io.EOF
+	}
+	dest[0], r.records = r.records[0], r.records[1:]
+	return nil
+}
+
+func TestSQLHistory(t *testing.T) {
+	fake := &fakeDB{}
+	sql.Register("ffmpeg-fake", fake)
+	db, err := sql.Open("ffmpeg-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	h, err := ffmpeg.NewSQLHistory(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1700000000, 0)
+	if err = h.Add(ffmpeg.RunRecord{Start: start, Args: []string{"-i", "in.mp4", "out.mp4"}, Reason: ffmpeg.StopTimeout}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.execs) != 3 || !strings.HasPrefix(fake.execs[0], "CREATE TABLE IF NOT EXISTS ffmpeg_runs") {
+		t.Errorf("unexpected statements %q", fake.execs)
+	}
+
+	recs, err := h.Query(ffmpeg.HistoryQuery{
+		From:    start,
+		To:      start.Add(time.Hour),
+		Reasons: []ffmpeg.StopReason{ffmpeg.StopFailed, ffmpeg.StopTimeout},
+	})
+	if err != nil || len(recs) != 1 || recs[0].Reason != ffmpeg.StopTimeout || !recs[0].Start.Equal(start) {
+		t.Fatalf("unexpected records %+v: %v", recs, err)
+	}
+	want := "SELECT record FROM ffmpeg_runs WHERE start >= ? AND start < ? AND reason IN (?, ?) ORDER BY start"
+	if fake.queries[0] != want {
+		t.Errorf("got query %q", fake.queries[0])
+	}
+	if len(fake.args) != 4 || fake.args[0] != start.UnixNano() || fake.args[3] != "timeout" {
+		t.Errorf("unexpected args %v", fake.args)
+	}
+}
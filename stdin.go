@@ -0,0 +1,76 @@
+package ffmpeg
+
+import (
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// StopViaStdin makes the exit hook write "q" to the stdin of
+// FFmpeg, kept open for it: the most portable way to stop FFmpeg
+// cleanly, with the trailers of the outputs written. If FFmpeg
+// is still running after grace, it is killed. It replaces the
+// DoneHook, and replaces the -nostdin of the arg, as set by
+// Recorder or Check, and of GlobalArgs with -stdin.
+func StopViaStdin(grace time.Duration) func(r *HookedRunner) {
+	type stop struct {
+		stdin io.WriteCloser
+		kill  *time.Timer
+	}
+	var mu sync.Mutex
+	stops := make(map[*exec.Cmd]*stop)
+
+	return func(r *HookedRunner) {
+		r.rewrites = append(r.rewrites, withStdin)
+		r.setups = append(r.setups, func(cmd *exec.Cmd) (func(), error) {
+			w, err := cmd.StdinPipe()
+			if err != nil {
+				return nil, err
+			}
+			s := &stop{stdin: w}
+			mu.Lock()
+			stops[cmd] = s
+			mu.Unlock()
+			return func() {
+				mu.Lock()
+				delete(stops, cmd)
+				mu.Unlock()
+				if s.kill != nil {
+					s.kill.Stop()
+				}
+				w.Close()
+			}, nil
+		})
+		r.exit = func(cmd *exec.Cmd) {
+			mu.Lock()
+			s := stops[cmd]
+			mu.Unlock()
+			if s == nil {
+				cmd.Process.Kill()
+				return
+			}
+			s.kill = time.AfterFunc(grace, func() { cmd.Process.Kill() })
+			if _, err := io.WriteString(s.stdin, "q"); err != nil {
+				cmd.Process.Kill()
+			}
+		}
+	}
+}
+
+// withStdin returns args with its -nostdin left out and -stdin
+// first, overriding the GlobalArgs.
+func withStdin(args []string) []string {
+	out := []string{"-stdin"}
+	walkArgs(args, func(flag string, i int) bool {
+		if flag == "-nostdin" || flag == "-stdin" {
+			return true
+		}
+		out = append(out, args[i])
+		if flag != "" && takesValue(flag) && i+1 < len(args) {
+			out = append(out, args[i+1])
+		}
+		return true
+	})
+	return out
+}
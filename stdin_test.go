@@ -0,0 +1,55 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestStopViaStdin(t *testing.T) {
+	trailer := filepath.Join(t.TempDir(), "trailer")
+	// stops on q, writing the trailer
+	p := fakeBinary(t, `while c=$(dd bs=1 count=1 2>/dev/null) && [ -n "$c" ]; do
+  [ "$c" = q ] && { echo done > `+trailer+`; exit 255; }
+done
+`)
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.GlobalArgs("-nostdin -y"), ffmpeg.StopViaStdin(5*time.Second))
+	cmd, err := r.Plan(context.TODO(), "-nostdin -i in.mp4 out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "-y -stdin -i in.mp4 out.mp4" {
+		t.Errorf("unexpected args %q", got)
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	r.Run(ctx, "-nostdin -i in.mp4 out.mp4")
+	if time.Since(start) > 4*time.Second {
+		t.Error("not stopped by q")
+	}
+	if b, _ := os.ReadFile(trailer); string(b) != "done\n" {
+		t.Errorf("trailer not written: %q", b)
+	}
+}
+
+func TestStopViaStdinKill(t *testing.T) {
+	// ignores stdin
+	p := fakeBinary(t, "exec sleep 10\n")
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.StopViaStdin(200*time.Millisecond))
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	if err := r.Run(ctx, "-i in.mp4 out.mp4"); err == nil {
+		t.Error("want the kill error")
+	}
+	if d := time.Since(start); d < 300*time.Millisecond || d > 5*time.Second {
+		t.Errorf("not killed after the grace period: %s", d)
+	}
+}
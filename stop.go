@@ -0,0 +1,135 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrCancelled is returned, wrapping the ctx.Err() and the exit
+// error if any, when FFmpeg was stopped for its context being
+// done.
+var ErrCancelled = errors.New("ffmpeg: cancelled")
+
+// ErrStalled is returned, wrapping the exit error if any, when
+// FFmpeg was stopped by StopOnStall for not reporting progress.
+var ErrStalled = errors.New("ffmpeg: stalled")
+
+// ErrInterrupted is returned, wrapping the exit error if any,
+// when FFmpeg was stopped for a signal of ForwardSignals.
+var ErrInterrupted = errors.New("ffmpeg: interrupted")
+
+// A StopReason is why a run is over.
+type StopReason int
+
+// The StopReasons.
+const (
+	// StopFinished is a run FFmpeg exited successfully.
+	StopFinished StopReason = iota
+	// StopFailed is a run FFmpeg exited on its own with an
+	// error, or failed to start.
+	StopFailed
+	// StopCancelled is a run stopped for its context.
+	StopCancelled
+	// StopTimeout is a run stopped for its Timeout.
+	StopTimeout
+	// StopStalled is a run stopped by StopOnStall.
+	StopStalled
+	// StopInterrupted is a run stopped by ForwardSignals.
+	StopInterrupted
+	// StopQuota is a run stopped by DiskGuard.
+	StopQuota
+)
+
+func (s StopReason) String() string {
+	switch s {
+	case StopFinished:
+		return "finished"
+	case StopFailed:
+		return "failed"
+	case StopCancelled:
+		return "cancelled"
+	case StopTimeout:
+		return "timeout"
+	case StopStalled:
+		return "stalled"
+	case StopInterrupted:
+		return "interrupted"
+	case StopQuota:
+		return "quota"
+	}
+	return "StopReason(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s StopReason) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *StopReason) UnmarshalText(b []byte) error {
+	for r := StopFinished; r <= StopQuota; r++ {
+		if r.String() == string(b) {
+			*s = r
+			return nil
+		}
+	}
+	return fmt.Errorf("ffmpeg: unknown stop reason %q", b)
+}
+
+// StopOnStall stops FFmpeg with the exit hook (see DoneHook)
+// when it stalled, i.e. on the first Stalled event, the
+// StallTimeout being required. The error returned then wraps
+// ErrStalled.
+func StopOnStall() func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.stopStall = true
+	}
+}
+
+// stopReason returns the StopReason of a run stopped for cause,
+// or that exited on its own if nil.
+func stopReason(cause error, success bool) StopReason {
+	switch {
+	case cause == ErrTimeout:
+		return StopTimeout
+	case cause == ErrStalled:
+		return StopStalled
+	case cause == ErrInterrupted:
+		return StopInterrupted
+	case cause == ErrDiskFull || cause == ErrOutputTooLarge:
+		return StopQuota
+	case cause != nil:
+		return StopCancelled
+	case success:
+		return StopFinished
+	}
+	return StopFailed
+}
+
+// stopError returns the error of a run of r stopped for cause
+// (a ctx.Err(), ErrTimeout, ErrStalled, ErrInterrupted,
+// ErrDiskFull or ErrOutputTooLarge), wrapping the exit error err.
+func (r *HookedRunner) stopError(cause, err error) error {
+	var stop error
+	switch {
+	case cause == nil:
+		return err
+	case cause == ErrTimeout:
+		stop = fmt.Errorf("%w after %s", ErrTimeout, r.timeout)
+	case cause == ErrStalled:
+		stop = fmt.Errorf("%w for %s", ErrStalled, r.stall)
+	case cause == ErrInterrupted:
+		stop = ErrInterrupted
+	case cause == ErrDiskFull:
+		stop = fmt.Errorf("%w: under %d bytes free", ErrDiskFull, r.quota.MinFree)
+	case cause == ErrOutputTooLarge:
+		stop = fmt.Errorf("%w: over %d bytes", ErrOutputTooLarge, r.quota.MaxOutput)
+	default:
+		stop = fmt.Errorf("%w: %w", ErrCancelled, cause)
+	}
+	if err == nil {
+		return stop
+	}
+	return fmt.Errorf("%w: %w", stop, err)
+}
@@ -0,0 +1,66 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestStopReason(t *testing.T) {
+	var exited ffmpeg.Exited
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep"), ffmpeg.Subscribe(func(e ffmpeg.Event) {
+		if e, ok := e.(ffmpeg.Exited); ok {
+			exited = e
+		}
+	}))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	res, err := r.RunResult(ctx, "10")
+	var ee *exec.ExitError
+	if !errors.Is(err, ffmpeg.ErrCancelled) || !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &ee) {
+		t.Errorf("want cancelled exit error, got %v", err)
+	}
+	if res.Reason != ffmpeg.StopCancelled || exited.Reason != ffmpeg.StopCancelled {
+		t.Errorf("want cancelled, got %v and %v", res.Reason, exited.Reason)
+	}
+
+	if res, _ = r.RunResult(context.TODO(), "0"); res.Reason != ffmpeg.StopFinished {
+		t.Errorf("want finished, got %v", res.Reason)
+	}
+	if res, _ = r.RunResult(context.TODO(), "-x"); res.Reason != ffmpeg.StopFailed {
+		t.Errorf("want failed, got %v", res.Reason)
+	}
+	if ffmpeg.StopReason(9).String() != "StopReason(9)" {
+		t.Errorf("unexpected %v", ffmpeg.StopReason(9))
+	}
+}
+
+func TestStopOnStall(t *testing.T) {
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep"), ffmpeg.StallTimeout(30*time.Millisecond), ffmpeg.StopOnStall())
+	start := time.Now()
+	res, err := r.RunResult(context.TODO(), "10")
+	if !errors.Is(err, ffmpeg.ErrStalled) || errors.Is(err, ffmpeg.ErrCancelled) {
+		t.Errorf("want stalled, got %v", err)
+	}
+	if res.Reason != ffmpeg.StopStalled || res.Cancelled || time.Since(start) > 5*time.Second {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestStopReasonText(t *testing.T) {
+	var r ffmpeg.StopReason
+	if err := r.UnmarshalText([]byte("stalled")); err != nil || r != ffmpeg.StopStalled {
+		t.Errorf("got %v: %v", r, err)
+	}
+	if b, _ := ffmpeg.StopQuota.MarshalText(); string(b) != "quota" {
+		t.Errorf("got %s", b)
+	}
+	if err := r.UnmarshalText([]byte("unknown")); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,111 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// EscapeFilterValue escapes v for a filter option value in a
+// filtergraph, at both levels: the option value and the graph.
+func EscapeFilterValue(v string) string {
+	return escapeRunes(escapeRunes(v, `\':`), `\'[],;`)
+}
+
+func escapeRunes(s, special string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// A Subtitle is a subtitle track file, SRT, ASS or WebVTT.
+type Subtitle struct {
+	Path     string
+	Language string // ISO 639-2, e.g. "eng"
+	Default  bool
+}
+
+// Subtitles adds subtitle Tracks to Input into Output, either burnt
+// into the video or muxed as soft subtitle streams.
+type Subtitles struct {
+	Input  string
+	Output string
+	Tracks []Subtitle
+
+	// Burn renders the tracks onto the video, re-encoded with
+	// VideoCodec, libx264 by default. Style, in the ASS format
+	// of the subtitles filter force_style, e.g. "Fontsize=24",
+	// applies to SRT tracks.
+	Burn       bool
+	VideoCodec string
+	Style      string
+}
+
+// Args returns the arg to run FFmpeg with. When muxed, the
+// tracks replace the subtitle streams of Input, and are
+// converted to the codec of the Output container: mov_text for
+// MP4 and MOV, webvtt for WebM, copied otherwise.
+func (s Subtitles) Args() string {
+	if s.Burn {
+		return s.burnArgs()
+	}
+
+	args := []string{"-y -i", s.Input}
+	for _, t := range s.Tracks {
+		args = append(args, "-i", t.Path)
+	}
+	args = append(args, "-map 0 -map -0:s")
+	for i := range s.Tracks {
+		args = append(args, fmt.Sprintf("-map %d", i+1))
+	}
+	args = append(args, "-c copy -c:s", subtitleCodec(s.Output))
+	for i, t := range s.Tracks {
+		if t.Language != "" {
+			args = append(args, fmt.Sprintf("-metadata:s:s:%d language=%s", i, t.Language))
+		}
+		disp := "0"
+		if t.Default {
+			disp = "default"
+		}
+		args = append(args, fmt.Sprintf("-disposition:s:%d %s", i, disp))
+	}
+	return strings.Join(append(args, s.Output), " ")
+}
+
+func (s Subtitles) burnArgs() string {
+	fs := make([]string, len(s.Tracks))
+	for i, t := range s.Tracks {
+		fs[i] = "subtitles=filename=" + EscapeFilterValue(filterPath(t.Path))
+		if s.Style != "" && !strings.EqualFold(filepath.Ext(t.Path), ".ass") {
+			fs[i] += ":force_style=" + EscapeFilterValue(s.Style)
+		}
+	}
+	vc := s.VideoCodec
+	if vc == "" {
+		vc = "libx264"
+	}
+	return strings.Join([]string{"-y -i", s.Input, "-vf", strings.Join(fs, ","),
+		"-c:v", vc, "-c:a copy", s.Output}, " ")
+}
+
+// filterPath returns path for a filter option, with forward
+// slashes as Windows paths have their backslashes eaten by the
+// escaping.
+func filterPath(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+func subtitleCodec(output string) string {
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".mp4", ".m4v", ".mov":
+		return "mov_text"
+	case ".webm":
+		return "webvtt"
+	}
+	return "copy"
+}
@@ -0,0 +1,38 @@
+package ffmpeg_test
+
+import (
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestSubtitlesMux(t *testing.T) {
+	s := ffmpeg.Subtitles{
+		Input:  "in.mp4",
+		Output: "out.mp4",
+		Tracks: []ffmpeg.Subtitle{
+			{Path: "en.srt", Language: "eng", Default: true},
+			{Path: "fr.srt", Language: "fra"},
+		},
+	}
+	want := "-y -i in.mp4 -i en.srt -i fr.srt -map 0 -map -0:s -map 1 -map 2 -c copy -c:s mov_text " +
+		"-metadata:s:s:0 language=eng -disposition:s:0 default -metadata:s:s:1 language=fra -disposition:s:1 0 out.mp4"
+	if a := s.Args(); a != want {
+		t.Errorf("want\n%s\ngot\n%s", want, a)
+	}
+}
+
+func TestSubtitlesBurn(t *testing.T) {
+	s := ffmpeg.Subtitles{
+		Input:  "in.mp4",
+		Output: "out.mp4",
+		Tracks: []ffmpeg.Subtitle{{Path: `C:\subs\it's.srt`}, {Path: "signs.ass"}},
+		Burn:   true,
+		Style:  "Fontsize=24,PrimaryColour=&H00FFFF&",
+	}
+	want := `-y -i in.mp4 -vf subtitles=filename=C\\:/subs/it\\\'s.srt:force_style=Fontsize=24\,PrimaryColour=&H00FFFF&,` +
+		`subtitles=filename=signs.ass -c:v libx264 -c:a copy out.mp4`
+	if a := s.Args(); a != want {
+		t.Errorf("want\n%s\ngot\n%s", want, a)
+	}
+}
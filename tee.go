@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"sort"
+	"strings"
+)
+
+// A TeeOutput is an output of the tee muxer.
+type TeeOutput struct {
+	Path   string
+	Format string // the muxer, f=
+	Select string // the streams, e.g. "v:0,a", select=
+	BSFs   string // the bitstream filters, bsfs=
+
+	// IgnoreFailure keeps the other outputs going when this one
+	// fails, onfail=ignore.
+	IgnoreFailure bool
+
+	// Options are the options of the muxer, e.g. "hls_time".
+	Options map[string]string
+}
+
+// A Tee feeds several outputs from a single encode, e.g. HLS,
+// a RTMP push and an archive MP4. The streams are those mapped
+// before it, and most outputs need them encoded with
+// "-flags +global_header".
+type Tee []TeeOutput
+
+// String returns the escaped output of the tee muxer, e.g.
+// "[f=flv:onfail=ignore]rtmp://a/live|archive.mp4".
+func (t Tee) String() string {
+	outs := make([]string, len(t))
+	for i, o := range t {
+		opts := []string{}
+		add := func(k, v string) {
+			if v != "" {
+				opts = append(opts, k+"="+escapeRunes(v, `\:]`))
+			}
+		}
+		add("f", o.Format)
+		add("select", o.Select)
+		add("bsfs", o.BSFs)
+		if o.IgnoreFailure {
+			add("onfail", "ignore")
+		}
+		keys := make([]string, 0, len(o.Options))
+		for k := range o.Options {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add(k, o.Options[k])
+		}
+
+		var spec string
+		if len(opts) > 0 || strings.HasPrefix(o.Path, "[") {
+			spec = "[" + strings.Join(opts, ":") + "]"
+		}
+		outs[i] = escapeRunes(spec+o.Path, `\'|`)
+	}
+	return strings.Join(outs, "|")
+}
+
+// Args returns the output args of t. The paths should have no
+// spaces, the args being split on them.
+func (t Tee) Args() string {
+	return "-f tee " + t.String()
+}
@@ -0,0 +1,23 @@
+package ffmpeg_test
+
+import (
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestTee(t *testing.T) {
+	tee := ffmpeg.Tee{
+		{Path: "out/live.m3u8", Format: "hls", Options: map[string]string{"hls_time": "4", "hls_list_size": "6"}},
+		{Path: "rtmp://a/live/key", Format: "flv", Select: "v:0,a:0", IgnoreFailure: true},
+		{Path: "archive.mp4", BSFs: "a=aac_adtstoasc"},
+		{Path: "odd'|name.ts"},
+	}
+	want := `-f tee [f=hls:hls_list_size=6:hls_time=4]out/live.m3u8|` +
+		`[f=flv:select=v\\:0,a\\:0:onfail=ignore]rtmp://a/live/key|` +
+		`[bsfs=a=aac_adtstoasc]archive.mp4|` +
+		`odd\'\|name.ts`
+	if got := tee.Args(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A VarType is the type of a placeholder of a Template, which
+// its values are checked against.
+type VarType int
+
+// The VarTypes, StringVar being the default.
+const (
+	StringVar   VarType = iota // any value without spaces
+	PathVar                    // a path, which must not start with "-"
+	DurationVar                // "90s", "1m30s", "00:01:30" or "90.5", given as seconds
+	BitrateVar                 // "2500k", "3M" or "128000"
+	IntVar
+	FilterVar // a filter option value, escaped with EscapeFilterValue
+)
+
+var varTypes = []string{"string", "path", "duration", "bitrate", "int", "filter"}
+
+func (t VarType) String() string {
+	if t < 0 || int(t) >= len(varTypes) {
+		return "VarType(" + strconv.Itoa(int(t)) + ")"
+	}
+	return varTypes[t]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t VarType) MarshalText() ([]byte, error) {
+	if t < 0 || int(t) >= len(varTypes) {
+		return nil, errors.New("ffmpeg: invalid " + t.String())
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *VarType) UnmarshalText(b []byte) error {
+	for i, s := range varTypes {
+		if s == string(b) {
+			*t = VarType(i)
+			return nil
+		}
+	}
+	return errors.New("ffmpeg: unknown var type " + strconv.Quote(string(b)))
+}
+
+// A Template is an arg with named placeholders such as
+// "{input}", for the presets defined in config files, e.g.
+//
+//	{"arg": "-ss {start} -i {input} -c:v libx264 -b:v {rate} {output}",
+//	 "vars": {"start": "duration", "input": "path", "rate": "bitrate", "output": "path"}}
+//
+// The placeholders following a "%", such as the "%{pts}" of
+// drawtext, and WorkspaceVar are left as is.
+type Template struct {
+	Arg  string             `json:"arg"`
+	Vars map[string]VarType `json:"vars,omitempty"` // StringVar by default
+}
+
+var (
+	placeholderRe = regexp.MustCompile(`%?\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	clockRe       = regexp.MustCompile(`^\d+:\d{2}:\d{2}(\.\d+)?$`)
+)
+
+// Placeholders returns the names of the placeholders of t, in
+// order of appearance.
+func (t *Template) Placeholders() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range placeholderRe.FindAllStringSubmatch(t.Arg, -1) {
+		if !isPlaceholder(m[0]) || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		names = append(names, m[1])
+	}
+	return names
+}
+
+func isPlaceholder(m string) bool {
+	return m[0] != '%' && m != WorkspaceVar
+}
+
+// Execute returns the arg of t with its placeholders replaced
+// by values, which are checked against their VarType and must
+// not have spaces, so that they cannot inject args. Within a
+// filtergraph, a StringVar can still inject filters or options
+// with ",;[]:'\": the values there should be FilterVars. It
+// fails if a placeholder has no value.
+func (t *Template) Execute(values map[string]string) (string, error) {
+	var unbound []string
+	subst := make(map[string]string)
+	for _, name := range t.Placeholders() {
+		v, ok := values[name]
+		if !ok {
+			unbound = append(unbound, "{"+name+"}")
+			continue
+		}
+		s, err := t.Vars[name].format(v)
+		if err != nil {
+			return "", fmt.Errorf("ffmpeg: {%s}: %w", name, err)
+		}
+		subst[name] = s
+	}
+	if len(unbound) > 0 {
+		return "", errors.New("ffmpeg: unbound placeholders " + strings.Join(unbound, ", "))
+	}
+
+	return placeholderRe.ReplaceAllStringFunc(t.Arg, func(m string) string {
+		if !isPlaceholder(m) {
+			return m
+		}
+		return subst[m[1:len(m)-1]]
+	}), nil
+}
+
+// format checks v against t and returns it as an arg.
+func (t VarType) format(v string) (string, error) {
+	if v == "" || strings.ContainsAny(v, " \t\n") {
+		return "", fmt.Errorf("invalid value %q: empty or with spaces", v)
+	}
+	switch t {
+	case PathVar:
+		if strings.HasPrefix(v, "-") && v != "-" {
+			return "", fmt.Errorf("path %q would be read as an option", v)
+		}
+	case DurationVar:
+		if d, err := time.ParseDuration(v); err == nil {
+			return seconds(d), nil
+		}
+		if clockRe.MatchString(v) {
+			return seconds(parseClock(v)), nil
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "", fmt.Errorf("invalid duration %q", v)
+		}
+	case BitrateVar:
+		if bitrate(v) <= 0 {
+			return "", fmt.Errorf("invalid bitrate %q", v)
+		}
+	case IntVar:
+		if _, err := strconv.Atoi(v); err != nil {
+			return "", fmt.Errorf("invalid int %q", v)
+		}
+	case FilterVar:
+		return EscapeFilterValue(v), nil
+	case StringVar:
+	default:
+		return "", errors.New("invalid " + t.String())
+	}
+	return v, nil
+}
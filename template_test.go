@@ -0,0 +1,55 @@
+package ffmpeg_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestTemplate(t *testing.T) {
+	var tmpl ffmpeg.Template
+	err := json.Unmarshal([]byte(`{
+		"arg": "-ss {start} -i {input} -c:v libx264 -b:v {rate} -vf drawtext=text=%{pts} {output} -f null {input}",
+		"vars": {"start": "duration", "input": "path", "rate": "bitrate", "output": "path"}
+	}`), &tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(tmpl.Placeholders(), " "); got != "start input rate output" {
+		t.Errorf("unexpected placeholders %q", got)
+	}
+
+	arg, err := tmpl.Execute(map[string]string{"start": "1m30s", "input": "in.mp4", "rate": "2500k", "output": "out.mp4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-ss 90 -i in.mp4 -c:v libx264 -b:v 2500k -vf drawtext=text=%{pts} out.mp4 -f null in.mp4"; arg != want {
+		t.Errorf("got %q, want %q", arg, want)
+	}
+
+	for _, values := range []map[string]string{
+		{"start": "90", "input": "in.mp4"},
+		{"start": "soon", "input": "in.mp4", "rate": "2500k", "output": "out.mp4"},
+		{"start": "00:01:30", "input": "in.mp4", "rate": "fast", "output": "out.mp4"},
+		{"start": "00:01:30", "input": "-y", "rate": "1M", "output": "out.mp4"},
+		{"start": "00:01:30", "input": "in.mp4", "rate": "1M", "output": "out.mp4 -y"},
+	} {
+		if _, err = tmpl.Execute(values); err == nil {
+			t.Errorf("%v: want error", values)
+		} else {
+			t.Log(err)
+		}
+	}
+
+	tmpl = ffmpeg.Template{Arg: "-vf drawtext=text={title} out.mp4", Vars: map[string]ffmpeg.VarType{"title": ffmpeg.FilterVar}}
+	if arg, err = tmpl.Execute(map[string]string{"title": "a,movie=x.mp4"}); err != nil || arg != `-vf drawtext=text=a\,movie=x.mp4 out.mp4` {
+		t.Errorf("unexpected filter arg %q: %v", arg, err)
+	}
+
+	b, err := json.Marshal(tmpl)
+	if err != nil || !strings.Contains(string(b), `"title":"filter"`) {
+		t.Errorf("unexpected JSON %s: %v", b, err)
+	}
+}
@@ -0,0 +1,116 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// A Timecode is a SMPTE timecode of a stream, e.g. of a QuickTime
+// timecode track or of the first GOP of a MPEG-2 video, as
+// reported in the "timecode" tags.
+type Timecode struct {
+	Stream int    // -1 for the container
+	Value  string // "HH:MM:SS:FF", or "HH:MM:SS;FF" for drop frame
+}
+
+// Timecodes returns the Timecodes of info, in stream order.
+func Timecodes(info *ProbeInfo) []Timecode {
+	var tcs []Timecode
+	if v := info.Format.Tags["timecode"]; v != "" {
+		tcs = append(tcs, Timecode{Stream: -1, Value: v})
+	}
+	for _, s := range info.Streams {
+		if v := s.Tags["timecode"]; v != "" {
+			tcs = append(tcs, Timecode{Stream: s.Index, Value: v})
+		}
+	}
+	return tcs
+}
+
+// A SideData is data attached to a decoded frame, e.g. an
+// H.264/H.265 SEI message, as reported by ffprobe.
+type SideData struct {
+	Type string // e.g. "SMPTE 12-1 timecode"
+
+	// Timecodes are the SMPTE timecodes of the frame, of a
+	// timecode SEI.
+	Timecodes []string
+
+	// Fields are the other fields reported, the strings as is
+	// and the other values as JSON.
+	Fields map[string]string
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (sd *SideData) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	*sd = SideData{}
+	for k, v := range fields {
+		switch k {
+		case "side_data_type":
+			if err := json.Unmarshal(v, &sd.Type); err != nil {
+				return err
+			}
+		case "timecodes":
+			var tcs []struct {
+				Value string `json:"value"`
+			}
+			if err := json.Unmarshal(v, &tcs); err != nil {
+				return err
+			}
+			for _, tc := range tcs {
+				sd.Timecodes = append(sd.Timecodes, tc.Value)
+			}
+		default:
+			if sd.Fields == nil {
+				sd.Fields = make(map[string]string)
+			}
+			var s string
+			if json.Unmarshal(v, &s) != nil {
+				s = string(v)
+			}
+			sd.Fields[k] = s
+		}
+	}
+	return nil
+}
+
+// A FrameSideData is a SideData with the frame it came with.
+type FrameSideData struct {
+	SideData
+	Stream int
+	PTS    time.Duration
+}
+
+// ProbeSideData calls f with the side data of every frame of the
+// video streams of input, as ProbeFrames does, e.g. to extract
+// the timecode or user data SEI messages.
+func ProbeSideData(ctx context.Context, probe, input string, f func(sd FrameSideData) error) error {
+	return ProbeFrames(ctx, probe, input, "v", func(fr ProbeFrame) error {
+		for _, sd := range fr.SideData {
+			if err := f(FrameSideData{SideData: sd, Stream: fr.StreamIndex, PTS: fr.PTS.Duration()}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FrameTimecodes returns the timecodes of the frames of the video
+// streams of input, from their timecode SEI, by PTS.
+func FrameTimecodes(ctx context.Context, probe, input string) ([]FrameSideData, error) {
+	var tcs []FrameSideData
+	err := ProbeSideData(ctx, probe, input, func(sd FrameSideData) error {
+		if len(sd.Timecodes) > 0 {
+			tcs = append(tcs, sd)
+		}
+		return nil
+	})
+	sort.SliceStable(tcs, func(i, j int) bool { return tcs[i].PTS < tcs[j].PTS })
+	return tcs, err
+}
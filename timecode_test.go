@@ -0,0 +1,67 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+const fakeSEI = `cat <<JSON
+{
+    "frames": [
+        {"media_type": "video", "stream_index": 0, "key_frame": 1, "pts_time": "0.000000",
+         "side_data_list": [
+             {"side_data_type": "SMPTE 12-1 timecode", "timecodes": [{"value": "10:00:00:00"}]},
+             {"side_data_type": "H.26[45] User Data Unregistered SEI message", "uuid": "dc45e9bd-e6d9-48b7-962c-d820d923eeef", "size": 42}
+         ]},
+        {"media_type": "video", "stream_index": 0, "key_frame": 0, "pts_time": "0.040000"},
+        {"media_type": "video", "stream_index": 0, "key_frame": 0, "pts_time": "0.080000",
+         "side_data_list": [{"side_data_type": "SMPTE 12-1 timecode", "timecodes": [{"value": "10:00:00;02"}]}]}
+    ]
+}
+JSON
+`
+
+func TestTimecodes(t *testing.T) {
+	info := &ffmpeg.ProbeInfo{
+		Format: ffmpeg.ProbeFormat{Tags: map[string]string{"timecode": "01:00:00:00"}},
+		Streams: []ffmpeg.ProbeStream{
+			{Index: 0, CodecType: "video"},
+			{Index: 1, CodecType: "data", Tags: map[string]string{"timecode": "01:00:00;00"}},
+		},
+	}
+	want := []ffmpeg.Timecode{{Stream: -1, Value: "01:00:00:00"}, {Stream: 1, Value: "01:00:00;00"}}
+	if tcs := ffmpeg.Timecodes(info); !reflect.DeepEqual(tcs, want) {
+		t.Errorf("got %+v, want %+v", tcs, want)
+	}
+}
+
+func TestProbeSideData(t *testing.T) {
+	p := fakeBinary(t, fakeSEI)
+	var sds []ffmpeg.FrameSideData
+	err := ffmpeg.ProbeSideData(context.TODO(), p, "in.mp4", func(sd ffmpeg.FrameSideData) error {
+		sds = append(sds, sd)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sds) != 3 {
+		t.Fatalf("unexpected side data %+v", sds)
+	}
+	if u := sds[1]; u.Type != "H.26[45] User Data Unregistered SEI message" ||
+		u.Fields["uuid"] != "dc45e9bd-e6d9-48b7-962c-d820d923eeef" || u.Fields["size"] != "42" {
+		t.Errorf("unexpected user data %+v", u)
+	}
+
+	tcs, err := ffmpeg.FrameTimecodes(context.TODO(), p, "in.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tcs) != 2 || tcs[0].Timecodes[0] != "10:00:00:00" || tcs[1].PTS != 80*time.Millisecond || tcs[1].Timecodes[0] != "10:00:00;02" {
+		t.Errorf("unexpected timecodes %+v", tcs)
+	}
+}
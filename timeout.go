@@ -0,0 +1,20 @@
+package ffmpeg
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeout is returned, wrapping the exit error if any, when
+// FFmpeg was stopped for running longer than its Timeout.
+var ErrTimeout = errors.New("ffmpeg: timeout")
+
+// Timeout stops FFmpeg with the exit hook (see DoneHook) if it
+// is still running after d, independently of the context given
+// to Run. The error returned then wraps ErrTimeout, while it
+// wraps ErrCancelled for a done context.
+func Timeout(d time.Duration) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.timeout = d
+	}
+}
@@ -0,0 +1,35 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestTimeout(t *testing.T) {
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("sleep"), ffmpeg.Timeout(50*time.Millisecond))
+
+	res, err := r.RunResult(context.TODO(), "10")
+	var ee *exec.ExitError
+	if !errors.Is(err, ffmpeg.ErrTimeout) || !errors.As(err, &ee) {
+		t.Errorf("want timeout exit error, got %v", err)
+	}
+	if !res.TimedOut || res.Cancelled {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	res, err = r.RunResult(ctx, "10")
+	if errors.Is(err, ffmpeg.ErrTimeout) || res.TimedOut || !res.Cancelled {
+		t.Errorf("want cancelled, got %+v: %v", res, err)
+	}
+
+	if err = r.Run(context.TODO(), "0"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,114 @@
+package ffmpeg
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HDR reports whether s is a HDR video, of the PQ (e.g. HDR10)
+// or HLG transfer.
+func (s ProbeStream) HDR() bool {
+	return s.ColorTransfer == "smpte2084" || s.ColorTransfer == "arib-std-b67"
+}
+
+// A ToneMap writes Input to Output with its first video in SDR
+// (BT.709) if it is HDR, see ProbeStream.HDR, or with its HDR
+// signalled again if KeepHDR. SDR videos are written as is.
+type ToneMap struct {
+	Input  string
+	Output string
+	Args   string // the output args, e.g. "-c:v libx264 -crf 20 -c:a copy"
+
+	// Algorithm is that of the tonemap filter, "hable" by
+	// default, e.g. "mobius" or "reinhard".
+	Algorithm string
+
+	// KeepHDR keeps a HDR video HDR, its colors, mastering
+	// display and content light level being set on the output:
+	// as -x265-params when Args use libx265, the colors only
+	// otherwise.
+	KeepHDR bool
+}
+
+// Filter returns the filters converting a HDR video to SDR:
+// zscale, which needs a FFmpeg with libzimg, linearizes it to
+// be tone mapped, then converts it to BT.709.
+func (t ToneMap) Filter() string {
+	alg := t.Algorithm
+	if alg == "" {
+		alg = "hable"
+	}
+	return "zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709," +
+		"tonemap=tonemap=" + alg + ":desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p"
+}
+
+// Arg returns the arg writing the Input described by info.
+func (t ToneMap) Arg(info *ProbeInfo) string {
+	args := []string{"-y -i", t.Input, "-map 0:v:0 -map 0:a?"}
+	if vs := info.StreamsOf("video"); len(vs) > 0 && vs[0].HDR() {
+		v := vs[0]
+		if t.KeepHDR {
+			args = append(args, "-pix_fmt yuv420p10le -color_primaries", v.ColorPrimaries,
+				"-color_trc", v.ColorTransfer, "-colorspace", v.ColorSpace)
+			if strings.Contains(t.Args, "libx265") {
+				args = append(args, "-x265-params", x265HDR(v))
+			}
+		} else {
+			args = append(args, "-filter:v:0", t.Filter(),
+				"-color_primaries bt709 -color_trc bt709 -colorspace bt709")
+		}
+	}
+	if t.Args != "" {
+		args = append(args, t.Args)
+	}
+	return strings.Join(append(args, t.Output), " ")
+}
+
+// Run probes the Input with the ffprobe binary at probe, writes
+// the Output with r and returns whether the Input is HDR.
+func (t ToneMap) Run(ctx context.Context, r Runner, probe string) (bool, error) {
+	info, err := Probe(ctx, probe, t.Input)
+	if err != nil {
+		return false, err
+	}
+	vs := info.StreamsOf("video")
+	return len(vs) > 0 && vs[0].HDR(), r.Run(ctx, t.Arg(info))
+}
+
+// x265HDR returns the x265 params signalling the HDR of s.
+func x265HDR(s ProbeStream) string {
+	ps := []string{"hdr-opt=1", "repeat-headers=1",
+		"colorprim=" + s.ColorPrimaries, "transfer=" + s.ColorTransfer, "colormatrix=" + s.ColorSpace}
+	for _, sd := range s.SideData {
+		f := sd.Fields
+		switch sd.Type {
+		case "Mastering display metadata":
+			// in units of 0.00002 for the chromaticities and
+			// 0.0001 cd/m² for the luminances
+			c := func(k string) string { return scaled(f[k], 50000) }
+			l := func(k string) string { return scaled(f[k], 10000) }
+			ps = append(ps, "master-display=G("+c("green_x")+","+c("green_y")+")B("+c("blue_x")+","+c("blue_y")+
+				")R("+c("red_x")+","+c("red_y")+")WP("+c("white_point_x")+","+c("white_point_y")+
+				")L("+l("max_luminance")+","+l("min_luminance")+")")
+		case "Content light level metadata":
+			ps = append(ps, "max-cll="+f["max_content"]+","+f["max_average"])
+		}
+	}
+	return strings.Join(ps, ":")
+}
+
+// scaled returns the rational r, e.g. "35400/50000", times unit.
+func scaled(r string, unit float64) string {
+	num, den, ok := strings.Cut(r, "/")
+	n, _ := strconv.ParseFloat(num, 64)
+	d := 1.0
+	if ok {
+		d, _ = strconv.ParseFloat(den, 64)
+	}
+	if d == 0 {
+		return "0"
+	}
+	return strconv.FormatInt(int64(math.Round(n/d*unit)), 10)
+}
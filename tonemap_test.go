@@ -0,0 +1,52 @@
+package ffmpeg_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+const hdr10 = `{"codec_type": "video", "color_space": "bt2020nc", "color_transfer": "smpte2084", "color_primaries": "bt2020",
+	"side_data_list": [
+		{"side_data_type": "Mastering display metadata",
+			"red_x": "34000/50000", "red_y": "16000/50000", "green_x": "13250/50000", "green_y": "34500/50000",
+			"blue_x": "7500/50000", "blue_y": "3000/50000", "white_point_x": "15635/50000", "white_point_y": "16450/50000",
+			"min_luminance": "50/10000", "max_luminance": "10000000/10000"},
+		{"side_data_type": "Content light level metadata", "max_content": 1000, "max_average": 400}
+	]}`
+
+func TestToneMapArg(t *testing.T) {
+	var v ffmpeg.ProbeStream
+	if err := json.Unmarshal([]byte(hdr10), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.HDR() || (ffmpeg.ProbeStream{ColorTransfer: "bt709"}).HDR() {
+		t.Error("unexpected HDR")
+	}
+	hdr := &ffmpeg.ProbeInfo{Streams: []ffmpeg.ProbeStream{v}}
+	sdr := &ffmpeg.ProbeInfo{Streams: []ffmpeg.ProbeStream{{CodecType: "video", ColorTransfer: "bt709"}}}
+
+	for _, c := range []struct {
+		t    ffmpeg.ToneMap
+		info *ffmpeg.ProbeInfo
+		want string
+	}{
+		{ffmpeg.ToneMap{Args: "-c:v libx264"}, hdr,
+			"-y -i in.mkv -map 0:v:0 -map 0:a? -filter:v:0 zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709," +
+				"tonemap=tonemap=hable:desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p " +
+				"-color_primaries bt709 -color_trc bt709 -colorspace bt709 -c:v libx264 out.mp4"},
+		{ffmpeg.ToneMap{Args: "-c:v libx264"}, sdr, "-y -i in.mkv -map 0:v:0 -map 0:a? -c:v libx264 out.mp4"},
+		{ffmpeg.ToneMap{Args: "-c:v libx265", KeepHDR: true}, hdr,
+			"-y -i in.mkv -map 0:v:0 -map 0:a? -pix_fmt yuv420p10le -color_primaries bt2020 -color_trc smpte2084 -colorspace bt2020nc " +
+				"-x265-params hdr-opt=1:repeat-headers=1:colorprim=bt2020:transfer=smpte2084:colormatrix=bt2020nc:" +
+				"master-display=G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50):max-cll=1000,400 -c:v libx265 out.mp4"},
+		{ffmpeg.ToneMap{Args: "-c:v libsvtav1", KeepHDR: true}, hdr,
+			"-y -i in.mkv -map 0:v:0 -map 0:a? -pix_fmt yuv420p10le -color_primaries bt2020 -color_trc smpte2084 -colorspace bt2020nc -c:v libsvtav1 out.mp4"},
+	} {
+		c.t.Input, c.t.Output = "in.mkv", "out.mp4"
+		if got := c.t.Arg(c.info); got != c.want {
+			t.Errorf("got  %q\nwant %q", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package ffmpeg
+
+// A Tool is a binary of the FFmpeg project.
+type Tool string
+
+// The Tools a HookedRunner runs.
+const (
+	FFmpeg  Tool = "ffmpeg"
+	FFprobe Tool = "ffprobe"
+	FFplay  Tool = "ffplay" // e.g. for local previews
+)
+
+// ToolRunner returns a HookedRunner running tool, searched in
+// the PATH unless CustomPath is given, with the hooks, exit
+// handling, events and errors of HookRunner. The progress
+// options (ProgressPeriod, ProgressSocket) are FFmpeg's only
+// and ignored for the other tools.
+func ToolRunner(tool Tool, opts ...func(r *HookedRunner)) *HookedRunner {
+	return HookRunner(append([]func(r *HookedRunner){func(r *HookedRunner) {
+		r.tool = tool
+		r.paths = []string{string(tool)}
+	}}, opts...)...)
+}
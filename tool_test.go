@@ -0,0 +1,40 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestToolRunner(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	probe := filepath.Join(dir, "ffprobe")
+	if err := os.WriteFile(probe, []byte("#!/bin/sh\necho \"$@\" > "+args+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	var started bool
+	r := ffmpeg.ToolRunner(ffmpeg.FFprobe, ffmpeg.ProgressPeriod(time.Second), ffmpeg.ProgressSocket("unix"),
+		ffmpeg.Subscribe(func(e ffmpeg.Event) {
+			_, ok := e.(ffmpeg.Started)
+			started = started || ok
+		}))
+	if err := r.Run(context.TODO(), "-show_format in.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(args); string(b) != "-show_format in.mp4\n" || !started {
+		t.Errorf("unexpected args %q without the progress options", b)
+	}
+
+	if err := ffmpeg.ToolRunner(ffmpeg.FFplay).Run(context.TODO(), "in.mp4"); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("want ffplay not found, got %v", err)
+	}
+}
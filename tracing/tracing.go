@@ -0,0 +1,125 @@
+/*
+Package tracing instruments ffmpeg runs with OpenTelemetry
+spans.
+*/
+package tracing
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/practigo/ffmpeg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TailLines is the number of stderr lines recorded on
+// the span of a failed run.
+const TailLines = 20
+
+// A Runner creates a span for every run of FFmpeg.
+type Runner struct {
+	tracer trace.Tracer
+	opts   []func(r *ffmpeg.HookedRunner)
+}
+
+// New returns a Runner creating spans with tracer around
+// runs of a HookedRunner built with opts.
+func New(tracer trace.Tracer, opts ...func(r *ffmpeg.HookedRunner)) *Runner {
+	return &Runner{tracer: tracer, opts: opts}
+}
+
+// Run starts a span as a child of the one in ctx, if any, runs
+// FFmpeg with the span in its context and ends the span once
+// FFmpeg exited. The span records the command line redacted
+// by the Redactor of the runner, see ffmpeg.WithRedactor, the
+// pid and the exit code, the ffmpeg.Labels of ctx as
+// "ffmpeg.label.<key>", and the tail of stderr when the run
+// fails.
+func (t *Runner) Run(ctx context.Context, arg string) error {
+	ctx, span := t.tracer.Start(ctx, "ffmpeg", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	tail := &tail{}
+	r := ffmpeg.HookRunner(append(t.opts[:len(t.opts):len(t.opts)],
+		ffmpeg.Subscribe(func(e ffmpeg.Event) {
+			switch e := e.(type) {
+			case ffmpeg.Resolved: // redacted already
+				span.SetAttributes(attribute.String("ffmpeg.command", ffmpeg.CommandLine(exec.Command(e.Path, e.Args...))))
+			case ffmpeg.Started:
+				span.SetAttributes(attribute.Int("process.pid", e.Pid))
+			case ffmpeg.Exited:
+				if e.Code >= 0 {
+					span.SetAttributes(attribute.Int("process.exit_code", e.Code))
+				}
+			}
+		}),
+		ffmpeg.LineHook(tail.add))...)
+
+	span.SetAttributes(attribute.String("ffmpeg.args", r.Redactor().String(arg)))
+	span.SetAttributes(labels(ctx)...)
+
+	err := r.Run(ctx, arg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if s := tail.String(); s != "" {
+			span.AddEvent("stderr", trace.WithAttributes(attribute.String("tail", s)))
+		}
+	}
+	return err
+}
+
+func labels(ctx context.Context) []attribute.KeyValue {
+	var kvs []attribute.KeyValue
+	for k, v := range ffmpeg.LabelsFrom(ctx) {
+		kvs = append(kvs, attribute.String("ffmpeg.label."+k, v))
+	}
+	return kvs
+}
+
+type tail struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (t *tail) add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.lines) == TailLines {
+		t.lines = t.lines[1:]
+	}
+	t.lines = append(t.lines, line)
+}
+
+func (t *tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.lines, "\n")
+}
+
+// Middleware returns a ffmpeg.Middleware starting a span with
+// tracer around every run of any Runner, its arg redacted by
+// rd, e.g. the ffmpeg.DefaultRedactor, or not if nil. Unlike a
+// Runner from New, it only sees the arg and the error of the
+// runs.
+func Middleware(tracer trace.Tracer, rd *ffmpeg.Redactor) ffmpeg.Middleware {
+	return func(next ffmpeg.Runner) ffmpeg.Runner {
+		return ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error {
+			ctx, span := tracer.Start(ctx, "ffmpeg")
+			defer span.End()
+			span.SetAttributes(attribute.String("ffmpeg.args", rd.String(arg)))
+			span.SetAttributes(labels(ctx)...)
+
+			err := next.Run(ctx, arg)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		})
+	}
+}
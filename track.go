@@ -0,0 +1,55 @@
+package ffmpeg
+
+import (
+	"context"
+	"time"
+)
+
+// A TrackedProgress is a Progress with the completion of the
+// run, see Track.
+type TrackedProgress struct {
+	Progress
+	Total   time.Duration // of the output, 0 if unknown
+	Percent float64       // from 0 to 100, 0 if Total is unknown
+	ETA     time.Duration // the estimated time left, 0 if unknown
+}
+
+// Track calls f with a TrackedProgress for every Progress of
+// the runs, which write an output of duration total. A zero
+// total, e.g. for live inputs, only reports the raw Progress.
+func Track(total time.Duration, f func(tp TrackedProgress)) func(r *HookedRunner) {
+	return Subscribe(func(e Event) {
+		if p, ok := e.(Progress); ok {
+			f(track(p, total))
+		}
+	})
+}
+
+func track(p Progress, total time.Duration) TrackedProgress {
+	tp := TrackedProgress{Progress: p, Total: total}
+	if total <= 0 {
+		return tp
+	}
+	done := p.Time
+	if done > total {
+		done = total
+	}
+	tp.Percent = 100 * float64(done) / float64(total)
+	if p.Speed > 0 {
+		tp.ETA = time.Duration(float64(total-done) / p.Speed)
+	}
+	return tp
+}
+
+// TrackedRun probes the duration of input with the ffprobe at
+// probe, then runs FFmpeg with arg and a HookedRunner built
+// with opts, calling f as Track does. Inputs without duration,
+// such as live ones, are tracked with a zero Total.
+func TrackedRun(ctx context.Context, probe, input, arg string, f func(tp TrackedProgress), opts ...func(r *HookedRunner)) error {
+	info, err := Probe(ctx, probe, input)
+	if err != nil {
+		return err
+	}
+	opts = append(opts[:len(opts):len(opts)], Track(info.Format.Duration.Duration(), f))
+	return HookRunner(opts...).Run(ctx, arg)
+}
@@ -0,0 +1,42 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestTrackedRun(t *testing.T) {
+	p := fakeBinary(t, `echo "frame=  75 fps= 25 time=00:00:03.00 speed=2x" >&2
+echo "frame= 250 fps= 25 time=00:00:10.00 speed=2x" >&2
+`)
+	var tps []ffmpeg.TrackedProgress
+	err := ffmpeg.TrackedRun(context.TODO(), fakeBinary(t, fakeProbe), "in.mp4", "-i in.mp4 out.mp4",
+		func(tp ffmpeg.TrackedProgress) { tps = append(tps, tp) }, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tps) != 2 {
+		t.Fatalf("want 2 progress, got %+v", tps)
+	}
+	if tp := tps[0]; tp.Total != 10*time.Second || tp.Percent != 30 || tp.ETA != 3500*time.Millisecond || tp.Frame != 75 {
+		t.Errorf("unexpected progress %+v", tp)
+	}
+	if tp := tps[1]; tp.Percent != 100 || tp.ETA != 0 {
+		t.Errorf("unexpected progress %+v", tp)
+	}
+}
+
+func TestTrackUnknown(t *testing.T) {
+	p := fakeBinary(t, `echo "frame=  75 fps= 25 time=00:00:03.00 speed=1x" >&2`+"\n")
+	var tp ffmpeg.TrackedProgress
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.Track(0, func(p ffmpeg.TrackedProgress) { tp = p }))
+	if err := r.Run(context.TODO(), "-i rtmp://live/app out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if tp.Time != 3*time.Second || tp.Percent != 0 || tp.ETA != 0 {
+		t.Errorf("unexpected progress %+v", tp)
+	}
+}
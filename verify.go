@@ -0,0 +1,127 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Checks are the assertions Verify makes on an output. The zero
+// value only checks that the output can be probed, has streams
+// and a positive duration.
+type Checks struct {
+	// Duration is the expected duration, within Tolerance.
+	// Input, if set, is probed for it when Duration is 0.
+	Duration  time.Duration
+	Tolerance time.Duration
+	Input     string
+
+	// AnyDuration skips the duration checks, e.g. for images.
+	AnyDuration bool
+
+	VideoCodec string // of the first video stream, e.g. "h264"
+	AudioCodec string // of the first audio stream, e.g. "aac"
+	Width      int
+	Height     int
+
+	// Streams is the expected number of streams by type,
+	// e.g. {"video": 1, "audio": 2}.
+	Streams map[string]int
+}
+
+// A ValidationError lists the failed Checks of an output.
+type ValidationError struct {
+	Path     string
+	Failures []string
+}
+
+func (e *ValidationError) Error() string {
+	return "ffmpeg: " + e.Path + " failed checks: " + strings.Join(e.Failures, "; ")
+}
+
+// Verify probes output with the ffprobe binary at probe and
+// returns a *ValidationError if it fails c.
+func Verify(ctx context.Context, probe, output string, c Checks) error {
+	info, err := Probe(ctx, probe, output)
+	if err != nil {
+		return &ValidationError{Path: output, Failures: []string{"not playable: " + err.Error()}}
+	}
+
+	var fs []string
+	fail := func(format string, a ...interface{}) {
+		fs = append(fs, fmt.Sprintf(format, a...))
+	}
+
+	if len(info.Streams) == 0 {
+		fail("no streams")
+	}
+
+	if !c.AnyDuration {
+		d := info.Format.Duration.Duration()
+		want := c.Duration
+		if want == 0 && c.Input != "" {
+			in, err := Probe(ctx, probe, c.Input)
+			if err != nil {
+				return err
+			}
+			want = in.Format.Duration.Duration()
+		}
+		switch {
+		case d <= 0:
+			fail("no duration")
+		case want > 0 && (d < want-c.Tolerance || d > want+c.Tolerance):
+			fail("duration %s, want %s±%s", d, want, c.Tolerance)
+		}
+	}
+
+	video, audio := info.StreamsOf("video"), info.StreamsOf("audio")
+	if c.VideoCodec != "" || c.Width > 0 || c.Height > 0 {
+		if len(video) == 0 {
+			fail("no video stream")
+		} else {
+			v := video[0]
+			if c.VideoCodec != "" && v.CodecName != c.VideoCodec {
+				fail("video codec %s, want %s", v.CodecName, c.VideoCodec)
+			}
+			if c.Width > 0 && v.Width != c.Width {
+				fail("width %d, want %d", v.Width, c.Width)
+			}
+			if c.Height > 0 && v.Height != c.Height {
+				fail("height %d, want %d", v.Height, c.Height)
+			}
+		}
+	}
+	if c.AudioCodec != "" {
+		if len(audio) == 0 {
+			fail("no audio stream")
+		} else if audio[0].CodecName != c.AudioCodec {
+			fail("audio codec %s, want %s", audio[0].CodecName, c.AudioCodec)
+		}
+	}
+
+	types := make([]string, 0, len(c.Streams))
+	for typ := range c.Streams {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		if got, n := len(info.StreamsOf(typ)), c.Streams[typ]; got != n {
+			fail("%d %s streams, want %d", got, typ, n)
+		}
+	}
+
+	if len(fs) > 0 {
+		return &ValidationError{Path: output, Failures: fs}
+	}
+	return nil
+}
+
+// Validator returns a func verifying outputs against c with the
+// ffprobe binary at probe, for AtomicOutput.
+func (c Checks) Validator(probe string) func(path string) error {
+	return func(path string) error {
+		return Verify(context.Background(), probe, path, c)
+	}
+}
@@ -0,0 +1,43 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestVerify(t *testing.T) {
+	probe := fakeBinary(t, fakeProbe)
+	ok := ffmpeg.Checks{
+		Input:      "in.mp4",
+		Tolerance:  500 * time.Millisecond,
+		VideoCodec: "h264",
+		Width:      1280,
+		Height:     720,
+		Streams:    map[string]int{"video": 1, "audio": 1},
+	}
+	if err := ffmpeg.Verify(context.TODO(), probe, "out.mp4", ok); err != nil {
+		t.Error(err)
+	}
+
+	bad := ffmpeg.Checks{
+		Duration:   10 * time.Second,
+		Tolerance:  500 * time.Millisecond,
+		AudioCodec: "opus",
+		Height:     1080,
+		Streams:    map[string]int{"subtitle": 1},
+	}
+	err := ffmpeg.Verify(context.TODO(), probe, "short.mp4", bad)
+	var ve *ffmpeg.ValidationError
+	if !errors.As(err, &ve) || len(ve.Failures) != 4 {
+		t.Fatalf("want 4 failures, got %v", err)
+	}
+	t.Log(err)
+
+	if err = ffmpeg.Verify(context.TODO(), probe, "out.txt", ffmpeg.Checks{}); !errors.As(err, &ve) {
+		t.Errorf("want ValidationError, got %v", err)
+	}
+}
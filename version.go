@@ -0,0 +1,179 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionInfo describes a FFmpeg build as reported by -version.
+type VersionInfo struct {
+	Raw   string // the version string, e.g. "4.4.2-0ubuntu0.22.04.1"
+	Major int
+	Minor int
+	Patch int
+
+	// Dev is set for builds from git (e.g. "N-109421-g1234abcd"),
+	// which carry no release number and are assumed to be newer
+	// than any release.
+	Dev bool
+
+	// Configuration holds the flags the binary was configured
+	// with, e.g. "--enable-libfdk-aac".
+	Configuration []string
+}
+
+var versionRe = regexp.MustCompile(`^n?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// Version runs the FFmpeg binary at path (as found by exec.LookPath)
+// with -version and parses its output.
+func Version(ctx context.Context, path string) (*VersionInfo, error) {
+	b, err := output(ctx, path, "-version")
+	if err != nil {
+		return nil, err
+	}
+	return parseVersion(b)
+}
+
+func parseVersion(b []byte) (*VersionInfo, error) {
+	v := &VersionInfo{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "configuration:") {
+			v.Configuration = strings.Fields(strings.TrimPrefix(line, "configuration:"))
+			continue
+		}
+		fs := strings.Fields(line)
+		if len(fs) < 3 || fs[1] != "version" || v.Raw != "" {
+			continue
+		}
+		v.Raw = fs[2]
+		if m := versionRe.FindStringSubmatch(v.Raw); m != nil {
+			v.Major, _ = strconv.Atoi(m[1])
+			v.Minor, _ = strconv.Atoi(m[2])
+			v.Patch, _ = strconv.Atoi(m[3])
+		} else {
+			v.Dev = true
+		}
+	}
+	if v.Raw == "" {
+		return nil, fmt.Errorf("ffmpeg: no version found in -version output")
+	}
+	return v, nil
+}
+
+// AtLeast reports whether v is major.minor.patch or newer.
+func (v *VersionInfo) AtLeast(major, minor, patch int) bool {
+	if v.Dev {
+		return true
+	}
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+// Enabled reports whether the binary was configured with
+// --enable-<lib>, e.g. Enabled("libfdk-aac").
+func (v *VersionInfo) Enabled(lib string) bool {
+	for _, c := range v.Configuration {
+		if c == "--enable-"+lib {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports reports whether f is available in v.
+func (v *VersionInfo) Supports(f Feature) bool {
+	return v.AtLeast(f.Major, f.Minor, 0)
+}
+
+func (v *VersionInfo) String() string {
+	if v.Dev {
+		return v.Raw
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// A Feature is an option only available from a FFmpeg release on.
+type Feature struct {
+	Name  string
+	Major int
+	Minor int
+}
+
+// Features gated on the FFmpeg version.
+var (
+	StatsPeriod     = Feature{"-stats_period", 4, 4}
+	DisplayRotation = Feature{"-display_rotation", 6, 0}
+	RTSPTimeout     = Feature{"-timeout", 5, 0} // of RTSP inputs, -stimeout before
+)
+
+// A VersionError is returned by a HookedRunner with a MinVersion
+// when the binary found is older.
+type VersionError struct {
+	Have *VersionInfo
+	Want string
+}
+
+func (e *VersionError) Error() string {
+	return "ffmpeg: version " + e.Have.String() + " is older than " + e.Want
+}
+
+// MinVersion refuses to run binaries older than major.minor.patch
+// with a *VersionError, trying the next candidate of CustomPath if
+// any. The version is detected on the first Run and cached per
+// binary path.
+func MinVersion(major, minor, patch int) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		r.minVer = []int{major, minor, patch}
+	}
+}
+
+// version returns the version of the binary at path, detected
+// once per path.
+func (r *HookedRunner) version(ctx context.Context, path string) (*VersionInfo, error) {
+	r.mu.Lock()
+	v, ok := r.versions[path]
+	r.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := Version(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	if r.versions == nil {
+		r.versions = make(map[string]*VersionInfo)
+	}
+	r.versions[path] = v
+	r.mu.Unlock()
+	return v, nil
+}
+
+// checkVersion checks the binary at path against r.minVer.
+func (r *HookedRunner) checkVersion(ctx context.Context, path string) error {
+	v, err := r.version(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if !v.AtLeast(r.minVer[0], r.minVer[1], r.minVer[2]) {
+		return &VersionError{
+			Have: v,
+			Want: fmt.Sprintf("%d.%d.%d", r.minVer[0], r.minVer[1], r.minVer[2]),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+const fakeVersion = `if [ "$1" = "-version" ]; then cat <<EOF
+ffmpeg version 4.3.1-0ubuntu1 Copyright (c) 2000-2020 the FFmpeg developers
+built with gcc 10 (Ubuntu 10.2.0-13ubuntu1)
+configuration: --prefix=/usr --enable-gpl --enable-libfdk-aac --enable-libx264
+libavutil      56. 51.100 / 56. 51.100
+EOF
+fi
+`
+
+func TestVersion(t *testing.T) {
+	v, err := ffmpeg.Version(context.TODO(), fakeBinary(t, fakeVersion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Major != 4 || v.Minor != 3 || v.Patch != 1 || v.Dev {
+		t.Errorf("unexpected version: %+v", v)
+	}
+	if !v.Enabled("libfdk-aac") || v.Enabled("libx265") {
+		t.Errorf("unexpected configuration: %v", v.Configuration)
+	}
+	if !v.AtLeast(4, 3, 0) || v.AtLeast(4, 3, 2) || v.Supports(ffmpeg.StatsPeriod) {
+		t.Errorf("unexpected comparison for %s", v)
+	}
+}
+
+func TestVersionDev(t *testing.T) {
+	p := fakeBinary(t, "echo 'ffmpeg version N-109421-g1234abcd Copyright (c) 2000-2023'\n")
+	v, err := ffmpeg.Version(context.TODO(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Dev || !v.Supports(ffmpeg.StatsPeriod) {
+		t.Errorf("unexpected version: %+v", v)
+	}
+}
+
+func TestMinVersion(t *testing.T) {
+	p := fakeBinary(t, fakeVersion)
+
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.MinVersion(4, 4, 0))
+	err := r.Run(context.TODO(), "-i in.mp4 out.mp4")
+	var ve *ffmpeg.VersionError
+	if !errors.As(err, &ve) {
+		t.Fatalf("want VersionError, got %v", err)
+	}
+	t.Log(err)
+
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.MinVersion(4, 0, 0))
+	if err = r.Run(context.TODO(), "-i in.mp4 out.mp4"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCandidatePaths(t *testing.T) {
+	old := fakeBinary(t, fakeVersion)
+	recent := fakeBinary(t, "echo 'ffmpeg version 6.1.1-static https://johnvansickle.com/ffmpeg/'\n")
+
+	var used string
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath("/opt/ffmpeg7/bin/ffmpeg", old, recent),
+		ffmpeg.MinVersion(4, 4, 0),
+		ffmpeg.DryRun(func(cmd *exec.Cmd) { used = cmd.Path }))
+	if err := r.Run(context.TODO(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if used != recent {
+		t.Errorf("want %s, used %s", recent, used)
+	}
+
+	r = ffmpeg.HookRunner(ffmpeg.CustomPath("/opt/ffmpeg7/bin/ffmpeg", old), ffmpeg.MinVersion(4, 4, 0))
+	var ve *ffmpeg.VersionError
+	if err := r.Run(context.TODO(), ""); !errors.As(err, &ve) {
+		t.Errorf("want VersionError, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+/*
+Package watch transcodes the files dropped in a directory, a
+hot folder, with a ffmpeg Runner.
+*/
+package watch
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/practigo/ffmpeg"
+)
+
+// A File is the data the templates of a Watcher are executed
+// with, for an input such as "/in/clip.mov".
+type File struct {
+	Input  string // "/in/clip.mov"
+	Dir    string // "/in"
+	Name   string // "clip.mov"
+	Base   string // "clip"
+	Ext    string // ".mov"
+	Output string // the executed Output template
+}
+
+// A Watcher runs FFmpeg for every new file of a directory.
+type Watcher struct {
+	dir    string
+	runner ffmpeg.Runner
+	arg    *template.Template
+	output *template.Template
+
+	pattern string
+	stable  time.Duration
+	poll    time.Duration
+	moveTo  string
+	remove  bool
+	onError func(input string, err error)
+	err     error // of the options
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+	outputs  map[string]bool // absolute, not handled as inputs
+	wg       sync.WaitGroup
+}
+
+// New returns a Watcher of dir running r with arg, a
+// text/template executed with a File, e.g.
+// "-i {{.Input}} -c:v libx264 {{.Output}}". The args are
+// split on spaces, the paths should not have any.
+func New(dir string, r ffmpeg.Runner, arg string, opts ...func(w *Watcher)) (*Watcher, error) {
+	t, err := template.New("arg").Parse(arg)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		dir:      dir,
+		runner:   r,
+		arg:      t,
+		stable:   2 * time.Second,
+		poll:     500 * time.Millisecond,
+		inFlight: make(map[string]bool),
+		outputs:  make(map[string]bool),
+	}
+
+	for _, o := range opts {
+		o(w)
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	return w, nil
+}
+
+// Output sets the template of the output path given to the arg
+// template as {{.Output}}, e.g. "/out/{{.Base}}.mp4". The
+// outputs written in the watched directory are not handled as
+// inputs, but those of a previous Run are: write them in
+// another directory, or exclude them with Pattern.
+func Output(tmpl string) func(w *Watcher) {
+	return func(w *Watcher) {
+		t, err := template.New("output").Parse(tmpl)
+		if err != nil {
+			w.err = err
+			return
+		}
+		w.output = t
+	}
+}
+
+// Pattern only handles the files whose name matches pattern,
+// see filepath.Match, e.g. "*.mov".
+func Pattern(pattern string) func(w *Watcher) {
+	return func(w *Watcher) {
+		w.pattern = pattern
+	}
+}
+
+// Stable sets for how long the size and modification time of
+// a file must be unchanged before it is handled, so that files
+// still being written are not, 2s by default. The files are
+// checked every 10ms at least.
+func Stable(d time.Duration) func(w *Watcher) {
+	return func(w *Watcher) {
+		w.stable = d
+		if d < w.poll {
+			w.poll = max(d, 10*time.Millisecond)
+		}
+	}
+}
+
+// MoveTo moves the inputs to dir once transcoded.
+func MoveTo(dir string) func(w *Watcher) {
+	return func(w *Watcher) {
+		w.moveTo = dir
+	}
+}
+
+// Remove deletes the inputs once transcoded.
+func Remove() func(w *Watcher) {
+	return func(w *Watcher) {
+		w.remove = true
+	}
+}
+
+// OnError calls h when an input fails to be transcoded or
+// moved, the input is then left in place and not retried until
+// the Watcher is run again. The input is empty for the errors
+// of the directory watch itself.
+func OnError(h func(input string, err error)) func(w *Watcher) {
+	return func(w *Watcher) {
+		w.onError = h
+	}
+}
+
+// Run handles the files already in the directory, then the new
+// ones, until ctx is done. It then waits for the runs, which
+// are cancelled with ctx, and returns ctx.Err().
+func (w *Watcher) Run(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+	if err = fw.Add(w.dir); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			w.handle(ctx, filepath.Join(w.dir, e.Name()))
+		}
+	}
+
+	defer w.wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-fw.Events:
+			if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) {
+				w.handle(ctx, ev.Name)
+			}
+		case err := <-fw.Errors:
+			w.fail("", err)
+		}
+	}
+}
+
+// handle starts handling input unless already in flight.
+func (w *Watcher) handle(ctx context.Context, input string) {
+	if w.pattern != "" {
+		if ok, _ := filepath.Match(w.pattern, filepath.Base(input)); !ok {
+			return
+		}
+	}
+	w.mu.Lock()
+	if w.inFlight[input] || w.outputs[abs(input)] {
+		w.mu.Unlock()
+		return
+	}
+	w.inFlight[input] = true
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		done, err := w.transcode(ctx, input)
+		if err != nil {
+			w.fail(input, err)
+		}
+		gone := err == nil && (w.moveTo != "" || w.remove)
+		if !done || gone {
+			// may come back
+			w.mu.Lock()
+			delete(w.inFlight, input)
+			w.mu.Unlock()
+		}
+	}()
+}
+
+// transcode waits for input to be stable and runs FFmpeg for it.
+// It reports whether input was handled, even if unsuccessfully.
+func (w *Watcher) transcode(ctx context.Context, input string) (bool, error) {
+	if !w.waitStable(ctx, input) {
+		return false, nil
+	}
+
+	f := File{
+		Input: input,
+		Dir:   filepath.Dir(input),
+		Name:  filepath.Base(input),
+		Ext:   filepath.Ext(input),
+	}
+	f.Base = strings.TrimSuffix(f.Name, f.Ext)
+	if w.output != nil {
+		var buf bytes.Buffer
+		if err := w.output.Execute(&buf, f); err != nil {
+			return true, err
+		}
+		f.Output = buf.String()
+		w.mu.Lock()
+		w.outputs[abs(f.Output)] = true
+		w.mu.Unlock()
+	}
+	var buf bytes.Buffer
+	if err := w.arg.Execute(&buf, f); err != nil {
+		return true, err
+	}
+
+	if err := w.runner.Run(ctx, buf.String()); err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		return true, err
+	}
+
+	switch {
+	case w.moveTo != "":
+		return true, os.Rename(input, filepath.Join(w.moveTo, f.Name))
+	case w.remove:
+		return true, os.Remove(input)
+	}
+	return true, nil
+}
+
+// waitStable reports whether input exists and had the same size
+// and modification time for w.stable.
+func (w *Watcher) waitStable(ctx context.Context, input string) bool {
+	var last os.FileInfo
+	since := time.Now()
+	t := time.NewTicker(w.poll)
+	defer t.Stop()
+	for {
+		fi, err := os.Stat(input)
+		if err != nil || !fi.Mode().IsRegular() {
+			return false
+		}
+		if last == nil || fi.Size() != last.Size() || !fi.ModTime().Equal(last.ModTime()) {
+			last, since = fi, time.Now()
+		} else if time.Since(since) >= w.stable {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+	}
+}
+
+func abs(path string) string {
+	if p, err := filepath.Abs(path); err == nil {
+		return p
+	}
+	return path
+}
+
+func (w *Watcher) fail(input string, err error) {
+	if w.onError != nil {
+		w.onError(input, err)
+	}
+}
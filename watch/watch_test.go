@@ -0,0 +1,130 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/practigo/ffmpeg"
+	"github.com/practigo/ffmpeg/ffmpegtest"
+	"github.com/practigo/ffmpeg/watch"
+)
+
+func TestWatcher(t *testing.T) {
+	in, done := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(in, "old.mov"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := ffmpegtest.NewMock().Respond("bad.mov", ffmpegtest.Response{Err: errors.New("invalid data")})
+	var mu sync.Mutex
+	var failed []string
+	w, err := watch.New(in, m, "-i {{.Input}} -c:v libx264 {{.Output}}",
+		watch.Output("/out/{{.Base}}.mp4"),
+		watch.Pattern("*.mov"),
+		watch.Stable(50*time.Millisecond),
+		watch.MoveTo(done),
+		watch.OnError(func(input string, err error) {
+			mu.Lock()
+			failed = append(failed, filepath.Base(input))
+			mu.Unlock()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	for _, name := range []string{"new.mov", "bad.mov", "skip.txt"} {
+		if err = os.WriteFile(filepath.Join(in, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(m.Calls()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err = <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+
+	want := map[string]bool{
+		"-i " + filepath.Join(in, "old.mov") + " -c:v libx264 /out/old.mp4": true,
+		"-i " + filepath.Join(in, "new.mov") + " -c:v libx264 /out/new.mp4": true,
+		"-i " + filepath.Join(in, "bad.mov") + " -c:v libx264 /out/bad.mp4": true,
+	}
+	args := m.Args()
+	if len(args) != len(want) {
+		t.Fatalf("unexpected runs %q", args)
+	}
+	for _, a := range args {
+		if !want[a] {
+			t.Errorf("unexpected run %q", a)
+		}
+	}
+
+	for _, name := range []string{"old.mov", "new.mov"} {
+		if _, err = os.Stat(filepath.Join(done, name)); err != nil {
+			t.Errorf("%s not moved: %v", name, err)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(in, "bad.mov")); err != nil {
+		t.Errorf("failed input not left in place: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 1 || failed[0] != "bad.mov" {
+		t.Errorf("unexpected failures %q", failed)
+	}
+}
+
+func TestWatcherOutputs(t *testing.T) {
+	if _, err := watch.New(t.TempDir(), ffmpegtest.NewMock(), "", watch.Output("{{.Base")); err == nil {
+		t.Error("want a template error")
+	}
+
+	in := t.TempDir()
+	var mu sync.Mutex
+	var runs []string
+	r := ffmpeg.RunnerFunc(func(ctx context.Context, arg string) error {
+		mu.Lock()
+		runs = append(runs, arg)
+		mu.Unlock()
+		fs := strings.Fields(arg)
+		return os.WriteFile(fs[len(fs)-1], []byte("x"), 0644)
+	})
+	w, err := watch.New(in, r, "-i {{.Input}} {{.Output}}",
+		watch.Output(filepath.Join(in, "{{.Base}}-out.mp4")),
+		watch.Stable(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	errc := make(chan error, 1)
+	go func() { errc <- w.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	if err = os.WriteFile(filepath.Join(in, "a.mp4"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-errc
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) != 1 {
+		t.Errorf("want the output not handled, got runs %q", runs)
+	}
+}
@@ -0,0 +1,81 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Peaks are the min and max samples of the consecutive windows
+// of an audio, in the JSON format of audiowaveform for the web
+// players such as peaks.js.
+type Peaks struct {
+	Version         int     `json:"version"`
+	Channels        int     `json:"channels"`
+	SampleRate      int     `json:"sample_rate"`
+	SamplesPerPixel int     `json:"samples_per_pixel"`
+	Bits            int     `json:"bits"`
+	Length          int     `json:"length"` // of the windows
+	Data            []int16 `json:"data"`   // min, max, min, max...
+}
+
+// Waveform returns the Peaks of the audio of input downmixed
+// to mono at sampleRate (44.1kHz if 0), in windows of
+// samplesPerPixel samples (512 if 0), running FFmpeg with a
+// HookedRunner built with opts.
+func Waveform(ctx context.Context, input string, sampleRate, samplesPerPixel int, opts ...func(r *HookedRunner)) (*Peaks, error) {
+	if sampleRate == 0 {
+		sampleRate = 44100
+	}
+	if samplesPerPixel == 0 {
+		samplesPerPixel = 512
+	}
+	ar, err := ReadAudio(ctx, input, AudioOptions{Format: S16LE, SampleRate: sampleRate, Channels: 1}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer ar.Close()
+
+	p := &Peaks{Version: 2, Channels: 1, SampleRate: sampleRate, SamplesPerPixel: samplesPerPixel, Bits: 16}
+	br := bufio.NewReader(ar)
+	buf := make([]byte, 2)
+	var lo, hi int16
+	n := 0
+	for {
+		if _, err = io.ReadFull(br, buf); err != nil {
+			break
+		}
+		s := int16(binary.LittleEndian.Uint16(buf))
+		if n == 0 || s < lo {
+			lo = s
+		}
+		if n == 0 || s > hi {
+			hi = s
+		}
+		if n++; n == samplesPerPixel {
+			p.Data, n = append(p.Data, lo, hi), 0
+		}
+	}
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if n > 0 {
+		p.Data = append(p.Data, lo, hi)
+	}
+	p.Length = len(p.Data) / 2
+	return p, nil
+}
+
+// WaveformImage renders the waveform of the audio of input to
+// the image output, e.g. a PNG, of width x height with the
+// showwavespic filter, in color if not empty (e.g. "0x2196f3").
+func WaveformImage(ctx context.Context, r Runner, input, output string, width, height int, color string) error {
+	filter := "showwavespic=s=" + strconv.Itoa(width) + "x" + strconv.Itoa(height) + ":split_channels=0"
+	if color != "" {
+		filter += ":colors=" + EscapeFilterValue(color)
+	}
+	return r.Run(ctx, strings.Join([]string{"-i", input, "-filter_complex", "[0:a:0]" + filter, "-frames:v 1 -y", output}, " "))
+}
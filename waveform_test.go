@@ -0,0 +1,41 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestWaveform(t *testing.T) {
+	// 5 s16le samples: 1, -2, 300, -4000, 5
+	p := fakeBinary(t, `printf '\001\000\376\377\054\001\140\360\005\000'`+"\n")
+	peaks, err := ffmpeg.Waveform(context.TODO(), "in.mp3", 8000, 2, ffmpeg.CustomPath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ffmpeg.Peaks{Version: 2, Channels: 1, SampleRate: 8000, SamplesPerPixel: 2, Bits: 16,
+		Length: 3, Data: []int16{-2, 1, -4000, 300, 5, 5}}
+	if !reflect.DeepEqual(peaks, want) {
+		t.Errorf("got %+v, want %+v", peaks, want)
+	}
+	if b, _ := json.Marshal(peaks); string(b) != `{"version":2,"channels":1,"sample_rate":8000,"samples_per_pixel":2,"bits":16,"length":3,"data":[-2,1,-4000,300,5,5]}` {
+		t.Errorf("unexpected JSON %s", b)
+	}
+
+	if _, err = ffmpeg.Waveform(context.TODO(), "in.mp3", 0, 0, ffmpeg.CustomPath(fakeBinary(t, "exit 1\n"))); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestWaveformImage(t *testing.T) {
+	p, last := argsBinary(t)
+	if err := ffmpeg.WaveformImage(context.TODO(), ffmpeg.HookRunner(ffmpeg.CustomPath(p)), "in.mp3", "wave.png", 800, 120, "0x2196f3"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "-i in.mp3 -filter_complex [0:a:0]showwavespic=s=800x120:split_channels=0:colors=0x2196f3 -frames:v 1 -y wave.png"; last() != want {
+		t.Errorf("got %q, want %q", last(), want)
+	}
+}
@@ -0,0 +1,164 @@
+package ffmpeg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// WorkspaceVar is replaced in the args by the workspace path of
+// the run, see TempWorkspace.
+const WorkspaceVar = "{workspace}"
+
+const (
+	workspacePrefix = "ffmpeg-ws-"
+	ownerFile       = ".owner"
+)
+
+// A Workspace is a temporary directory for the scratch files of
+// runs, e.g. pass log files, segments or palettes.
+type Workspace struct {
+	Dir string
+}
+
+// NewWorkspace creates a Workspace in root, or in os.TempDir()
+// if root is empty. The workspace records the pid of the
+// process, for CleanStale to remove it if the process died
+// without closing it.
+func NewWorkspace(root string) (*Workspace, error) {
+	dir, err := os.MkdirTemp(root, workspacePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return own(dir)
+}
+
+// own records the pid of the process in the new workspace dir,
+// removing it on failure.
+func own(dir string) (*Workspace, error) {
+	owner := []byte(strconv.Itoa(os.Getpid()))
+	if err := os.WriteFile(filepath.Join(dir, ownerFile), owner, 0644); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &Workspace{Dir: dir}, nil
+}
+
+// Path returns the path of name in the workspace.
+func (w *Workspace) Path(name ...string) string {
+	return filepath.Join(append([]string{w.Dir}, name...)...)
+}
+
+// File creates a new empty file in the workspace, see
+// os.CreateTemp for pattern, and returns its path.
+func (w *Workspace) File(pattern string) (string, error) {
+	f, err := os.CreateTemp(w.Dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// PassLogFile returns the prefix for -passlogfile, for 2-pass
+// encodes.
+func (w *Workspace) PassLogFile() string {
+	return w.Path("passlog")
+}
+
+// Close removes the workspace and all its files.
+func (w *Workspace) Close() error {
+	return os.RemoveAll(w.Dir)
+}
+
+// CleanStale removes the workspaces in root (os.TempDir() if
+// empty) whose process is not running anymore, e.g. because it
+// was killed, and returns their paths.
+func CleanStale(root string) ([]string, error) {
+	if root == "" {
+		root = os.TempDir()
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), workspacePrefix) {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		b, err := os.ReadFile(filepath.Join(dir, ownerFile))
+		if err != nil {
+			continue // being created, or not a workspace
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+		if err != nil || pid == os.Getpid() || processAlive(pid) {
+			continue
+		}
+		if err = os.RemoveAll(dir); err != nil {
+			return removed, err
+		}
+		removed = append(removed, dir)
+	}
+	return removed, nil
+}
+
+// TempWorkspace gives each run a Workspace in root (os.TempDir()
+// if empty), replacing WorkspaceVar in the args by its path, e.g.
+// "-passlogfile {workspace}/pass". The path is set by Plan, so
+// that the logs, events and DryRun show it, and the workspace is
+// created right before FFmpeg starts, then removed once FFmpeg
+// exited, whether it succeeded or not.
+func TempWorkspace(root string) func(r *HookedRunner) {
+	return func(r *HookedRunner) {
+		if root == "" {
+			root = os.TempDir()
+		}
+		var tok [4]byte
+		rand.Read(tok[:])
+		// tells the workspaces of the option from the others
+		prefix := filepath.Join(root, workspacePrefix+hex.EncodeToString(tok[:])+"-")
+		var seq atomic.Int64
+
+		r.rewrites = append(r.rewrites, func(args []string) []string {
+			dir := ""
+			for i, a := range args {
+				if strings.Contains(a, WorkspaceVar) {
+					if dir == "" {
+						dir = prefix + strconv.FormatInt(seq.Add(1), 10)
+					}
+					args[i] = strings.ReplaceAll(a, WorkspaceVar, dir)
+				}
+			}
+			return args
+		})
+		r.setups = append(r.setups, func(cmd *exec.Cmd) (func(), error) {
+			for _, a := range cmd.Args {
+				i := strings.Index(a, prefix)
+				if i < 0 {
+					continue
+				}
+				j := i + len(prefix)
+				for j < len(a) && '0' <= a[j] && a[j] <= '9' {
+					j++
+				}
+				dir := a[i:j]
+				if err := os.Mkdir(dir, 0700); err != nil {
+					return nil, err
+				}
+				ws, err := own(dir)
+				if err != nil {
+					return nil, err
+				}
+				return func() { ws.Close() }, nil
+			}
+			return func() {}, nil
+		})
+	}
+}
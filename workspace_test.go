@@ -0,0 +1,84 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/practigo/ffmpeg"
+)
+
+func TestTempWorkspace(t *testing.T) {
+	root := t.TempDir()
+	p := fakeBinary(t, `touch "$2-0.log" && echo "$2" > `+root+"/args\n")
+	var resolved []string
+	r := ffmpeg.HookRunner(ffmpeg.CustomPath(p), ffmpeg.TempWorkspace(root),
+		ffmpeg.Subscribe(func(e ffmpeg.Event) {
+			if e, ok := e.(ffmpeg.Resolved); ok {
+				resolved = e.Args
+			}
+		}))
+	if err := r.Run(context.TODO(), "-passlogfile {workspace}/pass -i in.mp4 out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := strings.TrimSpace(string(b))
+	if filepath.Dir(filepath.Dir(prefix)) != root {
+		t.Errorf("pass log file %s not in a workspace of %s", prefix, root)
+	}
+	if len(resolved) < 2 || resolved[1] != prefix {
+		t.Errorf("resolved args %q, want the pass log file %s", resolved, prefix)
+	}
+	if _, err = os.Stat(filepath.Dir(prefix)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("workspace not removed: %v", err)
+	}
+
+	// planned only, the workspace is not created
+	cmd, err := r.Plan(context.TODO(), "-passlogfile {workspace}/pass -i in.mp4 out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cmd.Args[2], ffmpeg.WorkspaceVar) || filepath.Dir(filepath.Dir(cmd.Args[2])) != root {
+		t.Errorf("unexpected planned arg %s", cmd.Args[2])
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 1 { // args
+		t.Errorf("unexpected files in %s: %v", root, entries)
+	}
+}
+
+func TestCleanStale(t *testing.T) {
+	root := t.TempDir()
+	live, err := ffmpeg.NewWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+
+	dead := exec.Command("true")
+	if err = dead.Run(); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(root, "ffmpeg-ws-stale")
+	os.Mkdir(stale, 0755)
+	os.WriteFile(filepath.Join(stale, ".owner"), []byte(strconv.Itoa(dead.Process.Pid)), 0644)
+
+	removed, err := ffmpeg.CleanStale(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != stale {
+		t.Errorf("unexpected removed workspaces %q", removed)
+	}
+	if _, err = os.Stat(live.Path(".owner")); err != nil {
+		t.Errorf("live workspace removed: %v", err)
+	}
+}